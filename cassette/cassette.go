@@ -0,0 +1,299 @@
+// Package cassette records the HTTP interactions of a client with Harbor to
+// a file, and replays them later without a server, so that integration
+// tests can run offline:
+//
+//	rec, err := cassette.New("testdata/projects.json", cassette.ModeAuto)
+//	c, err := client.New(client.WithBaseURL(url), client.WithTransportHook(rec.Wrap))
+//	...
+//	err = rec.Stop()
+//
+// Credentials are scrubbed from the recorded interactions: the
+// Authorization, Cookie and CSRF headers, the values of the session cookies,
+// and the password fields of the JSON and form bodies, e.g. of user.User and
+// user.UpdatePassword.
+package cassette
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Mode tells whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// ModeRecord sends the requests and records the interactions.
+	ModeRecord Mode = iota
+	// ModeReplay answers the requests with the recorded interactions.
+	ModeReplay
+	// ModeAuto replays the cassette if it exists, and records it otherwise.
+	ModeAuto
+)
+
+// Redacted replaces the scrubbed values.
+const Redacted = "REDACTED"
+
+// Cassette is the content of a cassette file.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a request and the response of the server.
+type Interaction struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded request. The host of the URL is not matched when
+// replaying, so that the cassette can be replayed against any base URL.
+type Request struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// Response is a recorded response.
+type Response struct {
+	Status  int         `json:"status"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+	// "base64" when Body is not text
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// Recorder is an http.RoundTripper recording or replaying a cassette.
+type Recorder struct {
+	// Keys of the JSON and form fields whose values are scrubbed, at any
+	// depth. Defaults to DefaultScrubKeys.
+	ScrubKeys []string
+
+	path string
+	mode Mode
+	next http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// DefaultScrubKeys are the fields scrubbed by default: passwords, secrets,
+// and the tokens returned by the token endpoint and on robot creation.
+var DefaultScrubKeys = []string{"password", "old_password", "new_password", "secret", "token", "access_token", "refresh_token"}
+
+// sensitiveHeaders are removed from the recorded requests.
+var sensitiveHeaders = []string{"Authorization", "Cookie", "X-Harbor-Csrf-Token", "Proxy-Authorization"}
+
+// New returns a Recorder of the cassette at path. In replay mode, the
+// cassette is read at once.
+func New(path string, mode Mode) (*Recorder, error) {
+	r := &Recorder{path: path, mode: mode, next: http.DefaultTransport}
+	if mode == ModeAuto {
+		r.mode = ModeRecord
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		}
+	}
+	if r.mode == ModeReplay {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the mode of the recorder, ModeAuto being resolved.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Wrap makes the recorder send the requests it records through rt, and
+// returns it. It is meant for client.WithTransportHook.
+func (r *Recorder) Wrap(rt http.RoundTripper) http.RoundTripper {
+	if rt != nil {
+		r.next = rt
+	}
+	return r
+}
+
+// Stop writes the recorded interactions to the cassette file. It does
+// nothing in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.path, append(data, '\n'), 0644)
+}
+
+// RoundTrip records or replays req.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	recorded := r.request(req, body)
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+
+	resp, err := r.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{Request: recorded, Response: r.response(resp, respBody)}
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// readBody reads the body of req and restores it for the transport.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// request returns the scrubbed and canonical form of req.
+func (r *Recorder) request(req *http.Request, body []byte) Request {
+	u := *req.URL
+	u.Scheme, u.Host, u.User = "", "", nil
+	u.RawQuery = u.Query().Encode()
+	headers := req.Header.Clone()
+	for _, h := range sensitiveHeaders {
+		headers.Del(h)
+	}
+	headers.Del("Content-Length")
+	return Request{
+		Method:  req.Method,
+		URL:     u.String(),
+		Headers: headers,
+		Body:    r.scrubBody(req.Header.Get("Content-Type"), body),
+	}
+}
+
+func (r *Recorder) response(resp *http.Response, body []byte) Response {
+	headers := resp.Header.Clone()
+	if cookies := headers["Set-Cookie"]; len(cookies) != 0 {
+		scrubbed := make([]string, 0, len(cookies))
+		for _, c := range cookies {
+			scrubbed = append(scrubbed, scrubCookie(c))
+		}
+		headers["Set-Cookie"] = scrubbed
+	}
+	headers.Del("X-Harbor-Csrf-Token")
+	// The body is recorded scrubbed and canonical, its length is computed
+	// again when replayed
+	headers.Del("Content-Length")
+	rec := Response{Status: resp.StatusCode, Headers: headers}
+	if utf8.Valid(body) {
+		rec.Body = r.scrubBody(resp.Header.Get("Content-Type"), body)
+	} else {
+		rec.Body = base64.StdEncoding.EncodeToString(body)
+		rec.Encoding = "base64"
+	}
+	return rec
+}
+
+// scrubCookie redacts the value of a Set-Cookie header, keeping the name
+// and attributes of the cookie.
+func scrubCookie(c string) string {
+	i := strings.Index(c, "=")
+	if i < 0 {
+		return c
+	}
+	rest := ""
+	if j := strings.Index(c, ";"); j > i {
+		rest = c[j:]
+	}
+	return c[:i+1] + Redacted + rest
+}
+
+// replay answers req with the first unused interaction matching it. Once
+// every matching interaction has been used, the last one is used again, so
+// that polls and retries can be replayed.
+func (r *Recorder) replay(req *http.Request, recorded Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	last := -1
+	for i, interaction := range r.cassette.Interactions {
+		if !interaction.Request.matches(recorded) {
+			continue
+		}
+		last = i
+		if !r.used[i] {
+			break
+		}
+	}
+	if last < 0 {
+		return nil, fmt.Errorf("cassette: no interaction recorded in %s for %s %s", r.path, recorded.Method, recorded.URL)
+	}
+	r.used[last] = true
+	rec := r.cassette.Interactions[last].Response
+	body := []byte(rec.Body)
+	if rec.Encoding == "base64" {
+		var err error
+		if body, err = base64.StdEncoding.DecodeString(rec.Body); err != nil {
+			return nil, fmt.Errorf("cassette: %s: %w", r.path, err)
+		}
+	}
+	header := rec.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", rec.Status, http.StatusText(rec.Status)),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}, nil
+}
+
+// matches reports whether two requests are the same, both being canonical.
+func (req Request) matches(o Request) bool {
+	return req.Method == o.Method && req.URL == o.URL && req.Body == o.Body
+}
@@ -0,0 +1,204 @@
+package cassette_test
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/cassette"
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/user"
+	"github.com/parnurzeal/gorequest"
+)
+
+// tempDir returns a directory removed at the end of the test.
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "harbor-cassette")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// userCalls creates a user, changes its password and lists the users.
+func userCalls(t *testing.T, c *client.Client) []user.User {
+	t.Helper()
+	s := user.NewUserService(c)
+	if _, errs := s.Create(&user.User{Username: "dev", Password: "Passw0rd", Email: "dev@example.com", Realname: "Dev"}); len(errs) != 0 {
+		t.Fatalf("Create: %v", errs)
+	}
+	users, _, errs := s.List()
+	if len(errs) != 0 || len(users) != 2 {
+		t.Fatalf("List = %+v, %v", users, errs)
+	}
+	if _, errs := s.ChangePassword(users[1].UserID, user.UpdatePassword{OldPassword: "Passw0rd", NewPassword: "N3wPassw0rd"}); len(errs) != 0 {
+		t.Fatalf("ChangePassword: %v", errs)
+	}
+	return users
+}
+
+func TestRecordAndReplayUsers(t *testing.T) {
+	path := filepath.Join(tempDir(t), "users.json")
+	srv := harbortest.Start(t)
+
+	rec, err := cassette.New(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeRecord {
+		t.Fatalf("mode %v without cassette, want record", rec.Mode())
+	}
+	recorded := userCalls(t, srv.Client(client.WithTransportHook(rec.Wrap)))
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"Passw0rd", "N3wPassw0rd", harbortest.AdminPassword, "Basic "} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("the cassette contains %q:\n%s", secret, data)
+		}
+	}
+	var c cassette.Cassette
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	bodies := map[string]string{}
+	for _, i := range c.Interactions {
+		bodies[i.Request.Method+" "+i.Request.URL] = i.Request.Body
+		if i.Request.Headers.Get("Content-Length") != "" || i.Response.Headers.Get("Content-Length") != "" {
+			t.Errorf("%s %s recorded with a Content-Length", i.Request.Method, i.Request.URL)
+		}
+	}
+	var created user.User
+	if err := json.Unmarshal([]byte(bodies["POST /api/users"]), &created); err != nil || created.Password != cassette.Redacted || created.Username != "dev" {
+		t.Errorf("user.User recorded as %s", bodies["POST /api/users"])
+	}
+	var password user.UpdatePassword
+	body := bodies["PUT /api/users/"+strconv.Itoa(recorded[1].UserID)+"/password"]
+	if err := json.Unmarshal([]byte(body), &password); err != nil || password != (user.UpdatePassword{OldPassword: cassette.Redacted, NewPassword: cassette.Redacted}) {
+		t.Errorf("user.UpdatePassword recorded as %q", body)
+	}
+
+	// Replayed without server, the scrubbed bodies matching the requests
+	srv.Close()
+	rec, err = cassette.New(path, cassette.ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != cassette.ModeReplay {
+		t.Fatalf("mode %v with a cassette, want replay", rec.Mode())
+	}
+	replayed := userCalls(t, srv.Client(client.WithTransportHook(rec.Wrap)))
+	if replayed[1].UserID != recorded[1].UserID || replayed[1].Username != "dev" {
+		t.Errorf("replayed users %+v, recorded %+v", replayed, recorded)
+	}
+}
+
+func TestRecordTokenAuth(t *testing.T) {
+	path := filepath.Join(tempDir(t), "registry.json")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/service/token" {
+			w.Write([]byte(`{"token":"registry-token","access_token":"registry-token","refresh_token":"refresh-token","expires_in":300}`))
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer registry-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name":"library/nginx","tags":["1.19"]}`))
+	}))
+	defer srv.Close()
+
+	rec, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := client.New(client.WithBaseURL(srv.URL), client.WithTransportHook(rec.Wrap),
+		client.WithAuthenticator(client.NewTokenAuth(client.BasicAuth{Username: "admin", Password: harbortest.AdminPassword})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The registry API is served outside of the API root
+	req := c.NewRequest(gorequest.GET, "")
+	req.Url = srv.URL + "/v2/library/nginx/tags/list"
+	if _, errs := c.Do(context.Background(), req, nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"registry-token", "refresh-token", harbortest.AdminPassword} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("the cassette contains %q:\n%s", secret, data)
+		}
+	}
+	var recorded cassette.Cassette
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(recorded.Interactions); n != 2 || !strings.HasPrefix(recorded.Interactions[0].Request.URL, "/service/token?") {
+		t.Fatalf("recorded %d interactions: %s", n, data)
+	}
+	want := `{"access_token":"REDACTED","expires_in":300,"refresh_token":"REDACTED","token":"REDACTED"}`
+	if body := recorded.Interactions[0].Response.Body; body != want {
+		t.Errorf("token response recorded as %s, want %s", body, want)
+	}
+}
+
+func TestReplayContentLength(t *testing.T) {
+	path := filepath.Join(tempDir(t), "projects.json")
+	// Recorded before the body was canonicalised, with a stale length
+	c := cassette.Cassette{Interactions: []cassette.Interaction{{
+		Request: cassette.Request{Method: http.MethodGet, URL: "/api/projects/1"},
+		Response: cassette.Response{
+			Status:  http.StatusOK,
+			Headers: http.Header{"Content-Type": {"application/json"}, "Content-Length": {"1024"}},
+			Body:    `{"name":"library","project_id":1}`,
+		},
+	}}}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://harbor.example.com/api/projects/1", nil)
+	resp, err := rec.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := ioutil.ReadAll(resp.Body)
+	if want := strconv.Itoa(len(body)); resp.Header.Get("Content-Length") != want || resp.ContentLength != int64(len(body)) {
+		t.Errorf("Content-Length %q, %d; want %s", resp.Header.Get("Content-Length"), resp.ContentLength, want)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, "http://harbor.example.com/api/projects/2", nil)
+	if _, err := rec.RoundTrip(req); err == nil {
+		t.Error("replayed a request that was not recorded")
+	}
+}
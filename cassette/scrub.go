@@ -0,0 +1,71 @@
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/url"
+	"strings"
+)
+
+// scrubBody redacts the fields of a JSON or form body named by the scrub
+// keys, and returns it in a canonical form, the JSON keys being sorted.
+func (r *Recorder) scrubBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	keys := r.ScrubKeys
+	if keys == nil {
+		keys = DefaultScrubKeys
+	}
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-www-form-urlencoded" {
+		if form, err := url.ParseQuery(string(body)); err == nil {
+			for key := range form {
+				if scrubbed(keys, key) {
+					form[key] = []string{Redacted}
+				}
+			}
+			return form.Encode()
+		}
+	}
+	// Numbers are kept as written, IDs exceeding the precision of float64
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return string(body)
+	}
+	data, err := json.Marshal(scrubJSON(keys, v))
+	if err != nil {
+		return string(body)
+	}
+	return string(data)
+}
+
+func scrubJSON(keys []string, v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok && s != "" && scrubbed(keys, key) {
+				v[key] = Redacted
+			} else {
+				v[key] = scrubJSON(keys, value)
+			}
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = scrubJSON(keys, v[i])
+		}
+	}
+	return v
+}
+
+func scrubbed(keys []string, key string) bool {
+	for _, k := range keys {
+		if strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	return false
+}
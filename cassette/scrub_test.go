@@ -0,0 +1,42 @@
+package cassette
+
+import "testing"
+
+func TestScrubBody(t *testing.T) {
+	r := &Recorder{}
+	for _, tt := range []struct {
+		contentType string
+		body        string
+		want        string
+	}{
+		{"application/json", `{"username":"dev","password":"Passw0rd"}`, `{"password":"REDACTED","username":"dev"}`},
+		{"application/json", `{"old_password":"a","new_password":"b"}`, `{"new_password":"REDACTED","old_password":"REDACTED"}`},
+		{"application/json", `[{"credential":{"secret":"s","id":12345678901234567890}}]`, `[{"credential":{"id":12345678901234567890,"secret":"REDACTED"}}]`},
+		// An empty password tells it is not set, and is kept
+		{"application/json", `{"password":""}`, `{"password":""}`},
+		{"application/x-www-form-urlencoded", "principal=admin&password=Harbor12345", "password=REDACTED&principal=admin"},
+		{"text/plain", "password=Harbor12345 not JSON", "password=Harbor12345 not JSON"},
+		{"application/json", "", ""},
+	} {
+		if got := r.scrubBody(tt.contentType, []byte(tt.body)); got != tt.want {
+			t.Errorf("scrubBody(%s, %s) = %s, want %s", tt.contentType, tt.body, got, tt.want)
+		}
+	}
+
+	r.ScrubKeys = []string{"token"}
+	if got, want := r.scrubBody("application/json", []byte(`{"password":"p","Token":"t"}`)), `{"Token":"REDACTED","password":"p"}`; got != want {
+		t.Errorf("scrubBody with custom keys = %s, want %s", got, want)
+	}
+}
+
+func TestScrubCookie(t *testing.T) {
+	for c, want := range map[string]string{
+		"sid=abc123; Path=/; HttpOnly": "sid=REDACTED; Path=/; HttpOnly",
+		"sid=abc123":                   "sid=REDACTED",
+		"malformed":                    "malformed",
+	} {
+		if got := scrubCookie(c); got != want {
+			t.Errorf("scrubCookie(%q) = %q, want %q", c, got, want)
+		}
+	}
+}
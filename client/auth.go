@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Authenticator adds the credentials of the caller to the requests of a
+// Client.
+type Authenticator interface {
+	Authenticate(ctx context.Context, c *Client, req *http.Request) error
+}
+
+// AuthObserver is implemented by the Authenticators whose credentials can
+// expire. Observe is called with the response to every request authenticated
+// by the Authenticator; when it returns true, the request is authenticated and
+// sent once more.
+type AuthObserver interface {
+	Observe(req *http.Request, resp *http.Response) bool
+}
+
+// AuthCloser is implemented by the Authenticators holding resources on the
+// server, released by Client.Close.
+type AuthCloser interface {
+	Close(ctx context.Context, c *Client) error
+}
+
+// WithAuthenticator authenticates every request with a.
+func WithAuthenticator(a Authenticator) Option {
+	return func(c *Client) error {
+		if a == nil {
+			return errors.New("harbor: nil authenticator")
+		}
+		c.auth = a
+		return nil
+	}
+}
+
+// BasicAuth authenticates the requests with a username and a password.
+type BasicAuth struct {
+	Username string
+	Password string
+}
+
+func (a BasicAuth) Authenticate(ctx context.Context, c *Client, req *http.Request) error {
+	req.SetBasicAuth(a.Username, a.Password)
+	return nil
+}
+
+// robotPrefix is the default prefix of the names of robot accounts.
+const robotPrefix = "robot$"
+
+// RobotAuth returns the Authenticator of a robot account. The robot$ prefix
+// is added to name unless it already carries one.
+func RobotAuth(name, secret string) Authenticator {
+	if !strings.Contains(name, "$") {
+		name = robotPrefix + name
+	}
+	return BasicAuth{Username: name, Password: secret}
+}
+
+// OIDCAuth returns the Authenticator of a user of a Harbor using OIDC, which
+// accepts the CLI secret of the user, shown in their profile, in place of a
+// password.
+func OIDCAuth(username, cliSecret string) Authenticator {
+	return BasicAuth{Username: username, Password: cliSecret}
+}
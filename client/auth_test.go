@@ -0,0 +1,69 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestRobotAuth(t *testing.T) {
+	for name, want := range map[string]string{
+		"ci":          "robot$ci",
+		"robot$ci":    "robot$ci",
+		"bot$library": "bot$library",
+	} {
+		if got := RobotAuth(name, "s3cret"); got != (BasicAuth{Username: want, Password: "s3cret"}) {
+			t.Errorf("RobotAuth(%q) = %+v, want user %s", name, got, want)
+		}
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://harbor.example.com/api/projects", nil)
+	if err := RobotAuth("ci", "s3cret").Authenticate(context.Background(), nil, req); err != nil {
+		t.Fatal(err)
+	}
+	if username, password, ok := req.BasicAuth(); !ok || username != "robot$ci" || password != "s3cret" {
+		t.Errorf("basic auth %q %q %v", username, password, ok)
+	}
+}
+
+func TestWithNilAuthenticator(t *testing.T) {
+	if _, err := New(WithAuthenticator(nil)); err == nil {
+		t.Error("New with a nil authenticator succeeded")
+	}
+}
+
+func TestConfigAuthenticator(t *testing.T) {
+	for _, tt := range []struct {
+		auth string
+		want Authenticator
+	}{
+		{"", BasicAuth{Username: "admin", Password: "Harbor12345"}},
+		{"basic", BasicAuth{Username: "admin", Password: "Harbor12345"}},
+		{"robot", BasicAuth{Username: "robot$admin", Password: "Harbor12345"}},
+		{"oidc", BasicAuth{Username: "admin", Password: "Harbor12345"}},
+		{"session", NewSession("admin", "Harbor12345")},
+		{"docker", NewDockerConfigAuth("/etc/docker/config.json")},
+	} {
+		config := viper.New()
+		config.Set("management.user.name", "admin")
+		config.Set("management.user.password", "Harbor12345")
+		config.Set("management.user.auth", tt.auth)
+		config.Set("management.user.docker_config", "/etc/docker/config.json")
+		got := configAuthenticator(config)
+		if s, ok := got.(*Session); ok {
+			// The cookie jars differ
+			if s.username != "admin" || s.password != "Harbor12345" {
+				t.Errorf("auth %q: session %+v", tt.auth, s)
+			}
+			if _, ok := tt.want.(*Session); !ok {
+				t.Errorf("auth %q: got a session, want %T", tt.auth, tt.want)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("auth %q: %#v, want %#v", tt.auth, got, tt.want)
+		}
+	}
+}
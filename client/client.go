@@ -1,12 +1,20 @@
 package client
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+
 	"github.com/codingXiang/configer"
-	"github.com/codingXiang/go-logger"
 	"github.com/parnurzeal/gorequest"
 	"github.com/spf13/viper"
-	"net/url"
-	"strings"
 )
 
 const (
@@ -18,12 +26,23 @@ type ClientInterface interface {
 	GetClient() *gorequest.SuperAgent
 	GetConfig() *viper.Viper
 	GetUserAgent() string
+	GetAPIVersion() APIVersion
+	NegotiateAPIVersion(ctx context.Context) (APIVersion, error)
 	NewRequest(method string, subPath string) *gorequest.SuperAgent
+	Do(ctx context.Context, req *gorequest.SuperAgent, v interface{}) (*gorequest.Response, []error)
+	SetRetryPolicy(p *RetryPolicy)
+	SetRateLimiter(l RateLimiter)
+	SetMaxInFlight(n int)
+	LimiterStats() LimiterStats
+	Close() error
 }
 
 type Client struct {
 	// HTTP client used to communicate with the API.
 	client *gorequest.SuperAgent
+	// HTTP client used to send the requests built by client, bound to the
+	// caller's context.
+	httpClient *http.Client
 	// Base URL for API requests. Defaults to the public GitLab API, but can be
 	// set to a domain endpoint to use with a self hosted GitLab server. baseURL
 	// should always be specified with a trailing slash.
@@ -31,6 +50,23 @@ type Client struct {
 	config  *viper.Viper
 	// User agent used when communicating with the GitLab API.
 	userAgent string
+	// Retry policy applied to every request, nil disables retries.
+	retryPolicy *RetryPolicy
+	// Rate limiter and in-flight cap shared by every request.
+	limiter *limiter
+	// Generation of the Harbor API the client talks to. When it is
+	// APIVersionAuto, the detected one or the failure to detect it, and the
+	// pending detection, closed once done.
+	apiVersion   APIVersion
+	negotiated   APIVersion
+	negotiateErr error
+	negotiating  chan struct{}
+	versionMu    sync.Mutex
+	logger       Logger
+	// Credentials added to every request, nil for anonymous access.
+	auth Authenticator
+	// Transport settings of the options, applied by New.
+	transport *transportOptions
 }
 
 // ListOptions specifies the optional parameters to various List methods that
@@ -43,23 +79,17 @@ type ListOptions struct {
 	PageSize int `url:"page_size,omitempty" json:"page_size,omitempty"`
 }
 
+// NewClient creates a client from the YAML configuration read by config, see
+// config/harbor.yaml. It logs and returns nil when the configuration cannot
+// be used; New reports the error instead.
 func NewClient(config configer.CoreInterface) ClientInterface {
-	return newClient(config)
-}
-
-// SetBaseURL sets the base URL for API requests to a custom endpoint. urlStr
-// should always be specified with a trailing slash.
-func (c *Client) setBaseURL(urlStr string) error {
-	// Make sure the given URL end with a slash
-	if !strings.HasSuffix(urlStr, "/") {
-		urlStr += "/"
+	if c := newClient(config); c != nil {
+		return c
 	}
-	var err error
-	c.baseURL, err = url.Parse(urlStr)
-	return err
+	return nil
 }
 
-// GetClient 取得 client
+// GetClient 取得 client，回傳的 agent 為所有 request 的範本，建立後請勿再修改
 func (c *Client) GetClient() *gorequest.SuperAgent {
 	return c.client
 }
@@ -74,29 +104,102 @@ func (c *Client) GetUserAgent() string {
 	return c.userAgent
 }
 
+// GetAPIVersion 取得 API 版本，不會向 Harbor 查詢；設定為 APIVersionAuto 且尚未查詢時回傳 APIVersionAuto，
+// 需要確定版本時請使用 NegotiateAPIVersion
+func (c *Client) GetAPIVersion() APIVersion {
+	if c.apiVersion != APIVersionAuto {
+		return c.apiVersion
+	}
+	c.versionMu.Lock()
+	defer c.versionMu.Unlock()
+	if c.negotiated != "" {
+		return c.negotiated
+	}
+	return APIVersionAuto
+}
+
 func newClient(config configer.CoreInterface) *Client {
-	if data, err := config.ReadConfig(nil); err == nil {
-		harborClient := gorequest.New()
-		c := &Client{client: harborClient, userAgent: userAgent, config: data}
-		var (
-			baseURL  = data.GetString("ingress.protocol") + "://" + data.GetString("ingress.domain")
-			username = data.GetString("management.user.name")
-			password = data.GetString("management.user.password")
-		)
-		// 設定基礎驗證
-		harborClient.SetBasicAuth(username, password)
-		// 設定 harbor 位置
-		if err := c.setBaseURL(baseURL); err == nil {
-			logger.Log.Info("Harbor Server 設定完成，位置為", baseURL)
-		} else {
-			logger.Log.Error(err.Error())
+	data, err := config.ReadConfig(nil)
+	if err != nil {
+		globalLogger{}.Error("設定組態檔發生錯誤", err.Error())
+		return nil
+	}
+	var (
+		baseURL = data.GetString("ingress.protocol") + "://" + data.GetString("ingress.domain")
+		opts    = []Option{
+			WithConfig(data),
+			// 設定 harbor 位置
+			WithBaseURL(baseURL),
+			// 設定驗證方式
+			WithAuthenticator(configAuthenticator(data)),
 		}
-		return c
-	} else {
-		logger.Log.Error("設定組態檔發生錯誤", err.Error())
+	)
+	if transport, ok := configTransport(data); ok {
+		opts = append(opts, WithTransportConfig(transport))
+	}
+	if version := data.GetString("api.version"); version != "" {
+		opts = append(opts, WithAPIVersion(APIVersion(version)))
+	}
+	c, err := New(opts...)
+	if err != nil {
+		globalLogger{}.Error(err.Error())
 		return nil
 	}
+	c.logger.Info("Harbor Server 設定完成，位置為", baseURL)
+	return c
+}
+
+// configAuthenticator returns the Authenticator selected by the
+// management.user.auth key of config: basic (the default), session, robot,
+// oidc or docker. The latter reads the credentials saved by docker login, so
+// that config holds no secret.
+func configAuthenticator(config *viper.Viper) Authenticator {
+	var (
+		username = config.GetString("management.user.name")
+		password = config.GetString("management.user.password")
+	)
+	switch config.GetString("management.user.auth") {
+	case "session":
+		return NewSession(username, password)
+	case "robot":
+		return RobotAuth(username, password)
+	case "oidc":
+		return OIDCAuth(username, password)
+	case "docker":
+		return NewDockerConfigAuth(config.GetString("management.user.docker_config"))
+	default:
+		return BasicAuth{Username: username, Password: password}
+	}
+}
+
+// autoRoot stands for the path prefix of the API in the URL of requests
+// built before the API version is known. Do replaces it once negotiated.
+const autoRoot = "{api}"
 
+// apiRoot returns the path prefix of the API version v.
+func (c *Client) apiRoot(v APIVersion) string {
+	switch v {
+	case APIVersion2:
+		return c.config.GetString("api.v2.root")
+	case APIVersionAuto:
+		return autoRoot
+	}
+	return c.config.GetString("api.root")
+}
+
+// resolveAPIRoot negotiates the API version with ctx when req was built
+// before it was known, and completes the URL of req.
+func (c *Client) resolveAPIRoot(ctx context.Context, req *gorequest.SuperAgent) error {
+	prefix := c.baseURL.String() + autoRoot
+	if !strings.HasPrefix(req.Url, prefix) {
+		return nil
+	}
+	v, err := c.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return err
+	}
+	req.Url = c.baseURL.String() + c.apiRoot(v) + strings.TrimPrefix(req.Url, prefix)
+	return nil
 }
 
 // NewRequest creates an API request. A relative URL path can be provided in
@@ -104,36 +207,163 @@ func newClient(config configer.CoreInterface) *Client {
 // Relative URL paths should always be specified without a preceding slash. If
 // specified, the value pointed to by body is JSON encoded and included as the
 // request body.
+//
+// Every call works on its own copy of the base agent returned by GetClient,
+// which carries the authentication and header defaults, so a Client can be
+// shared by several goroutines.
+//
+// NewRequest sends nothing: with APIVersionAuto, the API version is
+// negotiated by Do, with the context of the request.
 func (c *Client) NewRequest(method, subPath string) *gorequest.SuperAgent {
-	var (
-		api = c.config.GetString("api.root")
-	)
-	u := c.baseURL.String() + api + subPath
-	h := c.client.Set("Accept", "application/json")
+	return c.newRequest(method, c.apiRoot(c.GetAPIVersion())+subPath)
+}
+
+// newRequest creates a request for path, relative to the base URL.
+func (c *Client) newRequest(method, path string) *gorequest.SuperAgent {
+	u := c.baseURL.String() + path
+	r := c.client.Clone()
+	r.Set("Accept", "application/json")
 	if c.userAgent != "" {
-		h.Set("User-Agent", c.userAgent)
+		r.Set("User-Agent", c.userAgent)
 	}
-	logger.Log.Debug("發起 Request", "["+method+"]", u)
+	c.logger.Debug("發起 Request", "["+method+"]", u)
 	switch method {
 	case gorequest.PUT:
-		return c.client.Put(u).Set("Content-Type", "application/json")
+		return r.Put(u).Set("Content-Type", "application/json")
 	case gorequest.POST:
-		return c.client.Post(u).Set("Content-Type", "application/json")
+		return r.Post(u).Set("Content-Type", "application/json")
 	case gorequest.GET:
-		return c.client.Get(u)
+		return r.Get(u)
 	case gorequest.HEAD:
-		return c.client.Head(u)
+		return r.Head(u)
 	case gorequest.DELETE:
-		return c.client.Delete(u)
+		return r.Delete(u)
 	case gorequest.PATCH:
-		return c.client.Patch(u)
+		return r.Patch(u)
 	case gorequest.OPTIONS:
-		return c.client.Options(u)
+		return r.Options(u)
 	default:
-		return c.client.Get(u)
+		return r.Get(u)
 	}
 }
 
+// Do sends an API request built by NewRequest and returns the API response.
+// The request is bound to ctx, so cancelling ctx or reaching its deadline
+// aborts the underlying HTTP call and reports ctx.Err() (context.Canceled or
+// context.DeadlineExceeded). Transient failures are retried according to the
+// retry policy of the client, or the one carried by ctx. A status code
+// outside of the 2xx range is reported as an *ErrorResponse. If v is not nil,
+// the JSON response body is decoded into it. A request built before the API
+// version was negotiated negotiates it first, and reports the failure to do
+// so.
+func (c *Client) Do(ctx context.Context, req *gorequest.SuperAgent, v interface{}) (*gorequest.Response, []error) {
+	var resp gorequest.Response
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(req.Errors) != 0 {
+		return &resp, req.Errors
+	}
+	if err := c.resolveAPIRoot(ctx, req); err != nil {
+		return &resp, []error{err}
+	}
+
+	var (
+		r      *http.Response
+		body   []byte
+		err    error
+		policy = c.retryPolicyFor(ctx)
+	)
+	for attempt := 1; ; attempt++ {
+		r, body, err = c.send(ctx, req)
+		if policy == nil || attempt >= policy.MaxAttempts || !policy.ShouldRetry(req.Method, r, err) {
+			break
+		}
+		wait := policy.Backoff(attempt, r)
+		c.logger.Debug("重試 Request", "["+req.Method+"]", req.Url, "第", attempt, "次失敗，等待", wait)
+		if err := sleepContext(ctx, wait); err != nil {
+			return &resp, []error{err}
+		}
+	}
+	if err != nil {
+		return &resp, []error{err}
+	}
+	resp = r
+
+	if err := CheckResponse(r, body); err != nil {
+		return &resp, []error{err}
+	}
+	if v != nil && len(body) != 0 {
+		if err := json.Unmarshal(body, v); err != nil {
+			return &resp, []error{err}
+		}
+	}
+	return &resp, nil
+}
+
+// LocationID returns the ID of the resource created by a request, which
+// Harbor reports as the last segment of the Location header of the response.
+func LocationID(resp *gorequest.Response) (int64, bool) {
+	if resp == nil || *resp == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseInt(path.Base((*resp).Header.Get("Location")), 10, 64)
+	return id, err == nil
+}
+
+// send performs a single attempt of req, once allowed by the rate limiter and
+// the in-flight cap, and reads the whole response body. A request rejected
+// because the credentials of the client expired is sent again once renewed.
+func (c *Client) send(ctx context.Context, req *gorequest.SuperAgent) (*http.Response, []byte, error) {
+	for renewed := false; ; renewed = true {
+		httpReq, err := req.MakeRequest()
+		if err != nil {
+			return nil, nil, err
+		}
+		if c.auth != nil {
+			if err := c.auth.Authenticate(ctx, c, httpReq); err != nil {
+				return nil, nil, err
+			}
+		}
+		r, body, err := c.sendRequest(ctx, httpReq)
+		if err != nil {
+			return nil, nil, err
+		}
+		observer, ok := c.auth.(AuthObserver)
+		if !ok || !observer.Observe(httpReq, r) || renewed {
+			return r, body, nil
+		}
+		c.logger.Debug("驗證資訊已失效，重新驗證")
+	}
+}
+
+func (c *Client) sendRequest(ctx context.Context, httpReq *http.Request) (*http.Response, []byte, error) {
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+	r, err := c.httpClient.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, err
+	}
+	defer r.Body.Close()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, nil, ctxErr
+		}
+		return nil, nil, err
+	}
+	// Reset the body so callers can read it again
+	r.Body = ioutil.NopCloser(bytes.NewBuffer(body))
+	return r, body, nil
+}
+
 type SearchRepository struct {
 	// The ID of the project that the repository belongs to
 	ProjectId int32 `json:"project_id,omitempty"`
@@ -164,7 +394,7 @@ type StatisticMap struct {
 
 // Get projects number and repositories number relevant to the user
 //
-//This endpoint is aimed to statistic all of the projects number
+// This endpoint is aimed to statistic all of the projects number
 // and repositories number relevant to the logined user,
 // also the public projects number and repositories number.
 // If the user is admin,
@@ -172,8 +402,12 @@ type StatisticMap struct {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L631
 func (c *Client) GetStatistics() (StatisticMap, *gorequest.Response, []error) {
+	return c.GetStatisticsContext(context.Background())
+}
+
+// GetStatisticsContext is like GetStatistics but bound to ctx.
+func (c *Client) GetStatisticsContext(ctx context.Context) (StatisticMap, *gorequest.Response, []error) {
 	var statistics StatisticMap
-	resp, _, errs := c.NewRequest(gorequest.GET, "statistics").
-		EndStruct(&statistics)
-	return statistics, &resp, errs
+	resp, errs := c.Do(ctx, c.NewRequest(gorequest.GET, c.config.GetString("api.statistics.root")), &statistics)
+	return statistics, resp, errs
 }
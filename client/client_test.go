@@ -0,0 +1,159 @@
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+)
+
+// projectServer serves the list, get and create project endpoints, and
+// fails the test when a request carries a query or a body it should not.
+type projectServer struct {
+	t        *testing.T
+	mu       sync.Mutex
+	projects map[int64]string
+	nextID   int64
+}
+
+func (s *projectServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	if user, pass, ok := r.BasicAuth(); !ok || user != "admin" || pass != "secret" {
+		s.t.Errorf("%s %s: credentials %q %q, want admin secret", r.Method, r.URL, user, pass)
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/api/projects":
+		if len(body) != 0 {
+			s.t.Errorf("GET %s: unexpected body %q", r.URL, body)
+		}
+		names := r.URL.Query()["name"]
+		if len(names) != 1 {
+			s.t.Errorf("GET %s: names %q, want exactly one", r.URL, names)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		var found []projects.Project
+		for id, name := range s.projects {
+			if name == names[0] {
+				found = append(found, projects.Project{ProjectID: id, Name: name})
+			}
+		}
+		s.mu.Unlock()
+		json.NewEncoder(w).Encode(found)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/api/projects/"):
+		if len(body) != 0 || r.URL.RawQuery != "" {
+			s.t.Errorf("GET %s: unexpected body %q", r.URL, body)
+		}
+		id, _ := strconv.ParseInt(strings.TrimPrefix(r.URL.Path, "/api/projects/"), 10, 64)
+		s.mu.Lock()
+		name, ok := s.projects[id]
+		s.mu.Unlock()
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(projects.Project{ProjectID: id, Name: name})
+	case r.Method == http.MethodPost && r.URL.Path == "/api/projects":
+		if r.URL.RawQuery != "" {
+			s.t.Errorf("POST %s: unexpected query", r.URL)
+		}
+		var req projects.ProjectRequest
+		if err := json.Unmarshal(body, &req); err != nil || req.Name == "" {
+			s.t.Errorf("POST %s: body %q is not a single project", r.URL, body)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.nextID++
+		id := s.nextID
+		s.projects[id] = req.Name
+		s.mu.Unlock()
+		w.Header().Set("Location", fmt.Sprintf("/api/projects/%d", id))
+		w.WriteHeader(http.StatusCreated)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// TestClientConcurrentRequests shares one client between goroutines listing,
+// getting and creating projects, so that go test -race catches requests
+// sharing the state of the base agent.
+func TestClientConcurrentRequests(t *testing.T) {
+	ps := &projectServer{t: t, projects: map[int64]string{}}
+	for i := 0; i < 10; i++ {
+		ps.nextID++
+		ps.projects[ps.nextID] = fmt.Sprintf("seed-%d", i)
+	}
+	srv := httptest.NewServer(ps)
+	defer srv.Close()
+
+	var c client.ClientInterface
+	c, err := client.New(
+		client.WithBaseURL(srv.URL),
+		client.WithBasicAuth("admin", "secret"),
+		client.WithAPIVersion(client.APIVersion1),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	s := projects.NewProjectService(c)
+
+	const workers = 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("seed-%d", i%10)
+			found, _, errs := s.List(&projects.ListProjectsOptions{Name: name})
+			if len(errs) != 0 {
+				t.Errorf("List(%s): %v", name, errs)
+				return
+			}
+			if len(found) != 1 || found[0].Name != name {
+				t.Errorf("List(%s) = %+v", name, found)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			id := int64(i%10 + 1)
+			p, _, errs := s.Get(id)
+			if len(errs) != 0 {
+				t.Errorf("Get(%d): %v", id, errs)
+				return
+			}
+			if p.ProjectID != id || p.Name != fmt.Sprintf("seed-%d", id-1) {
+				t.Errorf("Get(%d) = %+v", id, p)
+			}
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			name := fmt.Sprintf("new-%d", i)
+			resp, errs := s.Create(&projects.ProjectRequest{Name: name})
+			if len(errs) != 0 {
+				t.Errorf("Create(%s): %v", name, errs)
+				return
+			}
+			if _, ok := client.LocationID(resp); !ok {
+				t.Errorf("Create(%s): no Location", name)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if got := len(ps.projects); got != 10+workers {
+		t.Errorf("%d projects after the creations, want %d", got, 10+workers)
+	}
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// DockerConfigAuth is an Authenticator using the credentials saved by docker
+// login for the Harbor host: the auths entries of the Docker configuration,
+// or the credential helpers it names in credHelpers and credsStore.
+type DockerConfigAuth struct {
+	// Path of the Docker configuration, $DOCKER_CONFIG/config.json or
+	// ~/.docker/config.json by default
+	Path string
+
+	mu    sync.Mutex
+	creds map[string]BasicAuth
+}
+
+// NewDockerConfigAuth returns a DockerConfigAuth reading the Docker
+// configuration at path, or at its default location when path is empty.
+func NewDockerConfigAuth(path string) *DockerConfigAuth {
+	return &DockerConfigAuth{Path: path}
+}
+
+// dockerConfig holds the credentials related entries of config.json.
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+	CredsStore  string            `json:"credsStore"`
+	CredHelpers map[string]string `json:"credHelpers"`
+}
+
+// Authenticate adds the credentials of the host of req, looked up once per
+// host.
+func (a *DockerConfigAuth) Authenticate(ctx context.Context, c *Client, req *http.Request) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	host := req.URL.Host
+	creds, ok := a.creds[host]
+	if !ok {
+		var err error
+		if creds, err = a.lookup(ctx, host); err != nil {
+			return err
+		}
+		if a.creds == nil {
+			a.creds = map[string]BasicAuth{}
+		}
+		a.creds[host] = creds
+	}
+	return creds.Authenticate(ctx, c, req)
+}
+
+func (a *DockerConfigAuth) path() string {
+	if a.Path != "" {
+		return a.Path
+	}
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+func (a *DockerConfigAuth) lookup(ctx context.Context, host string) (BasicAuth, error) {
+	path := a.path()
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return BasicAuth{}, err
+	}
+	var config dockerConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return BasicAuth{}, fmt.Errorf("harbor: invalid Docker configuration %s: %v", path, err)
+	}
+
+	if helper, ok := config.CredHelpers[host]; ok {
+		return credentialHelper(ctx, helper, host)
+	}
+	for server, entry := range config.Auths {
+		if registryHost(server) != host {
+			continue
+		}
+		if entry.Auth == "" {
+			return BasicAuth{Username: entry.Username, Password: entry.Password}, nil
+		}
+		decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+		if err != nil {
+			return BasicAuth{}, fmt.Errorf("harbor: invalid auth of %s in %s: %v", server, path, err)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return BasicAuth{}, fmt.Errorf("harbor: invalid auth of %s in %s", server, path)
+		}
+		return BasicAuth{Username: parts[0], Password: parts[1]}, nil
+	}
+	if config.CredsStore != "" {
+		return credentialHelper(ctx, config.CredsStore, host)
+	}
+	return BasicAuth{}, fmt.Errorf("harbor: no credentials for %s in %s", host, path)
+}
+
+// registryHost returns the host of a server of the auths entries, which may
+// be written as a URL, e.g. https://index.docker.io/v1/.
+func registryHost(server string) string {
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+len("://"):]
+	}
+	if i := strings.Index(server, "/"); i >= 0 {
+		server = server[:i]
+	}
+	return server
+}
+
+// credentialHelper asks the docker-credential-<helper> program for the
+// credentials of host.
+func credentialHelper(ctx context.Context, helper, host string) (BasicAuth, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		return BasicAuth{}, fmt.Errorf("harbor: credential helper %s: %v: %s", helper, err, msg)
+	}
+	var v struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &v); err != nil {
+		return BasicAuth{}, fmt.Errorf("harbor: credential helper %s: %v", helper, err)
+	}
+	return BasicAuth{Username: v.Username, Password: v.Secret}, nil
+}
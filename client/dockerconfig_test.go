@@ -0,0 +1,143 @@
+package client
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// tempDir returns a directory removed at the end of the test.
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "harbor-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+// writeDockerConfig writes config in a Docker configuration directory and
+// returns the path of its config.json.
+func writeDockerConfig(t *testing.T, config string) string {
+	path := filepath.Join(tempDir(t), "config.json")
+	if err := ioutil.WriteFile(path, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// dockerConfigUser returns the basic auth added by a to a request of host.
+func dockerConfigUser(a *DockerConfigAuth, host string) (string, string, error) {
+	req, _ := http.NewRequest(http.MethodGet, "https://"+host+"/api/projects", nil)
+	if err := a.Authenticate(context.Background(), nil, req); err != nil {
+		return "", "", err
+	}
+	username, password, _ := req.BasicAuth()
+	return username, password, nil
+}
+
+func TestDockerConfigAuths(t *testing.T) {
+	path := writeDockerConfig(t, `{
+		"auths": {
+			"https://harbor.example.com/v1/": {"auth": "YWRtaW46SGFyYm9yMTIzNDU="},
+			"harbor.example.org:8443": {"username": "robot$ci", "password": "s3cret"},
+			"broken.example.com": {"auth": "YWRtaW4="}
+		}
+	}`)
+	a := NewDockerConfigAuth(path)
+	for host, want := range map[string][2]string{
+		"harbor.example.com":      {"admin", "Harbor12345"},
+		"harbor.example.org:8443": {"robot$ci", "s3cret"},
+	} {
+		username, password, err := dockerConfigUser(a, host)
+		if err != nil || username != want[0] || password != want[1] {
+			t.Errorf("%s: %q %q %v, want %v", host, username, password, err, want)
+		}
+	}
+	if _, _, err := dockerConfigUser(a, "broken.example.com"); err == nil || !strings.Contains(err.Error(), "invalid auth") {
+		t.Errorf("auth without password: %v", err)
+	}
+	if _, _, err := dockerConfigUser(a, "unknown.example.com"); err == nil || !strings.Contains(err.Error(), "no credentials for unknown.example.com") {
+		t.Errorf("unknown host: %v", err)
+	}
+
+	// Credentials are looked up once per host
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if username, _, err := dockerConfigUser(a, "harbor.example.com"); err != nil || username != "admin" {
+		t.Errorf("cached credentials: %q %v", username, err)
+	}
+}
+
+func TestDockerConfigDefaultPath(t *testing.T) {
+	path := writeDockerConfig(t, `{"auths": {"harbor.example.com": {"username": "admin", "password": "Harbor12345"}}}`)
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", filepath.Dir(path))
+	if username, _, err := dockerConfigUser(NewDockerConfigAuth(""), "harbor.example.com"); err != nil || username != "admin" {
+		t.Errorf("credentials from $DOCKER_CONFIG: %q %v", username, err)
+	}
+}
+
+func TestDockerConfigCredentialHelpers(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake credential helper is a shell script")
+	}
+	// docker-credential-fake answers the credentials of the host it is
+	// asked for, and fails for unknown.example.com
+	bin := tempDir(t)
+	helper := `#!/bin/sh
+read host
+if [ "$host" = unknown.example.com ]; then
+	echo "credentials not found in native keychain"
+	exit 1
+fi
+echo "{\"ServerURL\":\"$host\",\"Username\":\"$1-$host\",\"Secret\":\"s3cret\"}"
+`
+	if err := ioutil.WriteFile(filepath.Join(bin, "docker-credential-fake"), []byte(helper), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	path := writeDockerConfig(t, `{
+		"auths": {"harbor.example.com": {}, "harbor.example.org": {"username": "admin", "password": "Harbor12345"}},
+		"credsStore": "fake",
+		"credHelpers": {"harbor.example.com": "fake"}
+	}`)
+	a := NewDockerConfigAuth(path)
+	for host, want := range map[string]string{
+		// credHelpers take precedence over auths
+		"harbor.example.com": "get-harbor.example.com",
+		"harbor.example.org": "admin",
+		// credsStore covers the hosts missing from auths
+		"harbor.example.net": "get-harbor.example.net",
+	} {
+		if username, _, err := dockerConfigUser(a, host); err != nil || username != want {
+			t.Errorf("%s: %q %v, want %q", host, username, err, want)
+		}
+	}
+	_, _, err := dockerConfigUser(a, "unknown.example.com")
+	if err == nil || !strings.Contains(err.Error(), "credential helper fake") || !strings.Contains(err.Error(), "not found in native keychain") {
+		t.Errorf("helper failure: %v", err)
+	}
+}
+
+func TestRegistryHost(t *testing.T) {
+	for server, want := range map[string]string{
+		"harbor.example.com":            "harbor.example.com",
+		"harbor.example.com:8443":       "harbor.example.com:8443",
+		"https://index.docker.io/v1/":   "index.docker.io",
+		"http://harbor.example.com/v2/": "harbor.example.com",
+	} {
+		if got := registryHost(server); got != want {
+			t.Errorf("registryHost(%q) = %q, want %q", server, got, want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrBadRequest is matched by an ErrorResponse with status 400.
+	ErrBadRequest = errors.New("harbor: bad request")
+	// ErrUnauthorized is matched by an ErrorResponse with status 401.
+	ErrUnauthorized = errors.New("harbor: unauthorized")
+	// ErrForbidden is matched by an ErrorResponse with status 403.
+	ErrForbidden = errors.New("harbor: forbidden")
+	// ErrNotFound is matched by an ErrorResponse with status 404.
+	ErrNotFound = errors.New("harbor: not found")
+	// ErrConflict is matched by an ErrorResponse with status 409.
+	ErrConflict = errors.New("harbor: conflict")
+	// ErrNotSupported is returned by calls that have no equivalent in the
+	// API version of the server.
+	ErrNotSupported = errors.New("harbor: not supported by this API version")
+)
+
+// HarborError is a single entry of the error body returned by Harbor.
+type HarborError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse reports an error caused by an API request. It is returned
+// by Client.Do, and hence by every service method, whenever Harbor answers
+// with a status code outside of the 2xx range.
+type ErrorResponse struct {
+	// HTTP response that caused this error
+	Response *http.Response `json:"-"`
+	// HTTP status code of the response
+	StatusCode int `json:"-"`
+	// HTTP method and URL of the request
+	Method string `json:"-"`
+	URL    string `json:"-"`
+	// Value of the X-Request-Id header set by Harbor
+	RequestID string `json:"-"`
+	// Errors decoded from the response body
+	Errors []HarborError `json:"errors"`
+	// Raw response body, kept when it could not be decoded
+	Body string `json:"-"`
+}
+
+func (e *ErrorResponse) Error() string {
+	msg := e.Body
+	if len(e.Errors) != 0 {
+		msgs := make([]string, 0, len(e.Errors))
+		for _, he := range e.Errors {
+			if he.Code != "" {
+				msgs = append(msgs, he.Code+": "+he.Message)
+			} else {
+				msgs = append(msgs, he.Message)
+			}
+		}
+		msg = strings.Join(msgs, "; ")
+	}
+	s := fmt.Sprintf("%s %s: %d", e.Method, e.URL, e.StatusCode)
+	if msg != "" {
+		s += " " + msg
+	}
+	if e.RequestID != "" {
+		s += " (request id " + e.RequestID + ")"
+	}
+	return s
+}
+
+// Is reports whether e matches one of the sentinel errors of this package,
+// so that errors.Is(err, ErrNotFound) works on an *ErrorResponse.
+func (e *ErrorResponse) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	}
+	return false
+}
+
+// CheckResponse returns an *ErrorResponse if r has a status code outside of
+// the 2xx range, nil otherwise. body is the already read response body.
+func CheckResponse(r *http.Response, body []byte) error {
+	if c := r.StatusCode; 200 <= c && c <= 299 {
+		return nil
+	}
+	e := &ErrorResponse{
+		Response:   r,
+		StatusCode: r.StatusCode,
+		RequestID:  r.Header.Get("X-Request-Id"),
+	}
+	if r.Request != nil {
+		e.Method = r.Request.Method
+		e.URL = r.Request.URL.String()
+	}
+	if len(body) == 0 {
+		return e
+	}
+	// Harbor 2.x answers {"errors":[{"code":..,"message":..}]}, while 1.x
+	// answers {"code":..,"message":..} or plain text.
+	if err := json.Unmarshal(body, e); err == nil && len(e.Errors) != 0 {
+		return e
+	}
+	var legacy struct {
+		Code    interface{} `json:"code"`
+		Message string      `json:"message"`
+	}
+	if err := json.Unmarshal(body, &legacy); err == nil && legacy.Message != "" {
+		e.Errors = []HarborError{{Message: legacy.Message}}
+		// 1.x mostly repeats the status code here, keep textual codes only
+		if code, ok := legacy.Code.(string); ok {
+			e.Errors[0].Code = code
+		}
+		return e
+	}
+	e.Body = strings.TrimSpace(string(body))
+	return e
+}
+
+// IsBadRequest reports whether err is an ErrorResponse with status 400.
+func IsBadRequest(err error) bool {
+	return errors.Is(err, ErrBadRequest)
+}
+
+// IsUnauthorized reports whether err is an ErrorResponse with status 401.
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+// IsForbidden reports whether err is an ErrorResponse with status 403.
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+// IsNotFound reports whether err is an ErrorResponse with status 404.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+// IsConflict reports whether err is an ErrorResponse with status 409.
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
+
+// AsErrorResponse returns the first *ErrorResponse found in errs, as
+// returned by the service methods.
+func AsErrorResponse(errs []error) (*ErrorResponse, bool) {
+	for _, err := range errs {
+		var e *ErrorResponse
+		if errors.As(err, &e) {
+			return e, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,115 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func TestCheckResponse(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "https://harbor.example.com/api/projects/1", nil)
+	for _, tt := range []struct {
+		name   string
+		status int
+		body   string
+		errors []HarborError
+		raw    string
+		msg    string
+	}{
+		{
+			name:   "2.x",
+			status: http.StatusNotFound,
+			body:   `{"errors":[{"code":"NOT_FOUND","message":"project 1 not found"}]}`,
+			errors: []HarborError{{Code: "NOT_FOUND", Message: "project 1 not found"}},
+			msg:    "GET https://harbor.example.com/api/projects/1: 404 NOT_FOUND: project 1 not found (request id abc)",
+		},
+		{
+			name:   "1.x",
+			status: http.StatusConflict,
+			body:   `{"code":409,"message":"project library already exists"}`,
+			errors: []HarborError{{Message: "project library already exists"}},
+			msg:    "GET https://harbor.example.com/api/projects/1: 409 project library already exists (request id abc)",
+		},
+		{
+			name:   "plain text",
+			status: http.StatusInternalServerError,
+			body:   "internal error\n",
+			raw:    "internal error",
+			msg:    "GET https://harbor.example.com/api/projects/1: 500 internal error (request id abc)",
+		},
+		{
+			name:   "empty",
+			status: http.StatusForbidden,
+			msg:    "GET https://harbor.example.com/api/projects/1: 403 (request id abc)",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &http.Response{StatusCode: tt.status, Header: http.Header{"X-Request-Id": {"abc"}}, Request: req}
+			err := CheckResponse(r, []byte(tt.body))
+			var e *ErrorResponse
+			if !errors.As(err, &e) {
+				t.Fatalf("CheckResponse = %v, want an *ErrorResponse", err)
+			}
+			if e.StatusCode != tt.status || !reflect.DeepEqual(e.Errors, tt.errors) || e.Body != tt.raw {
+				t.Errorf("CheckResponse = %+v", e)
+			}
+			if e.Error() != tt.msg {
+				t.Errorf("Error() = %q, want %q", e.Error(), tt.msg)
+			}
+		})
+	}
+	if err := CheckResponse(&http.Response{StatusCode: http.StatusNoContent}, nil); err != nil {
+		t.Errorf("CheckResponse(204) = %v", err)
+	}
+}
+
+func TestErrorPredicates(t *testing.T) {
+	predicates := map[int]func(error) bool{
+		http.StatusBadRequest:   IsBadRequest,
+		http.StatusUnauthorized: IsUnauthorized,
+		http.StatusForbidden:    IsForbidden,
+		http.StatusNotFound:     IsNotFound,
+		http.StatusConflict:     IsConflict,
+	}
+	for status := range predicates {
+		err := fmt.Errorf("wrapped: %w", &ErrorResponse{StatusCode: status})
+		for other, is := range predicates {
+			if got := is(err); got != (other == status) {
+				t.Errorf("predicate of %d on %d = %v", other, status, got)
+			}
+		}
+	}
+	if IsNotFound(errors.New("not found")) {
+		t.Error("IsNotFound matched an error that is no ErrorResponse")
+	}
+}
+
+func TestDoReturnsErrorResponse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-1")
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"code":404,"message":"user 7 not found"}`))
+	}))
+	defer srv.Close()
+	c, err := New(WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/users/7"), nil)
+	e, ok := AsErrorResponse(errs)
+	if !ok {
+		t.Fatalf("Do = %v, want an *ErrorResponse", errs)
+	}
+	if !IsNotFound(errs[0]) || e.Method != http.MethodGet || e.URL != srv.URL+"/api/users/7" || e.RequestID != "req-1" {
+		t.Errorf("Do = %+v", e)
+	}
+	if _, ok := AsErrorResponse([]error{errors.New("EOF")}); ok {
+		t.Error("AsErrorResponse found an *ErrorResponse in a transport error")
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// RateLimiter throttles the requests sent by a Client. Wait blocks until a
+// request may be sent or ctx is done.
+type RateLimiter interface {
+	Wait(ctx context.Context) error
+}
+
+// TokenBucket is a RateLimiter letting rate requests per second through,
+// with bursts of up to burst requests.
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket returns a full TokenBucket.
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: time.Now()}
+}
+
+// Wait takes a token from the bucket, waiting for it to be refilled when it
+// is empty. The token is given back if ctx is done before.
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+	// Reserve the token right away so that concurrent callers queue up
+	// behind each other instead of all waking up at once.
+	b.tokens--
+	deficit := -b.tokens
+	b.mu.Unlock()
+
+	if deficit <= 0 {
+		return nil
+	}
+	if b.rate <= 0 {
+		<-ctx.Done()
+		b.cancel()
+		return ctx.Err()
+	}
+	if err := sleepContext(ctx, time.Duration(deficit/b.rate*float64(time.Second))); err != nil {
+		b.cancel()
+		return err
+	}
+	return nil
+}
+
+func (b *TokenBucket) cancel() {
+	b.mu.Lock()
+	b.tokens++
+	b.mu.Unlock()
+}
+
+// LimiterStats reports how long the requests of a Client waited on its rate
+// limiter and in-flight cap. Durations are cumulated over all requests.
+type LimiterStats struct {
+	// Number of requests sent
+	Requests int64
+	// Requests currently in flight
+	InFlight int64
+	// Time spent waiting on the rate limiter
+	RateWait    time.Duration
+	MaxRateWait time.Duration
+	// Time spent waiting for an in-flight slot
+	SlotWait    time.Duration
+	MaxSlotWait time.Duration
+}
+
+// limiter gathers the throttling settings shared by every service created
+// from a Client.
+type limiter struct {
+	// Counters come first to stay 64-bit aligned for sync/atomic
+	requests    int64
+	inFlight    int64
+	rateWait    int64
+	maxRateWait int64
+	slotWait    int64
+	maxSlotWait int64
+
+	rate  RateLimiter
+	slots chan struct{}
+}
+
+// acquire waits for the rate limiter and a free in-flight slot. The returned
+// function releases the slot.
+func (l *limiter) acquire(ctx context.Context) (func(), error) {
+	if l.rate != nil {
+		start := time.Now()
+		if err := l.rate.Wait(ctx); err != nil {
+			return nil, err
+		}
+		record(&l.rateWait, &l.maxRateWait, time.Since(start))
+	}
+	if l.slots != nil {
+		start := time.Now()
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		record(&l.slotWait, &l.maxSlotWait, time.Since(start))
+	}
+	atomic.AddInt64(&l.requests, 1)
+	atomic.AddInt64(&l.inFlight, 1)
+	return func() {
+		atomic.AddInt64(&l.inFlight, -1)
+		if l.slots != nil {
+			<-l.slots
+		}
+	}, nil
+}
+
+func record(total, max *int64, d time.Duration) {
+	atomic.AddInt64(total, int64(d))
+	for {
+		cur := atomic.LoadInt64(max)
+		if int64(d) <= cur || atomic.CompareAndSwapInt64(max, cur, int64(d)) {
+			return
+		}
+	}
+}
+
+// SetRateLimiter sets the rate limiter applied to every request of the
+// client, nil disables rate limiting. It must be called before the client
+// is shared between goroutines.
+func (c *Client) SetRateLimiter(l RateLimiter) {
+	c.limiter.rate = l
+}
+
+// SetMaxInFlight caps the number of requests of the client running at the
+// same time, 0 removes the cap. It must be called before the client is
+// shared between goroutines.
+func (c *Client) SetMaxInFlight(n int) {
+	if n <= 0 {
+		c.limiter.slots = nil
+		return
+	}
+	c.limiter.slots = make(chan struct{}, n)
+}
+
+// LimiterStats returns a snapshot of the throttling metrics of the client.
+func (c *Client) LimiterStats() LimiterStats {
+	l := c.limiter
+	return LimiterStats{
+		Requests:    atomic.LoadInt64(&l.requests),
+		InFlight:    atomic.LoadInt64(&l.inFlight),
+		RateWait:    time.Duration(atomic.LoadInt64(&l.rateWait)),
+		MaxRateWait: time.Duration(atomic.LoadInt64(&l.maxRateWait)),
+		SlotWait:    time.Duration(atomic.LoadInt64(&l.slotWait)),
+		MaxSlotWait: time.Duration(atomic.LoadInt64(&l.maxSlotWait)),
+	}
+}
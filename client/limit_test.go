@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func TestTokenBucket(t *testing.T) {
+	b := NewTokenBucket(100, 2)
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if err := b.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The burst goes through at once, the 2 others wait 10ms each
+	if elapsed := time.Since(start); elapsed < 15*time.Millisecond {
+		t.Errorf("4 tokens at 100/s with a burst of 2 took %s", elapsed)
+	}
+
+	empty := NewTokenBucket(0, 1)
+	if err := empty.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := empty.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait on an empty bucket that is never refilled: %v", err)
+	}
+	if empty.tokens != 0 {
+		t.Errorf("the token of a cancelled Wait was not given back: %v tokens", empty.tokens)
+	}
+}
+
+func TestMaxInFlight(t *testing.T) {
+	var (
+		cur, peak int64
+		release   = make(chan struct{})
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&cur, 1)
+		for {
+			m := atomic.LoadInt64(&peak)
+			if n <= m || atomic.CompareAndSwapInt64(&peak, m, n) {
+				break
+			}
+		}
+		<-release
+		atomic.AddInt64(&cur, -1)
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	c, err := New(WithBaseURL(srv.URL), WithMaxInFlight(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+				t.Error(errs)
+			}
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	if stats := c.LimiterStats(); stats.InFlight != 2 {
+		t.Errorf("%d requests in flight, want 2", stats.InFlight)
+	}
+	close(release)
+	wg.Wait()
+
+	if peak != 2 {
+		t.Errorf("the server saw %d requests at the same time, want 2", peak)
+	}
+	stats := c.LimiterStats()
+	if stats.Requests != 6 || stats.InFlight != 0 || stats.SlotWait <= 0 || stats.MaxSlotWait > stats.SlotWait {
+		t.Errorf("LimiterStats() = %+v", stats)
+	}
+}
+
+func TestRateLimiterCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+	c, err := New(WithBaseURL(srv.URL), WithRateLimiter(NewTokenBucket(0.001, 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) == 0 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("Do waiting on the rate limiter past its deadline: %v", errs)
+	}
+	if stats := c.LimiterStats(); stats.Requests != 1 {
+		t.Errorf("%d requests sent, want 1", stats.Requests)
+	}
+}
@@ -0,0 +1,32 @@
+package client
+
+import "github.com/codingXiang/go-logger"
+
+// Logger is the logging interface used by the client. The LoggerInterface
+// of github.com/codingXiang/go-logger satisfies it.
+type Logger interface {
+	Debug(args ...interface{})
+	Info(args ...interface{})
+	Error(args ...interface{})
+}
+
+// globalLogger forwards to logger.Log, once it has been initialized.
+type globalLogger struct{}
+
+func (globalLogger) Debug(args ...interface{}) {
+	if logger.Log != nil {
+		logger.Log.Debug(args...)
+	}
+}
+
+func (globalLogger) Info(args ...interface{}) {
+	if logger.Log != nil {
+		logger.Log.Info(args...)
+	}
+}
+
+func (globalLogger) Error(args ...interface{}) {
+	if logger.Log != nil {
+		logger.Log.Error(args...)
+	}
+}
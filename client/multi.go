@@ -0,0 +1,268 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/codingXiang/configer"
+)
+
+// ErrUnknownInstance is the error of the results of DoOn for names that are
+// not instances of the Multi.
+var ErrUnknownInstance = errors.New("harbor: unknown instance")
+
+// Instance is a named Harbor instance of a Multi.
+type Instance struct {
+	Name   string
+	Client ClientInterface
+	// Limit of a call on the instance, in addition to the deadline of the
+	// context given to Do. None when zero.
+	Timeout time.Duration
+}
+
+// Multi holds named Harbor instances, e.g. one per region, and calls them
+// concurrently. Each instance has its own client, hence its own credentials,
+// transport and timeouts:
+//
+//	m, _ := client.NewMulti(
+//		client.Instance{Name: "eu", Client: eu, Timeout: 10 * time.Second},
+//		client.Instance{Name: "us", Client: us, Timeout: 30 * time.Second},
+//	)
+//	results := m.Do(ctx, func(ctx context.Context, inst client.Instance) (interface{}, error) {
+//		tag, _, errs := repositories.NewRepositoriesService(inst.Client).GetTagContext(ctx, "library", "nginx", "1.19")
+//		if len(errs) != 0 {
+//			return nil, errs[0]
+//		}
+//		return tag, nil
+//	})
+type Multi struct {
+	mu        sync.RWMutex
+	instances []Instance
+}
+
+// NewMulti returns a Multi holding instances.
+func NewMulti(instances ...Instance) (*Multi, error) {
+	m := &Multi{}
+	for _, inst := range instances {
+		if err := m.Add(inst); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// NewMultiClient returns a Multi holding one instance per configuration,
+// keyed by the name of the instance, see NewClient.
+func NewMultiClient(configs map[string]configer.CoreInterface) (*Multi, error) {
+	m := &Multi{}
+	names := make([]string, 0, len(configs))
+	for name := range configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := newClient(configs[name])
+		if c == nil {
+			m.Close()
+			return nil, fmt.Errorf("harbor: invalid configuration of instance %s", name)
+		}
+		if err := m.Add(Instance{Name: name, Client: c}); err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Add adds an instance, whose name must be unique.
+func (m *Multi) Add(inst Instance) error {
+	if inst.Name == "" {
+		return errors.New("harbor: instance without name")
+	}
+	if inst.Client == nil {
+		return errors.New("harbor: nil client of instance " + inst.Name)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, i := range m.instances {
+		if i.Name == inst.Name {
+			return errors.New("harbor: duplicate instance " + inst.Name)
+		}
+	}
+	m.instances = append(m.instances, inst)
+	return nil
+}
+
+// Instance returns the instance name.
+func (m *Multi) Instance(name string) (Instance, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, inst := range m.instances {
+		if inst.Name == name {
+			return inst, true
+		}
+	}
+	return Instance{}, false
+}
+
+// Names returns the names of the instances, in the order they were added.
+func (m *Multi) Names() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.instances))
+	for _, inst := range m.instances {
+		names = append(names, inst.Name)
+	}
+	return names
+}
+
+// Close closes the clients of the instances and returns the first error.
+func (m *Multi) Close() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var first error
+	for _, inst := range m.instances {
+		if err := inst.Client.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// CallFunc is a call on an instance, made by Do.
+type CallFunc func(ctx context.Context, inst Instance) (interface{}, error)
+
+// Result is the outcome of a call on an instance.
+type Result struct {
+	Instance string
+	Value    interface{}
+	Err      error
+	Duration time.Duration
+}
+
+// Do calls fn on every instance concurrently and returns the results in the
+// order of the instances.
+func (m *Multi) Do(ctx context.Context, fn CallFunc) Results {
+	return m.DoOn(ctx, m.Names(), fn)
+}
+
+// DoOn is like Do, on the instances of names only.
+func (m *Multi) DoOn(ctx context.Context, names []string, fn CallFunc) Results {
+	var (
+		results = make(Results, len(names))
+		wg      sync.WaitGroup
+	)
+	for i, name := range names {
+		results[i].Instance = name
+		inst, ok := m.Instance(name)
+		if !ok {
+			results[i].Err = fmt.Errorf("%w %s", ErrUnknownInstance, name)
+			continue
+		}
+		wg.Add(1)
+		go func(r *Result, inst Instance) {
+			defer wg.Done()
+			ctx := ctx
+			if inst.Timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, inst.Timeout)
+				defer cancel()
+			}
+			start := time.Now()
+			r.Value, r.Err = fn(ctx, inst)
+			r.Duration = time.Since(start)
+		}(&results[i], inst)
+	}
+	wg.Wait()
+	return results
+}
+
+// Results are the results of the calls of Do, one per instance.
+type Results []Result
+
+// Get returns the result of the instance name.
+func (r Results) Get(name string) (Result, bool) {
+	for _, res := range r {
+		if res.Instance == name {
+			return res, true
+		}
+	}
+	return Result{}, false
+}
+
+// Succeeded returns the results without error.
+func (r Results) Succeeded() Results {
+	var ok Results
+	for _, res := range r {
+		if res.Err == nil {
+			ok = append(ok, res)
+		}
+	}
+	return ok
+}
+
+// Failed returns the results with an error.
+func (r Results) Failed() Results {
+	var failed Results
+	for _, res := range r {
+		if res.Err != nil {
+			failed = append(failed, res)
+		}
+	}
+	return failed
+}
+
+// FailedWith returns the failed results whose error matches target with
+// errors.Is, e.g. the instances on which a call timed out:
+//
+//	timedOut := results.FailedWith(context.DeadlineExceeded)
+func (r Results) FailedWith(target error) Results {
+	var matching Results
+	for _, res := range r.Failed() {
+		if errors.Is(res.Err, target) {
+			matching = append(matching, res)
+		}
+	}
+	return matching
+}
+
+// Values returns the values of the successful calls, per instance.
+func (r Results) Values() map[string]interface{} {
+	values := make(map[string]interface{}, len(r))
+	for _, res := range r.Succeeded() {
+		values[res.Instance] = res.Value
+	}
+	return values
+}
+
+// Err returns a *MultiError gathering the errors of the failed calls, if
+// any.
+func (r Results) Err() error {
+	failed := r.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+	return &MultiError{Failed: failed, Total: len(r)}
+}
+
+// MultiError reports the instances whose call failed. It does not match
+// the errors of the instances with errors.Is, as one instance failing with
+// ErrNotFound says nothing of the others: use Results.FailedWith instead.
+type MultiError struct {
+	Failed Results
+	// Number of instances called
+	Total int
+}
+
+func (e *MultiError) Error() string {
+	msgs := make([]string, 0, len(e.Failed))
+	for _, res := range e.Failed {
+		msgs = append(msgs, res.Instance+": "+res.Err.Error())
+	}
+	return fmt.Sprintf("harbor: %d of %d instances failed: %s", len(e.Failed), e.Total, strings.Join(msgs, "; "))
+}
@@ -0,0 +1,107 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+func getTag(ctx context.Context, inst client.Instance) (interface{}, error) {
+	tag, _, errs := repositories.NewRepositoriesService(inst.Client).GetTagContext(ctx, "library", "nginx", "1.19")
+	if len(errs) != 0 {
+		return nil, errs[0]
+	}
+	return tag, nil
+}
+
+func TestMultiPartialFailure(t *testing.T) {
+	servers := map[string]*harbortest.Server{}
+	for _, name := range []string{"eu", "us", "ap"} {
+		srv := harbortest.Start(t)
+		srv.AddProject("library", true)
+		servers[name] = srv
+	}
+	for _, name := range []string{"eu", "us"} {
+		if _, err := servers[name].PushImage("library/nginx", "1.19"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// us answers after the timeout of its instance, ap has no such image
+	servers["us"].Inject(harbortest.Fault{Latency: time.Second})
+
+	m, err := client.NewMulti(
+		client.Instance{Name: "eu", Client: servers["eu"].Client(), Timeout: 5 * time.Second},
+		client.Instance{Name: "us", Client: servers["us"].Client(), Timeout: 50 * time.Millisecond},
+		client.Instance{Name: "ap", Client: servers["ap"].Client(), Timeout: 5 * time.Second},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	start := time.Now()
+	results := m.Do(context.Background(), getTag)
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("Do waited %s for the instance past its timeout", elapsed)
+	}
+
+	var names []string
+	for _, res := range results {
+		names = append(names, res.Instance)
+	}
+	if want := []string{"eu", "us", "ap"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("results of %q, want %q", names, want)
+	}
+	values := results.Values()
+	if tag, ok := values["eu"].(repositories.TagResp); len(values) != 1 || !ok || tag.Name != "1.19" {
+		t.Errorf("Values() = %+v, want the tag of eu only", values)
+	}
+	if res, _ := results.Get("us"); !errors.Is(res.Err, context.DeadlineExceeded) {
+		t.Errorf("us: %v, want the deadline of its instance", res.Err)
+	}
+	if res, _ := results.Get("ap"); !client.IsNotFound(res.Err) {
+		t.Errorf("ap: %v, want not found", res.Err)
+	}
+	if got := results.FailedWith(client.ErrNotFound); len(got) != 1 || got[0].Instance != "ap" {
+		t.Errorf("FailedWith(ErrNotFound) = %+v, want ap", got)
+	}
+	if got := results.FailedWith(context.DeadlineExceeded); len(got) != 1 || got[0].Instance != "us" {
+		t.Errorf("FailedWith(DeadlineExceeded) = %+v, want us", got)
+	}
+
+	err = results.Err()
+	var multi *client.MultiError
+	if !errors.As(err, &multi) || multi.Total != 3 || len(multi.Failed) != 2 {
+		t.Fatalf("Err() = %v, want 2 of 3 instances failed", err)
+	}
+	// One instance not finding the image says nothing of the others
+	if errors.Is(err, client.ErrNotFound) {
+		t.Error("the MultiError matches ErrNotFound")
+	}
+}
+
+func TestMultiDoOnUnknownInstance(t *testing.T) {
+	srv := harbortest.Start(t)
+	m, err := client.NewMulti(client.Instance{Name: "eu", Client: srv.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add(client.Instance{Name: "eu", Client: srv.Client()}); err == nil {
+		t.Error("Add of a duplicate instance succeeded")
+	}
+	results := m.DoOn(context.Background(), []string{"eu", "moon"}, func(ctx context.Context, inst client.Instance) (interface{}, error) {
+		return inst.Name, nil
+	})
+	if res, _ := results.Get("moon"); !errors.Is(res.Err, client.ErrUnknownInstance) {
+		t.Errorf("moon: %v, want ErrUnknownInstance", res.Err)
+	}
+	if values := results.Values(); !reflect.DeepEqual(values, map[string]interface{}{"eu": "eu"}) {
+		t.Errorf("Values() = %v", values)
+	}
+}
@@ -0,0 +1,169 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/parnurzeal/gorequest"
+	"github.com/spf13/viper"
+)
+
+// APIVersion identifies the generation of the Harbor REST API a Client talks
+// to.
+type APIVersion string
+
+const (
+	// APIVersion1 is the API of Harbor 1.x, served under /api.
+	APIVersion1 APIVersion = "v1"
+	// APIVersion2 is the API of Harbor 2.x, served under /api/v2.0.
+	APIVersion2 APIVersion = "v2.0"
+	// APIVersionAuto detects the API version from the systeminfo endpoint
+	// of the server, on the first request.
+	APIVersionAuto APIVersion = "auto"
+)
+
+// Option configures a Client created by New.
+type Option func(c *Client) error
+
+// New returns a Client configured by opts. WithBaseURL is required; the
+// route table defaults to the built-in one.
+func New(opts ...Option) (*Client, error) {
+	harborClient := gorequest.New()
+	c := &Client{
+		client:     harborClient,
+		httpClient: harborClient.Client,
+		config:     viper.New(),
+		userAgent:  userAgent,
+		apiVersion: APIVersion1,
+		logger:     globalLogger{},
+		limiter:    &limiter{},
+	}
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			return nil, err
+		}
+	}
+	if c.baseURL == nil {
+		return nil, errors.New("harbor: base URL is required")
+	}
+	if err := c.buildTransport(); err != nil {
+		return nil, err
+	}
+	if s, ok := c.auth.(*Session); ok {
+		s.dropJar(c)
+	}
+	setDefaultRoutes(c.config)
+	return c, nil
+}
+
+// WithBaseURL sets the address of the Harbor server, e.g.
+// https://harbor.example.com.
+func WithBaseURL(urlStr string) Option {
+	return func(c *Client) error {
+		// Make sure the given URL end with a slash
+		if !strings.HasSuffix(urlStr, "/") {
+			urlStr += "/"
+		}
+		u, err := url.Parse(urlStr)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return errors.New("harbor: base URL must be absolute: " + urlStr)
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates every request with username and password.
+func WithBasicAuth(username, password string) Option {
+	return func(c *Client) error {
+		c.auth = BasicAuth{Username: username, Password: password}
+		return nil
+	}
+}
+
+// WithHTTPClient sets the HTTP client used to send the requests.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("harbor: nil HTTP client")
+		}
+		c.httpClient = hc
+		return nil
+	}
+}
+
+// WithUserAgent sets the User-Agent header of every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) error {
+		c.userAgent = ua
+		return nil
+	}
+}
+
+// WithAPIVersion selects the generation of the Harbor API to talk to. It
+// defaults to APIVersion1; APIVersionAuto asks the server on the first
+// request.
+func WithAPIVersion(v APIVersion) Option {
+	return func(c *Client) error {
+		switch v {
+		case APIVersion1, APIVersion2, APIVersionAuto:
+			c.apiVersion = v
+			return nil
+		}
+		return errors.New("harbor: unknown API version " + string(v))
+	}
+}
+
+// WithLogger sets the logger of the client. By default the client logs
+// through logger.Log of github.com/codingXiang/go-logger, once initialized.
+func WithLogger(l Logger) Option {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("harbor: nil logger")
+		}
+		c.logger = l
+		return nil
+	}
+}
+
+// WithConfig overrides entries of the built-in route table with the ones of
+// config, using the keys of config/harbor.yaml.
+func WithConfig(config *viper.Viper) Option {
+	return func(c *Client) error {
+		if config == nil {
+			return errors.New("harbor: nil config")
+		}
+		c.config = config
+		return nil
+	}
+}
+
+// WithRetryPolicy sets the retry policy of the client, see SetRetryPolicy.
+func WithRetryPolicy(p *RetryPolicy) Option {
+	return func(c *Client) error {
+		c.SetRetryPolicy(p)
+		return nil
+	}
+}
+
+// WithRateLimiter sets the rate limiter of the client, see SetRateLimiter.
+func WithRateLimiter(l RateLimiter) Option {
+	return func(c *Client) error {
+		c.SetRateLimiter(l)
+		return nil
+	}
+}
+
+// WithMaxInFlight caps the number of concurrent requests, see
+// SetMaxInFlight.
+func WithMaxInFlight(n int) Option {
+	return func(c *Client) error {
+		c.SetMaxInFlight(n)
+		return nil
+	}
+}
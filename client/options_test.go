@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+	"github.com/spf13/viper"
+)
+
+func TestNewOptionErrors(t *testing.T) {
+	for name, opts := range map[string][]Option{
+		"no base URL":       nil,
+		"relative base URL": {WithBaseURL("harbor.example.com")},
+		"API version":       {WithBaseURL("https://harbor.example.com"), WithAPIVersion("v3")},
+		"nil HTTP client":   {WithBaseURL("https://harbor.example.com"), WithHTTPClient(nil)},
+		"nil logger":        {WithBaseURL("https://harbor.example.com"), WithLogger(nil)},
+		"nil config":        {WithBaseURL("https://harbor.example.com"), WithConfig(nil)},
+	} {
+		if _, err := New(opts...); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
+
+func TestNewRoutes(t *testing.T) {
+	c, err := New(WithBaseURL("https://harbor.example.com/harbor"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetConfig().GetString("api.projects.base"); got != "/projects/%d" {
+		t.Errorf("built-in route api.projects.base = %q", got)
+	}
+	if got := c.NewRequest(gorequest.GET, "/projects").Url; got != "https://harbor.example.com/harbor/api/projects" {
+		t.Errorf("NewRequest URL = %q", got)
+	}
+
+	config := viper.New()
+	config.Set("api.projects.root", "/custom/projects")
+	c, err = New(WithBaseURL("https://harbor.example.com"), WithConfig(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.GetConfig().GetString("api.projects.root"); got != "/custom/projects" {
+		t.Errorf("overridden route api.projects.root = %q", got)
+	}
+	if got := c.GetConfig().GetString("api.user.root"); got != "/users" {
+		t.Errorf("route api.user.root not overridden = %q", got)
+	}
+}
+
+// recordingLogger records the messages logged at every level.
+type recordingLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *recordingLogger) log(args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Debug(args ...interface{}) { l.log(args...) }
+func (l *recordingLogger) Info(args ...interface{})  { l.log(args...) }
+func (l *recordingLogger) Error(args ...interface{}) { l.log(args...) }
+
+func TestNewRequestOptions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if r.UserAgent() != "harbor-sync/1.0" || user != "admin" || password != "secret" {
+			t.Errorf("request with User-Agent %q and credentials %s:%s", r.UserAgent(), user, password)
+		}
+		w.Write([]byte("{}"))
+	}))
+	defer srv.Close()
+
+	var (
+		logger = &recordingLogger{}
+		hc     = &http.Client{}
+	)
+	c, err := New(WithBaseURL(srv.URL), WithBasicAuth("admin", "secret"), WithUserAgent("harbor-sync/1.0"), WithLogger(logger), WithHTTPClient(hc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	logger.mu.Lock()
+	defer logger.mu.Unlock()
+	if len(logger.msgs) == 0 || !strings.Contains(strings.Join(logger.msgs, "\n"), srv.URL+"/api/projects") {
+		t.Errorf("logged %q, want the URL of the request", logger.msgs)
+	}
+}
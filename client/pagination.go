@@ -0,0 +1,225 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strconv"
+	"sync"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// defaultPageSize is the page size Harbor uses when none is requested.
+const defaultPageSize = 10
+
+var linkRegexp = regexp.MustCompile(`<([^>]*)>\s*;\s*rel="?(\w+)"?`)
+
+// Pagination holds the paging information Harbor sends along with a list.
+type Pagination struct {
+	// Total number of items, read from X-Total-Count; -1 when unknown.
+	Total int
+	// Page numbers linked from the Link header, 0 when absent.
+	NextPage int
+	PrevPage int
+	// Whether the response carried a Link header at all.
+	HasLinks bool
+}
+
+// ParsePagination reads the X-Total-Count and Link headers of resp.
+func ParsePagination(resp *gorequest.Response) Pagination {
+	p := Pagination{Total: -1}
+	if resp == nil || *resp == nil {
+		return p
+	}
+	h := (*resp).Header
+	if total, err := strconv.Atoi(h.Get("X-Total-Count")); err == nil {
+		p.Total = total
+	}
+	for _, link := range h["Link"] {
+		for _, m := range linkRegexp.FindAllStringSubmatch(link, -1) {
+			p.HasLinks = true
+			u, err := url.Parse(m[1])
+			if err != nil {
+				continue
+			}
+			page, _ := strconv.Atoi(u.Query().Get("page"))
+			switch m[2] {
+			case "next":
+				p.NextPage = page
+			case "prev":
+				p.PrevPage = page
+			}
+		}
+	}
+	return p
+}
+
+// PageFunc fetches the page of a list endpoint described by opt and returns
+// the number of items received.
+type PageFunc func(ctx context.Context, opt ListOptions) (int, *gorequest.Response, []error)
+
+// Pager walks the pages of a list endpoint, following the Link headers
+// returned by Harbor until the last page.
+type Pager struct {
+	ctx   context.Context
+	opt   ListOptions
+	fetch PageFunc
+	total int
+	done  bool
+	err   error
+}
+
+// NewPager returns a Pager starting at opt.Page, or at the first page when
+// opt.Page is not set.
+func NewPager(ctx context.Context, opt ListOptions, fetch PageFunc) *Pager {
+	if opt.Page <= 0 {
+		opt.Page = 1
+	}
+	if opt.PageSize <= 0 {
+		opt.PageSize = defaultPageSize
+	}
+	return &Pager{ctx: ctx, opt: opt, fetch: fetch, total: -1}
+}
+
+// Next fetches the next page. It returns false once every page has been
+// read, Stop has been called or an error occurred.
+func (p *Pager) Next() bool {
+	if p.done || p.err != nil {
+		return false
+	}
+	if err := p.ctx.Err(); err != nil {
+		p.err = err
+		return false
+	}
+	n, resp, errs := p.fetch(p.ctx, p.opt)
+	if len(errs) != 0 {
+		p.err = errs[0]
+		return false
+	}
+	pg := ParsePagination(resp)
+	if pg.Total >= 0 {
+		p.total = pg.Total
+	}
+	switch {
+	case n == 0:
+		p.done = true
+		return false
+	case pg.HasLinks:
+		if pg.NextPage == 0 {
+			p.done = true
+		} else {
+			p.opt.Page = pg.NextPage
+		}
+	case p.total >= 0:
+		// No Link header, rely on the total count
+		if p.opt.Page*p.opt.PageSize >= p.total {
+			p.done = true
+		} else {
+			p.opt.Page++
+		}
+	default:
+		if n < p.opt.PageSize {
+			p.done = true
+		} else {
+			p.opt.Page++
+		}
+	}
+	return true
+}
+
+// Stop ends the iteration early; Next returns false afterwards.
+func (p *Pager) Stop() {
+	p.done = true
+}
+
+// Total returns the total number of items reported by Harbor, or -1 when it
+// is not known yet.
+func (p *Pager) Total() int {
+	return p.total
+}
+
+// Err returns the error that stopped the iteration, if any.
+func (p *Pager) Err() error {
+	return p.err
+}
+
+// FetchAll retrieves every page of a list endpoint. With concurrency greater
+// than one, the first page is fetched to learn the total count and the
+// remaining pages are fetched by up to concurrency goroutines; fetch must then
+// be safe for concurrent use and key its results by opt.Page.
+func FetchAll(ctx context.Context, opt ListOptions, concurrency int, fetch PageFunc) []error {
+	p := NewPager(ctx, opt, fetch)
+	if concurrency <= 1 {
+		for p.Next() {
+		}
+		if err := p.Err(); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	if !p.Next() {
+		if err := p.Err(); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+	if p.done {
+		return nil
+	}
+	if p.total < 0 {
+		// Without a total the page count is unknown, walk the rest in order
+		for p.Next() {
+		}
+		if err := p.Err(); err != nil {
+			return []error{err}
+		}
+		return nil
+	}
+
+	var pages []int
+	for page := p.opt.Page; (page-1)*p.opt.PageSize < p.total; page++ {
+		pages = append(pages, page)
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, concurrency)
+	)
+	for _, page := range pages {
+		o := p.opt
+		o.Page = page
+		wg.Add(1)
+		go func(o ListOptions) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+			if _, _, e := fetch(ctx, o); len(e) != 0 {
+				mu.Lock()
+				// Keep the error that cancelled the others only
+				if errs == nil {
+					errs = e
+				}
+				mu.Unlock()
+				cancel()
+			}
+		}(o)
+	}
+	wg.Wait()
+	// The pages left waiting for a slot when the caller gave up were never
+	// fetched
+	if errs == nil && parent.Err() != nil {
+		return []error{parent.Err()}
+	}
+	return errs
+}
@@ -0,0 +1,175 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func pageResponse(header http.Header) *gorequest.Response {
+	var resp gorequest.Response = &http.Response{Header: header}
+	return &resp
+}
+
+func TestParsePagination(t *testing.T) {
+	for _, tt := range []struct {
+		header http.Header
+		want   Pagination
+	}{
+		{http.Header{}, Pagination{Total: -1}},
+		{http.Header{"X-Total-Count": {"42"}}, Pagination{Total: 42}},
+		{
+			http.Header{
+				"X-Total-Count": {"42"},
+				"Link":          {`</api/projects?page=1&page_size=10>; rel="prev" , </api/projects?page=3&page_size=10>; rel="next"`},
+			},
+			Pagination{Total: 42, PrevPage: 1, NextPage: 3, HasLinks: true},
+		},
+		{http.Header{"Link": {`</api/projects?page=4>; rel=prev`}}, Pagination{Total: -1, PrevPage: 4, HasLinks: true}},
+	} {
+		if got := ParsePagination(pageResponse(tt.header)); got != tt.want {
+			t.Errorf("ParsePagination(%v) = %+v, want %+v", tt.header, got, tt.want)
+		}
+	}
+	if got := ParsePagination(nil); got.Total != -1 {
+		t.Errorf("ParsePagination(nil) = %+v", got)
+	}
+}
+
+// pagedList serves n items by pages, with the headers chosen by links and
+// total.
+func pagedList(n int, links, total bool) (PageFunc, func() []int) {
+	var (
+		mu    sync.Mutex
+		pages []int
+	)
+	fetch := func(ctx context.Context, opt ListOptions) (int, *gorequest.Response, []error) {
+		mu.Lock()
+		pages = append(pages, opt.Page)
+		mu.Unlock()
+		header := http.Header{}
+		if total {
+			header.Set("X-Total-Count", fmt.Sprint(n))
+		}
+		if links && opt.Page*opt.PageSize < n {
+			header.Set("Link", fmt.Sprintf(`</api/x?page=%d>; rel="next"`, opt.Page+1))
+		}
+		count := n - (opt.Page-1)*opt.PageSize
+		if count > opt.PageSize {
+			count = opt.PageSize
+		}
+		if count < 0 {
+			count = 0
+		}
+		return count, pageResponse(header), nil
+	}
+	return fetch, func() []int {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]int(nil), pages...)
+	}
+}
+
+func TestPager(t *testing.T) {
+	for _, tt := range []struct {
+		name         string
+		links, total bool
+		n            int
+		pages        []int
+	}{
+		{"links", true, true, 25, []int{1, 2, 3}},
+		{"total", false, true, 25, []int{1, 2, 3}},
+		{"total of a full last page", false, true, 20, []int{1, 2}},
+		{"short page", false, false, 25, []int{1, 2, 3}},
+		{"empty page", false, false, 20, []int{1, 2, 3}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fetch, pages := pagedList(tt.n, tt.links, tt.total)
+			p := NewPager(context.Background(), ListOptions{PageSize: 10}, fetch)
+			for p.Next() {
+			}
+			if err := p.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got := pages(); !reflect.DeepEqual(got, tt.pages) {
+				t.Errorf("pages %v, want %v", got, tt.pages)
+			}
+		})
+	}
+}
+
+func TestPagerStopAndError(t *testing.T) {
+	fetch, pages := pagedList(100, true, true)
+	p := NewPager(context.Background(), ListOptions{}, fetch)
+	if !p.Next() || p.Total() != 100 {
+		t.Fatalf("first page: total %d", p.Total())
+	}
+	p.Stop()
+	if p.Next() || len(pages()) != 1 {
+		t.Errorf("Next after Stop fetched %v", pages())
+	}
+
+	boom := errors.New("boom")
+	p = NewPager(context.Background(), ListOptions{}, func(ctx context.Context, opt ListOptions) (int, *gorequest.Response, []error) {
+		return 0, nil, []error{boom}
+	})
+	if p.Next() || p.Err() != boom {
+		t.Errorf("Err() = %v, want %v", p.Err(), boom)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	p = NewPager(ctx, ListOptions{}, fetch)
+	if p.Next() || !errors.Is(p.Err(), context.Canceled) {
+		t.Errorf("Err() with a cancelled context = %v", p.Err())
+	}
+}
+
+func TestFetchAll(t *testing.T) {
+	fetch, pages := pagedList(95, true, true)
+	if errs := FetchAll(context.Background(), ListOptions{PageSize: 10}, 4, fetch); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	got := map[int]int{}
+	for _, page := range pages() {
+		got[page]++
+	}
+	if len(got) != 10 {
+		t.Errorf("pages fetched %v, want 1 to 10 once", pages())
+	}
+	for page, n := range got {
+		if n != 1 {
+			t.Errorf("page %d fetched %d times", page, n)
+		}
+	}
+
+	boom := errors.New("boom")
+	errs := FetchAll(context.Background(), ListOptions{PageSize: 10}, 4, func(ctx context.Context, opt ListOptions) (int, *gorequest.Response, []error) {
+		if opt.Page == 5 {
+			return 0, nil, []error{boom}
+		}
+		return fetch(ctx, opt)
+	})
+	if len(errs) != 1 || errs[0] != boom {
+		t.Errorf("FetchAll = %v, want %v", errs, boom)
+	}
+
+	// Pages skipped because the caller gave up are reported
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	errs = FetchAll(ctx, ListOptions{PageSize: 10}, 2, func(ctx context.Context, opt ListOptions) (int, *gorequest.Response, []error) {
+		if opt.Page == 2 {
+			cancel()
+		}
+		return fetch(ctx, opt)
+	})
+	if len(errs) != 1 || errs[0] != context.Canceled {
+		t.Errorf("FetchAll = %v, want %v", errs, context.Canceled)
+	}
+}
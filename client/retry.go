@@ -0,0 +1,165 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+// RetryPolicy describes how Client.Do retries a request that failed with a
+// transient error, such as a 502/503 returned while Harbor core restarts or
+// a reset connection.
+type RetryPolicy struct {
+	// Total number of attempts, including the first one. A value lower than
+	// 2 disables retries.
+	MaxAttempts int
+	// Delay before the first retry, multiplied by Multiplier after every
+	// attempt and capped by MaxBackoff. MaxBackoff also caps the delay asked
+	// by a Retry-After header.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	// Fraction of the delay, between 0 and 1, that is randomized.
+	Jitter float64
+	// Status codes worth retrying.
+	RetryableStatus []int
+	// Also retry non idempotent methods (POST, PATCH). Only enable it for
+	// calls that are safe to repeat.
+	RetryNonIdempotent bool
+}
+
+// DefaultRetryPolicy returns a policy of 3 attempts with exponential backoff
+// starting at 500ms, retrying 429, 502, 503 and 504 on idempotent methods.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  500 * time.Millisecond,
+		MaxBackoff:      10 * time.Second,
+		Multiplier:      2,
+		Jitter:          0.2,
+		RetryableStatus: []int{http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+	}
+}
+
+// ShouldRetry reports whether a request sent with method, which ended with
+// resp or err, is worth retrying.
+func (p *RetryPolicy) ShouldRetry(method string, resp *http.Response, err error) bool {
+	if !p.RetryNonIdempotent && !isIdempotent(method) {
+		return false
+	}
+	if err != nil {
+		return isTransient(err)
+	}
+	if resp == nil {
+		return false
+	}
+	for _, code := range p.RetryableStatus {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Backoff returns the delay to wait after the given attempt, starting at 1.
+// A Retry-After header in resp takes precedence over the computed delay, up
+// to MaxBackoff, so that a server cannot stall the client for hours.
+func (p *RetryPolicy) Backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if d, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if p.MaxBackoff > 0 && d > p.MaxBackoff {
+				d = p.MaxBackoff
+			}
+			return d
+		}
+	}
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	d := float64(p.InitialBackoff) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (rand.Float64()*2 - 1)
+	}
+	return time.Duration(d)
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isTransient(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func parseRetryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+type retryPolicyKey struct{}
+
+// ContextWithRetryPolicy returns a copy of ctx carrying p, which overrides
+// the retry policy of the client for the calls made with it. A nil policy
+// disables retries for those calls.
+func ContextWithRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+// SetRetryPolicy sets the retry policy applied to every request of the
+// client. A nil policy, the default, disables retries. It must be called
+// before the client is shared between goroutines.
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.retryPolicy = p
+}
+
+func (c *Client) retryPolicyFor(ctx context.Context) *RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(*RetryPolicy); ok {
+		return p
+	}
+	return c.retryPolicy
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func TestBackoff(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2}
+	for _, tt := range []struct {
+		attempt    int
+		retryAfter string
+		want       time.Duration
+	}{
+		{attempt: 1, want: 100 * time.Millisecond},
+		{attempt: 2, want: 200 * time.Millisecond},
+		{attempt: 3, want: 400 * time.Millisecond},
+		{attempt: 10, want: time.Second},
+		{attempt: 1, retryAfter: "0", want: 0},
+		{attempt: 1, retryAfter: "1", want: time.Second},
+		// Capped by MaxBackoff
+		{attempt: 1, retryAfter: "3600", want: time.Second},
+		{attempt: 1, retryAfter: time.Now().Add(time.Hour).UTC().Format(http.TimeFormat), want: time.Second},
+		// Not a delay, the computed one is used
+		{attempt: 2, retryAfter: "soon", want: 200 * time.Millisecond},
+	} {
+		resp := &http.Response{Header: http.Header{}}
+		if tt.retryAfter != "" {
+			resp.Header.Set("Retry-After", tt.retryAfter)
+		}
+		if got := p.Backoff(tt.attempt, resp); got != tt.want {
+			t.Errorf("Backoff(%d, Retry-After %q) = %v, want %v", tt.attempt, tt.retryAfter, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffRetryAfterUncapped(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond}
+	resp := &http.Response{Header: http.Header{"Retry-After": {"120"}}}
+	if got := p.Backoff(1, resp); got != 2*time.Minute {
+		t.Errorf("Backoff without MaxBackoff = %v, want 2m", got)
+	}
+}
+
+func TestShouldRetry(t *testing.T) {
+	p := DefaultRetryPolicy()
+	for _, tt := range []struct {
+		method string
+		status int
+		err    error
+		want   bool
+	}{
+		{method: http.MethodGet, status: http.StatusServiceUnavailable, want: true},
+		{method: http.MethodGet, status: http.StatusTooManyRequests, want: true},
+		{method: http.MethodGet, status: http.StatusInternalServerError},
+		{method: http.MethodGet, status: http.StatusNotFound},
+		{method: http.MethodPost, status: http.StatusServiceUnavailable},
+		{method: http.MethodGet, err: context.Canceled},
+	} {
+		var resp *http.Response
+		if tt.err == nil {
+			resp = &http.Response{StatusCode: tt.status}
+		}
+		if got := p.ShouldRetry(tt.method, resp, tt.err); got != tt.want {
+			t.Errorf("ShouldRetry(%s, %d, %v) = %v, want %v", tt.method, tt.status, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestDoRetriesWithCappedRetryAfter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"harbor_version":"v1.10.0"}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(WithBaseURL(srv.URL), WithAPIVersion(APIVersion1), WithRetryPolicy(&RetryPolicy{
+		MaxAttempts:     3,
+		InitialBackoff:  time.Millisecond,
+		MaxBackoff:      10 * time.Millisecond,
+		RetryableStatus: []int{http.StatusServiceUnavailable},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var info SystemInfo
+	if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/systeminfo"), &info); len(errs) != 0 {
+		t.Fatalf("Do: %v", errs)
+	}
+	if calls != 3 || info.HarborVersion != "v1.10.0" {
+		t.Errorf("%d calls, version %q; want 3 calls, v1.10.0", calls, info.HarborVersion)
+	}
+}
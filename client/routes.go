@@ -0,0 +1,86 @@
+package client
+
+import "github.com/spf13/viper"
+
+// defaultRoutes is the route table of the Harbor API, keyed like the api
+// section of config/harbor.yaml. Entries of a configuration file take
+// precedence over it.
+var defaultRoutes = map[string]string{
+	"api.root":                               "api",
+	"api.v2.root":                            "api/v2.0",
+	"api.identity.login":                     "/c/login",
+	"api.identity.logout":                    "/c/log_out",
+	"api.identity.token":                     "/service/token",
+	"api.projects.root":                      "/projects",
+	"api.projects.base":                      "/projects/%d",
+	"api.projects.metadatas.root":            "/projects/%d/metadatas",
+	"api.projects.metadatas.base":            "/projects/%d/metadatas/%s",
+	"api.projects.logs.root":                 "/projects/%d/logs",
+	"api.projects.members.root":              "/projects/%d/members",
+	"api.projects.members.base":              "/projects/%d/members/%d",
+	"api.projects.robots.root":               "/projects/%d/robots",
+	"api.projects.robots.base":               "/projects/%d/robots/%d",
+	"api.robots.root":                        "/robots",
+	"api.robots.base":                        "/robots/%d",
+	"api.statistics.root":                    "/statistics",
+	"api.user.root":                          "/users",
+	"api.user.base":                          "/users/%d",
+	"api.user.current":                       "/users/current",
+	"api.user.password":                      "/users/%d/password",
+	"api.user.sysadmin":                      "/users/%d/sysadmin",
+	"api.repositories.root":                  "/repositories",
+	"api.repositories.base":                  "/repositories/%s",
+	"api.repositories.labels.root":           "/repositories/%s/labels",
+	"api.repositories.labels.base":           "/repositories/%s/labels/%d",
+	"api.repositories.tags.root":             "/repositories/%s/%s/tags",
+	"api.repositories.tags.base":             "/repositories/%s/%s/tags/%s",
+	"api.repositories.tags.manifest.root":    "/repositories/%s/tags/%s/manifest",
+	"api.repositories.tags.manifest.version": "/repositories/%s/tags/%s/manifest?version=%s",
+	"api.repositories.signatures":            "/repositories/%s/signatures",
+	"api.repositories.top.root":              "/repositories/top",
+	"api.logs.root":                          "/logs",
+	"api.jobs.root":                          "/jobs/replication",
+	"api.jobs.base":                          "replication",
+	"api.jobs.log.root":                      "/jobs/replication/%d/log",
+	"api.jobs.log.scan":                      "/jobs/scan/%d/log",
+	"api.policies.root":                      "/policies/replication",
+	"api.policies.base":                      "/policies/replication/%d",
+	"api.replications.root":                  "/replications",
+	"api.targets.root":                       "/targets",
+	"api.targets.base":                       "/targets/%d",
+	"api.targets.ping":                       "/targets/ping",
+	"api.targets.policies":                   "/targets/%d/policies/",
+	"api.configurations.root":                "/configurations",
+	"api.configurations.reset":               "/configurations/reset",
+	"api.systeminfo":                         "/systeminfo",
+
+	// Routes of Harbor 2.x that differ from 1.x, used when the client talks
+	// to the v2.0 API.
+	"api.v2.projects.logs.root":                     "/projects/%s/logs",
+	"api.v2.repositories.all":                       "/repositories",
+	"api.v2.repositories.root":                      "/projects/%s/repositories",
+	"api.v2.repositories.base":                      "/projects/%s/repositories/%s",
+	"api.v2.repositories.artifacts.root":            "/projects/%s/repositories/%s/artifacts",
+	"api.v2.repositories.artifacts.base":            "/projects/%s/repositories/%s/artifacts/%s",
+	"api.v2.repositories.artifacts.tags.base":       "/projects/%s/repositories/%s/artifacts/%s/tags/%s",
+	"api.v2.repositories.artifacts.scan":            "/projects/%s/repositories/%s/artifacts/%s/scan",
+	"api.v2.repositories.artifacts.vulnerabilities": "/projects/%s/repositories/%s/artifacts/%s/additions/vulnerabilities",
+	"api.v2.repositories.artifacts.tags.root":       "/projects/%s/repositories/%s/artifacts/%s/tags",
+	"api.v2.repositories.artifacts.additions":       "/projects/%s/repositories/%s/artifacts/%s/additions/%s",
+	"api.v2.registries.root":                        "/registries",
+	"api.v2.registries.base":                        "/registries/%d",
+	"api.v2.registries.ping":                        "/registries/ping",
+	"api.v2.replication.policies.root":              "/replication/policies",
+	"api.v2.replication.policies.base":              "/replication/policies/%d",
+	"api.v2.replication.executions.root":            "/replication/executions",
+	"api.v2.replication.executions.base":            "/replication/executions/%d",
+	"api.v2.replication.executions.tasks":           "/replication/executions/%d/tasks",
+	"api.v2.replication.executions.log":             "/replication/executions/%d/tasks/%d/log",
+}
+
+// setDefaultRoutes registers defaultRoutes as defaults of config.
+func setDefaultRoutes(config *viper.Viper) {
+	for key, route := range defaultRoutes {
+		config.SetDefault(key, route)
+	}
+}
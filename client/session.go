@@ -0,0 +1,215 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	// csrfHeader carries the CSRF token Harbor 2.x requires on mutating
+	// requests, along with the _gorilla_csrf cookie.
+	csrfHeader = "X-Harbor-CSRF-Token"
+	// sessionCookie is the cookie of an authenticated Harbor session.
+	sessionCookie = "sid"
+)
+
+// Session is an Authenticator using a Harbor session, the way the web portal
+// does, instead of sending the credentials on every request. It logs in on
+// the first request, keeps the sid cookie and the CSRF token returned by
+// Harbor, logs in again when the session expires, and logs out on
+// Client.Close.
+type Session struct {
+	username string
+	password string
+
+	mu       sync.Mutex
+	jar      http.CookieJar
+	csrf     string
+	loggedIn bool
+}
+
+// NewSession returns a Session logging in as username.
+func NewSession(username, password string) *Session {
+	jar, _ := cookiejar.New(nil)
+	return &Session{username: username, password: password, jar: jar}
+}
+
+// WithSession authenticates the client with a session opened with username
+// and password, see Session.
+func WithSession(username, password string) Option {
+	return func(c *Client) error {
+		c.auth = NewSession(username, password)
+		return nil
+	}
+}
+
+// dropJar removes the cookie jar of the HTTP client of c, which would keep
+// and send the session cookies along with the ones of the Session, and
+// still hold them after the logout.
+func (s *Session) dropJar(c *Client) {
+	if c.httpClient.Jar == nil {
+		return
+	}
+	hc := *c.httpClient
+	hc.Jar = nil
+	c.httpClient = &hc
+}
+
+// Authenticate logs in if needed and adds the session cookies, and the CSRF
+// token for mutating requests, to req.
+func (s *Session) Authenticate(ctx context.Context, c *Client, req *http.Request) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedIn {
+		if err := s.login(ctx, c); err != nil {
+			return err
+		}
+	}
+	s.decorate(req)
+	return nil
+}
+
+// Observe keeps the cookies and the CSRF token of resp, which Harbor renews
+// along the way. When Harbor rejected the session, the next request logs in
+// again; requests rejected concurrently only log in once, since the session
+// they used is compared to the current one.
+func (s *Session) Observe(req *http.Request, resp *http.Response) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.record(resp)
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	if used, err := req.Cookie(sessionCookie); err == nil && used.Value == s.cookie(req.URL, sessionCookie) {
+		s.loggedIn = false
+	}
+	return true
+}
+
+func (s *Session) decorate(req *http.Request) {
+	for _, cookie := range s.jar.Cookies(req.URL) {
+		req.AddCookie(cookie)
+	}
+	switch req.Method {
+	case gorequest.GET, gorequest.HEAD, gorequest.OPTIONS:
+	default:
+		if s.csrf != "" {
+			req.Header.Set(csrfHeader, s.csrf)
+		}
+	}
+}
+
+func (s *Session) record(resp *http.Response) {
+	if cookies := resp.Cookies(); len(cookies) != 0 {
+		s.jar.SetCookies(resp.Request.URL, cookies)
+	}
+	if token := resp.Header.Get(csrfHeader); token != "" {
+		s.csrf = token
+	}
+}
+
+// login opens a new session, s.mu being held.
+func (s *Session) login(ctx context.Context, c *Client) error {
+	// Any GET hands out a CSRF token, which the login itself requires on
+	// Harbor 2.x. Harbor 1.x answers 404 here and needs no token.
+	probe, err := http.NewRequest(gorequest.GET, c.baseURL.String()+c.config.GetString("api.v2.root")+c.config.GetString("api.systeminfo"), nil)
+	if err != nil {
+		return err
+	}
+	if _, _, err := s.send(ctx, c, probe); err != nil {
+		return err
+	}
+
+	form := url.Values{"principal": {s.username}, "password": {s.password}}
+	req, err := http.NewRequest(gorequest.POST, c.identityURL("api.identity.login"), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, body, err := s.send(ctx, c, req)
+	if err != nil {
+		return err
+	}
+	if err := CheckResponse(resp, body); err != nil {
+		return err
+	}
+	if s.cookie(req.URL, sessionCookie) == "" {
+		return errors.New("harbor: login did not return a session cookie")
+	}
+	s.loggedIn = true
+	c.logger.Debug("已登入 Harbor，使用者為", s.username)
+	return nil
+}
+
+// Close logs out of the session, if any.
+func (s *Session) Close(ctx context.Context, c *Client) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.loggedIn {
+		return nil
+	}
+	s.loggedIn = false
+	req, err := http.NewRequest(gorequest.GET, c.identityURL("api.identity.logout"), nil)
+	if err != nil {
+		return err
+	}
+	resp, body, err := s.send(ctx, c, req)
+	// Forget the session whatever Harbor answered
+	s.jar, _ = cookiejar.New(nil)
+	s.csrf = ""
+	if err != nil {
+		return err
+	}
+	return CheckResponse(resp, body)
+}
+
+// send performs a request of the login flow, s.mu being held.
+func (s *Session) send(ctx context.Context, c *Client, req *http.Request) (*http.Response, []byte, error) {
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	s.decorate(req)
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.record(resp)
+	return resp, body, nil
+}
+
+// cookie returns the value of the cookie name sent to u, if any.
+func (s *Session) cookie(u *url.URL, name string) string {
+	for _, cookie := range s.jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// identityURL returns the URL of an identity route, served outside of the
+// API root.
+func (c *Client) identityURL(key string) string {
+	return c.baseURL.String() + strings.TrimPrefix(c.config.GetString(key), "/")
+}
+
+// Close releases the resources of the client, e.g. logs out of its session.
+func (c *Client) Close() error {
+	if closer, ok := c.auth.(AuthCloser); ok {
+		return closer.Close(context.Background(), c)
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// sessionServer is a Harbor 2.x handing out sessions and CSRF tokens, which
+// can expire its current session.
+type sessionServer struct {
+	mu      sync.Mutex
+	sid     string
+	logins  int
+	logouts int
+	posts   int
+	// Session cookies of the last request
+	sent []string
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sent = nil
+	for _, c := range r.Cookies() {
+		if c.Name == sessionCookie {
+			s.sent = append(s.sent, c.Value)
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: "_gorilla_csrf", Value: "csrf-cookie", Path: "/"})
+	w.Header().Set(csrfHeader, "csrf-token")
+	if r.Method != http.MethodGet {
+		if c, err := r.Cookie("_gorilla_csrf"); err != nil || c.Value != "csrf-cookie" || r.Header.Get(csrfHeader) != "csrf-token" {
+			http.Error(w, `{"errors":[{"code":"FORBIDDEN","message":"CSRF token invalid"}]}`, http.StatusForbidden)
+			return
+		}
+	}
+	switch r.URL.Path {
+	case "/api/v2.0/systeminfo":
+		w.Write([]byte(`{}`))
+	case "/c/login":
+		if r.FormValue("principal") != "admin" || r.FormValue("password") != "Harbor12345" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		s.logins++
+		s.sid = fmt.Sprintf("sid-%d", s.logins)
+		http.SetCookie(w, &http.Cookie{Name: sessionCookie, Value: s.sid, Path: "/"})
+	case "/c/log_out":
+		s.logouts++
+		s.sid = ""
+	default:
+		if c, err := r.Cookie(sessionCookie); err != nil || s.sid == "" || c.Value != s.sid {
+			http.Error(w, `{"errors":[{"code":"UNAUTHORIZED","message":"unauthorized"}]}`, http.StatusUnauthorized)
+			return
+		}
+		if r.Method == http.MethodPost {
+			s.posts++
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.Write([]byte(`[]`))
+	}
+}
+
+// expire ends the current session.
+func (s *sessionServer) expire() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sid = "expired"
+}
+
+func (s *sessionServer) counts() (logins, logouts, posts int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.logins, s.logouts, s.posts
+}
+
+func (s *sessionServer) sessionCookies() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sent
+}
+
+func newSessionClient(t *testing.T, password string) (*sessionServer, *Client) {
+	s := &sessionServer{}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	c, err := New(WithBaseURL(srv.URL), WithAPIVersion(APIVersion2), WithSession("admin", password))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s, c
+}
+
+func TestSession(t *testing.T) {
+	s, c := newSessionClient(t, "Harbor12345")
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+			t.Fatal(errs)
+		}
+	}
+	// Mutating requests carry the CSRF token
+	if _, errs := c.Do(ctx, c.NewRequest(gorequest.POST, "/projects").Send(`{"project_name":"library"}`), nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if logins, _, posts := s.counts(); logins != 1 || posts != 1 {
+		t.Errorf("%d logins and %d posts, want 1 and 1", logins, posts)
+	}
+	if sent := s.sessionCookies(); !reflect.DeepEqual(sent, []string{"sid-1"}) {
+		t.Errorf("session cookies sent %q, want the one of the session", sent)
+	}
+
+	s.expire()
+	if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatalf("request after the session expired: %v", errs)
+	}
+	if logins, _, _ := s.counts(); logins != 2 {
+		t.Errorf("%d logins after the session expired, want 2", logins)
+	}
+
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, logouts, _ := s.counts(); logouts != 1 {
+		t.Errorf("%d logouts, want 1", logouts)
+	}
+	// The HTTP client keeps no cookie of the closed session
+	resp, err := c.httpClient.Get(c.baseURL.String() + "api/v2.0/projects")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if sent := s.sessionCookies(); len(sent) != 0 {
+		t.Errorf("session cookies sent after Close: %q", sent)
+	}
+}
+
+func TestSessionHTTPClientJar(t *testing.T) {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc := &http.Client{Jar: jar}
+	c, err := New(WithBaseURL("https://harbor.example.com"), WithHTTPClient(hc), WithSession("admin", "Harbor12345"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.httpClient.Jar != nil {
+		t.Error("the HTTP client of a session client has a cookie jar")
+	}
+	if hc.Jar != jar {
+		t.Error("the HTTP client given was changed")
+	}
+}
+
+func TestSessionConcurrentExpiry(t *testing.T) {
+	s, c := newSessionClient(t, "Harbor12345")
+	ctx := context.Background()
+	if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	s.expire()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, errs := c.Do(ctx, c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+				t.Error(errs)
+			}
+		}()
+	}
+	wg.Wait()
+	if logins, _, _ := s.counts(); logins != 2 {
+		t.Errorf("%d logins after the session expired, want 2", logins)
+	}
+}
+
+func TestSessionLoginFailure(t *testing.T) {
+	s, c := newSessionClient(t, "wrong")
+	_, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil)
+	if len(errs) != 1 || !IsUnauthorized(errs[0]) {
+		t.Fatalf("Do with wrong credentials: %v, want unauthorized", errs)
+	}
+	if logins, _, _ := s.counts(); logins != 0 {
+		t.Errorf("%d logins", logins)
+	}
+	// No session was opened, so none is closed
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, logouts, _ := s.counts(); logouts != 0 {
+		t.Errorf("%d logouts", logouts)
+	}
+}
@@ -0,0 +1,129 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// SystemInfo holds the general information Harbor exposes about itself.
+type SystemInfo struct {
+	HarborVersion              string `json:"harbor_version"`
+	AuthMode                   string `json:"auth_mode"`
+	ProjectCreationRestriction string `json:"project_creation_restriction"`
+	SelfRegistration           bool   `json:"self_registration"`
+	HasCARoot                  bool   `json:"has_ca_root"`
+	RegistryURL                string `json:"registry_url"`
+	ExternalURL                string `json:"external_url"`
+	WithNotary                 bool   `json:"with_notary"`
+	WithChartmuseum            bool   `json:"with_chartmuseum"`
+	ReadOnly                   bool   `json:"read_only"`
+}
+
+// GetSystemInfo returns the general information of the Harbor server.
+func (c *Client) GetSystemInfo() (SystemInfo, *gorequest.Response, []error) {
+	return c.GetSystemInfoContext(context.Background())
+}
+
+// GetSystemInfoContext is like GetSystemInfo but bound to ctx.
+func (c *Client) GetSystemInfoContext(ctx context.Context) (SystemInfo, *gorequest.Response, []error) {
+	var info SystemInfo
+	resp, errs := c.Do(ctx, c.NewRequest(gorequest.GET, c.config.GetString("api.systeminfo")), &info)
+	return info, resp, errs
+}
+
+// NegotiateAPIVersion returns the API version of the client. When it is
+// APIVersionAuto, the server is asked through /api/v2.0/systeminfo, falling
+// back to /api/systeminfo for Harbor 1.x, with ctx. The answer is kept for
+// the following calls, and so is a failure that asking again would not fix,
+// e.g. a server that is not Harbor. Concurrent callers wait for a single
+// negotiation, or for their own ctx to be done.
+func (c *Client) NegotiateAPIVersion(ctx context.Context) (APIVersion, error) {
+	if c.apiVersion != APIVersionAuto {
+		return c.apiVersion, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	for {
+		c.versionMu.Lock()
+		if c.negotiated != "" || c.negotiateErr != nil {
+			v, err := c.negotiated, c.negotiateErr
+			c.versionMu.Unlock()
+			return v, err
+		}
+		if wait := c.negotiating; wait != nil {
+			c.versionMu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				return "", ctx.Err()
+			}
+		}
+		done := make(chan struct{})
+		c.negotiating = done
+		c.versionMu.Unlock()
+
+		v, err := c.negotiate(ctx)
+
+		c.versionMu.Lock()
+		if err == nil {
+			c.negotiated = v
+		} else if definitive(err) {
+			c.negotiateErr = fmt.Errorf("harbor: cannot detect the API version: %w", err)
+			err = c.negotiateErr
+		}
+		c.negotiating = nil
+		close(done)
+		c.versionMu.Unlock()
+		return v, err
+	}
+}
+
+// negotiate asks the server for its API version.
+func (c *Client) negotiate(ctx context.Context) (APIVersion, error) {
+	var (
+		info    SystemInfo
+		path    = c.config.GetString("api.systeminfo")
+		version = APIVersion2
+	)
+	_, errs := c.Do(ctx, c.newRequest(gorequest.GET, c.config.GetString("api.v2.root")+path), &info)
+	if len(errs) != 0 && IsNotFound(errs[0]) {
+		version = APIVersion1
+		_, errs = c.Do(ctx, c.newRequest(gorequest.GET, c.config.GetString("api.root")+path), &info)
+	}
+	if len(errs) != 0 {
+		c.logger.Error("無法取得 Harbor API 版本", errs[0].Error())
+		return "", errs[0]
+	}
+	c.logger.Info("Harbor 版本為", info.HarborVersion, "，使用 API", string(version))
+	return version, nil
+}
+
+// definitive reports whether err, met while negotiating the API version,
+// would happen again: the server answered, but not as Harbor does. Network
+// errors, server errors, throttling and rejected credentials are worth
+// another try.
+func definitive(err error) bool {
+	var (
+		resp      *ErrorResponse
+		syntax    *json.SyntaxError
+		unmarshal *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &resp):
+		switch resp.StatusCode {
+		case http.StatusUnauthorized, http.StatusForbidden, http.StatusRequestTimeout, http.StatusTooManyRequests:
+			return false
+		}
+		return resp.StatusCode < http.StatusInternalServerError
+	case errors.As(err, &syntax), errors.As(err, &unmarshal):
+		return true
+	}
+	return false
+}
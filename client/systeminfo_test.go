@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// versionServer answers the systeminfo endpoints with the status of
+// v1Status and v2Status, and 200 to any other request. It records the paths
+// it was asked.
+type versionServer struct {
+	mu       sync.Mutex
+	v1Status int
+	v2Status int
+	paths    []string
+}
+
+func (s *versionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.paths = append(s.paths, r.URL.Path)
+	status := http.StatusOK
+	switch r.URL.Path {
+	case "/api/systeminfo":
+		status = s.v1Status
+	case "/api/v2.0/systeminfo":
+		status = s.v2Status
+	}
+	s.mu.Unlock()
+	w.WriteHeader(status)
+	w.Write([]byte(`{"harbor_version":"v0.0.0"}`))
+}
+
+func (s *versionServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.paths...)
+}
+
+func (s *versionServer) set(v1Status, v2Status int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.v1Status, s.v2Status = v1Status, v2Status
+}
+
+func newVersionClient(t *testing.T, vs *versionServer, opts ...Option) *Client {
+	srv := httptest.NewServer(vs)
+	t.Cleanup(srv.Close)
+	c, err := New(append([]Option{WithBaseURL(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func equalPaths(got []string, want ...string) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAPIVersionDefaultsToV1(t *testing.T) {
+	vs := &versionServer{}
+	c := newVersionClient(t, vs)
+	if v := c.GetAPIVersion(); v != APIVersion1 {
+		t.Errorf("GetAPIVersion() = %s, want %s", v, APIVersion1)
+	}
+	if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if got := vs.requests(); !equalPaths(got, "/api/projects") {
+		t.Errorf("requests %q, want /api/projects only", got)
+	}
+}
+
+func TestNegotiateAPIVersionInDo(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		v1, v2   int
+		want     APIVersion
+		requests []string
+	}{
+		{
+			name:     "2.x",
+			v1:       http.StatusOK,
+			v2:       http.StatusOK,
+			want:     APIVersion2,
+			requests: []string{"/api/v2.0/systeminfo", "/api/v2.0/projects", "/api/v2.0/projects"},
+		},
+		{
+			name:     "1.x",
+			v1:       http.StatusOK,
+			v2:       http.StatusNotFound,
+			want:     APIVersion1,
+			requests: []string{"/api/v2.0/systeminfo", "/api/systeminfo", "/api/projects", "/api/projects"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			vs := &versionServer{v1Status: tt.v1, v2Status: tt.v2}
+			c := newVersionClient(t, vs, WithAPIVersion(APIVersionAuto))
+
+			req := c.NewRequest(gorequest.GET, "/projects")
+			if got := vs.requests(); len(got) != 0 {
+				t.Fatalf("NewRequest sent %q", got)
+			}
+			if v := c.GetAPIVersion(); v != APIVersionAuto {
+				t.Errorf("GetAPIVersion() before negotiation = %s, want %s", v, APIVersionAuto)
+			}
+			if _, errs := c.Do(context.Background(), req, nil); len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			if v := c.GetAPIVersion(); v != tt.want {
+				t.Errorf("GetAPIVersion() = %s, want %s", v, tt.want)
+			}
+			// Built once the version is known
+			if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+				t.Fatal(errs)
+			}
+			if got := vs.requests(); !equalPaths(got, tt.requests...) {
+				t.Errorf("requests %q, want %q", got, tt.requests)
+			}
+		})
+	}
+}
+
+func TestNegotiateAPIVersionDefinitiveFailure(t *testing.T) {
+	vs := &versionServer{v1Status: http.StatusNotFound, v2Status: http.StatusNotFound}
+	c := newVersionClient(t, vs, WithAPIVersion(APIVersionAuto))
+
+	_, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil)
+	if len(errs) == 0 || !IsNotFound(errs[0]) {
+		t.Fatalf("Do on a server that is not Harbor: %v, want not found", errs)
+	}
+	probes := len(vs.requests())
+	if _, err := c.NegotiateAPIVersion(context.Background()); !IsNotFound(err) {
+		t.Errorf("NegotiateAPIVersion: %v, want the cached not found", err)
+	}
+	if got := vs.requests(); len(got) != probes {
+		t.Errorf("the failure was not cached, requests %q", got)
+	}
+	if v := c.GetAPIVersion(); v != APIVersionAuto {
+		t.Errorf("GetAPIVersion() = %s, want %s rather than a guess", v, APIVersionAuto)
+	}
+}
+
+func TestNegotiateAPIVersionTransientFailure(t *testing.T) {
+	vs := &versionServer{v1Status: http.StatusServiceUnavailable, v2Status: http.StatusServiceUnavailable}
+	c := newVersionClient(t, vs, WithAPIVersion(APIVersionAuto))
+
+	if _, err := c.NegotiateAPIVersion(context.Background()); err == nil {
+		t.Fatal("NegotiateAPIVersion succeeded on a failing server")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.NegotiateAPIVersion(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("NegotiateAPIVersion with a cancelled context: %v", err)
+	}
+
+	vs.set(http.StatusOK, http.StatusOK)
+	if v, err := c.NegotiateAPIVersion(context.Background()); err != nil || v != APIVersion2 {
+		t.Errorf("NegotiateAPIVersion once the server is back = %s, %v; want %s", v, err, APIVersion2)
+	}
+}
+
+func TestNegotiateAPIVersionConcurrent(t *testing.T) {
+	vs := &versionServer{v1Status: http.StatusOK, v2Status: http.StatusOK}
+	c := newVersionClient(t, vs, WithAPIVersion(APIVersionAuto))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+				t.Error(errs)
+			}
+		}()
+	}
+	wg.Wait()
+	probes := 0
+	for _, p := range vs.requests() {
+		switch p {
+		case "/api/v2.0/systeminfo":
+			probes++
+		case "/api/v2.0/projects":
+		default:
+			t.Errorf("unexpected request %s", p)
+		}
+	}
+	if probes != 1 {
+		t.Errorf("%d negotiations, want 1", probes)
+	}
+}
@@ -0,0 +1,197 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+// defaultTokenService is the service the Harbor token endpoint issues
+// registry tokens for.
+const defaultTokenService = "harbor-registry"
+
+// TokenAuth is an Authenticator sending bearer tokens obtained from the
+// /service/token endpoint of Harbor to the registry API under /v2. Tokens are
+// cached per scope until they expire. Harbor does not accept these tokens on
+// its own API, whose requests are authenticated with Credentials instead.
+type TokenAuth struct {
+	// Credentials authenticating the requests to the token endpoint, nil
+	// for anonymous tokens
+	Credentials Authenticator
+	// Service the tokens are issued for, harbor-registry by default
+	Service string
+	// Scope returns the scope of the token needed by req, RegistryScope by
+	// default
+	Scope func(req *http.Request) string
+
+	mu     sync.Mutex
+	tokens map[string]bearerToken
+}
+
+type bearerToken struct {
+	value   string
+	expires time.Time
+}
+
+// NewTokenAuth returns a TokenAuth obtaining its tokens with credentials.
+func NewTokenAuth(credentials Authenticator) *TokenAuth {
+	return &TokenAuth{Credentials: credentials}
+}
+
+// Authenticate adds a bearer token for the scope of req, fetching it when
+// it is not cached, or the credentials to a request of the Harbor API.
+func (a *TokenAuth) Authenticate(ctx context.Context, c *Client, req *http.Request) error {
+	if !c.registryRequest(req) {
+		if a.Credentials == nil {
+			return nil
+		}
+		return a.Credentials.Authenticate(ctx, c, req)
+	}
+	token, err := a.token(ctx, c, a.scope(req))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Observe drops the token of the scope of req when Harbor rejected it.
+func (a *TokenAuth) Observe(req *http.Request, resp *http.Response) bool {
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "Bearer ") {
+		// A request of the Harbor API, authenticated with the credentials
+		if observer, ok := a.Credentials.(AuthObserver); ok {
+			return observer.Observe(req, resp)
+		}
+		return false
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+	a.mu.Lock()
+	delete(a.tokens, a.scope(req))
+	a.mu.Unlock()
+	return true
+}
+
+func (a *TokenAuth) scope(req *http.Request) string {
+	if a.Scope != nil {
+		return a.Scope(req)
+	}
+	return RegistryScope(req)
+}
+
+func (a *TokenAuth) token(ctx context.Context, c *Client, scope string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if t, ok := a.tokens[scope]; ok && time.Now().Before(t.expires) {
+		return t.value, nil
+	}
+
+	service := a.Service
+	if service == "" {
+		service = defaultTokenService
+	}
+	query := url.Values{"service": {service}}
+	if scope != "" {
+		query.Set("scope", scope)
+	}
+	req, err := http.NewRequest(gorequest.GET, c.identityURL("api.identity.token")+"?"+query.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	if a.Credentials != nil {
+		if err := a.Credentials.Authenticate(ctx, c, req); err != nil {
+			return "", err
+		}
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	fetched := time.Now()
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if err := CheckResponse(resp, body); err != nil {
+		return "", err
+	}
+
+	var v struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return "", err
+	}
+	if v.Token == "" {
+		v.Token = v.AccessToken
+	}
+	// Tokens without expiry are valid for 60 seconds, see the token
+	// authentication specification of the Docker registry
+	if v.ExpiresIn <= 0 {
+		v.ExpiresIn = 60
+	}
+	// Renew the token a little before it expires, to cover the latency of
+	// the requests using it
+	lifetime := time.Duration(v.ExpiresIn) * time.Second
+	if lifetime > time.Minute {
+		lifetime -= 10 * time.Second
+	}
+	if a.tokens == nil {
+		a.tokens = map[string]bearerToken{}
+	}
+	a.tokens[scope] = bearerToken{value: v.Token, expires: fetched.Add(lifetime)}
+	return v.Token, nil
+}
+
+// registryRequest tells whether req goes to the registry API, served under
+// /v2/ next to the Harbor API.
+func (c *Client) registryRequest(req *http.Request) bool {
+	path := strings.TrimPrefix(req.URL.Path, c.baseURL.Path)
+	return path == "v2" || strings.HasPrefix(path, "v2/")
+}
+
+// RegistryScope returns the scope of the token needed by a request of the
+// registry API, e.g. repository:library/nginx:pull for a pull of
+// library/nginx, or an empty scope outside of the registry API.
+func RegistryScope(req *http.Request) string {
+	path := req.URL.Path
+	i := strings.Index(path, "/v2/")
+	if i < 0 {
+		return ""
+	}
+	path = path[i+len("/v2/"):]
+	if path == "_catalog" {
+		return "registry:catalog:*"
+	}
+	for _, kind := range []string{"/manifests/", "/blobs/", "/tags/"} {
+		if j := strings.LastIndex(path, kind); j > 0 {
+			return "repository:" + path[:j] + ":" + registryActions(req.Method)
+		}
+	}
+	return ""
+}
+
+func registryActions(method string) string {
+	switch method {
+	case gorequest.GET, gorequest.HEAD:
+		return "pull"
+	case gorequest.DELETE:
+		return "delete"
+	default:
+		return "pull,push"
+	}
+}
@@ -0,0 +1,141 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/parnurzeal/gorequest"
+)
+
+func TestRegistryScope(t *testing.T) {
+	for _, tt := range []struct {
+		method, path, want string
+	}{
+		{"GET", "/v2/library/nginx/manifests/1.19", "repository:library/nginx:pull"},
+		{"HEAD", "/v2/library/tools/nginx/blobs/sha256:a", "repository:library/tools/nginx:pull"},
+		{"GET", "/v2/library/nginx/tags/list", "repository:library/nginx:pull"},
+		{"PUT", "/v2/library/nginx/manifests/1.19", "repository:library/nginx:pull,push"},
+		{"DELETE", "/v2/library/nginx/manifests/sha256:a", "repository:library/nginx:delete"},
+		// The repository may itself be named after a kind
+		{"GET", "/v2/library/manifests/manifests/1.19", "repository:library/manifests:pull"},
+		{"GET", "/v2/_catalog", "registry:catalog:*"},
+		{"GET", "/harbor/v2/library/nginx/manifests/1.19", "repository:library/nginx:pull"},
+		{"GET", "/v2/", ""},
+		{"GET", "/api/v2.0/projects", ""},
+	} {
+		req, _ := http.NewRequest(tt.method, "https://harbor.example.com"+tt.path, nil)
+		if got := RegistryScope(req); got != tt.want {
+			t.Errorf("RegistryScope(%s %s) = %q, want %q", tt.method, tt.path, got, tt.want)
+		}
+	}
+}
+
+// tokenServer issues numbered tokens for the scopes requested by admin, and
+// serves the registry API to the holders of the last token of a scope.
+type tokenServer struct {
+	mu     sync.Mutex
+	issued []string
+	tokens map[string]string
+}
+
+func (s *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if r.URL.Path == "/service/token" {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "Harbor12345" || r.FormValue("service") != "harbor-registry" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		scope := r.FormValue("scope")
+		s.issued = append(s.issued, scope)
+		token := fmt.Sprintf("token-%d", len(s.issued))
+		s.tokens[scope] = token
+		// Older registries only return access_token
+		fmt.Fprintf(w, `{"access_token":%q,"expires_in":300}`, token)
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/api/") {
+		if username, password, _ := r.BasicAuth(); username != "admin" || password != "Harbor12345" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`[]`))
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.tokens[RegistryScope(r)] {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func (s *tokenServer) revoke() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens = map[string]string{}
+}
+
+func (s *tokenServer) issuedScopes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.issued...)
+}
+
+func TestTokenAuth(t *testing.T) {
+	s := &tokenServer{tokens: map[string]string{}}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	c, err := New(WithBaseURL(srv.URL), WithAuthenticator(NewTokenAuth(BasicAuth{Username: "admin", Password: "Harbor12345"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The registry API is served outside of the API root
+	get := func(path string) {
+		t.Helper()
+		req := c.NewRequest(gorequest.GET, "")
+		req.Url = srv.URL + path
+		if _, errs := c.Do(context.Background(), req, nil); len(errs) != 0 {
+			t.Fatalf("GET %s: %v", path, errs)
+		}
+	}
+	get("/v2/library/nginx/manifests/1.19")
+	get("/v2/library/nginx/manifests/1.20")
+	get("/v2/library/redis/manifests/6")
+	// Tokens are cached per scope
+	if got := s.issuedScopes(); len(got) != 2 || got[0] != "repository:library/nginx:pull" || got[1] != "repository:library/redis:pull" {
+		t.Fatalf("issued scopes %v", got)
+	}
+	// A rejected token is fetched again
+	s.revoke()
+	get("/v2/library/nginx/manifests/1.19")
+	if got := s.issuedScopes(); len(got) != 3 || got[2] != "repository:library/nginx:pull" {
+		t.Errorf("issued scopes after revocation %v", got)
+	}
+
+	// The Harbor API gets the credentials rather than a token
+	if _, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/projects"), nil); len(errs) != 0 {
+		t.Fatalf("GET /api/projects: %v", errs)
+	}
+	if got := s.issuedScopes(); len(got) != 3 {
+		t.Errorf("issued scopes after a request of the Harbor API %v", got)
+	}
+}
+
+func TestTokenAuthRejectedCredentials(t *testing.T) {
+	srv := httptest.NewServer(&tokenServer{tokens: map[string]string{}})
+	defer srv.Close()
+	c, err := New(WithBaseURL(srv.URL), WithAuthenticator(NewTokenAuth(BasicAuth{Username: "admin", Password: "wrong"})))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := c.NewRequest(gorequest.GET, "")
+	req.Url = srv.URL + "/v2/library/nginx/manifests/1.19"
+	if _, errs := c.Do(context.Background(), req, nil); len(errs) != 1 || !IsUnauthorized(errs[0]) {
+		t.Errorf("Do with rejected credentials: %v, want unauthorized", errs)
+	}
+}
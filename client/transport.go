@@ -0,0 +1,318 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// TransportConfig configures the HTTP transport of a Client.
+type TransportConfig struct {
+	// PEM bundle of the CAs to trust in addition to the system ones
+	CAFile string
+	// PEM encoded client certificate and key
+	CertFile string
+	KeyFile  string
+	// Name expected in the certificate of the server, the host of the base
+	// URL by default
+	ServerName string
+	// Skip the verification of the certificate of the server, for lab
+	// instances only
+	InsecureSkipVerify bool
+	// URL of the proxy. The HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment
+	// variables are used when empty.
+	Proxy string
+	// Comma separated hosts and domains reached without the proxy, NO_PROXY
+	// by default
+	NoProxy string
+	// Timeouts of the connection, the TLS handshake, and the wait for the
+	// response headers once the request is sent
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	// Timeout of a whole request, reading of the response body included
+	Timeout time.Duration
+}
+
+// transportOptions gathers the transport settings of the options, applied
+// once all of them are known.
+type transportOptions struct {
+	config       TransportConfig
+	tlsConfig    *tls.Config
+	roundTripper http.RoundTripper
+	// Wrappers of the final round tripper, from the innermost
+	hooks []func(http.RoundTripper) http.RoundTripper
+	// Whether an option changing the *http.Transport was given
+	changed bool
+}
+
+func (c *Client) transportOptions(change bool) *transportOptions {
+	if c.transport == nil {
+		c.transport = &transportOptions{}
+	}
+	c.transport.changed = c.transport.changed || change
+	return c.transport
+}
+
+// WithTransportConfig configures the HTTP transport of the client with
+// config, replacing the settings of the previous transport options.
+func WithTransportConfig(config TransportConfig) Option {
+	return func(c *Client) error {
+		c.transportOptions(true).config = config
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the client, completed by
+// the other TLS options.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(c *Client) error {
+		if config == nil {
+			return errors.New("harbor: nil TLS config")
+		}
+		c.transportOptions(true).tlsConfig = config.Clone()
+		return nil
+	}
+}
+
+// WithCAFile trusts the CAs of the PEM bundle at path, in addition to the
+// system ones.
+func WithCAFile(path string) Option {
+	return func(c *Client) error {
+		c.transportOptions(true).config.CAFile = path
+		return nil
+	}
+}
+
+// WithClientCert authenticates the client with the PEM encoded certificate
+// and key at certFile and keyFile.
+func WithClientCert(certFile, keyFile string) Option {
+	return func(c *Client) error {
+		t := c.transportOptions(true)
+		t.config.CertFile = certFile
+		t.config.KeyFile = keyFile
+		return nil
+	}
+}
+
+// WithServerName sets the name expected in the certificate of the server.
+func WithServerName(name string) Option {
+	return func(c *Client) error {
+		c.transportOptions(true).config.ServerName = name
+		return nil
+	}
+}
+
+// WithInsecureSkipVerify disables the verification of the certificate of
+// the server. It must only be used with lab instances.
+func WithInsecureSkipVerify(skip bool) Option {
+	return func(c *Client) error {
+		c.transportOptions(true).config.InsecureSkipVerify = skip
+		return nil
+	}
+}
+
+// WithProxy sends the requests through the proxy at proxyURL, except for
+// the hosts and domains of the comma separated noProxy list. NO_PROXY is
+// used when noProxy is empty.
+func WithProxy(proxyURL, noProxy string) Option {
+	return func(c *Client) error {
+		if _, err := url.Parse(proxyURL); err != nil {
+			return err
+		}
+		t := c.transportOptions(true)
+		t.config.Proxy = proxyURL
+		t.config.NoProxy = noProxy
+		return nil
+	}
+}
+
+// WithTimeouts sets the timeouts of the connection, the TLS handshake, the
+// wait for the response headers and the whole request. Zero values leave
+// the timeout unchanged.
+func WithTimeouts(dial, tlsHandshake, responseHeader, overall time.Duration) Option {
+	return func(c *Client) error {
+		t := c.transportOptions(dial != 0 || tlsHandshake != 0 || responseHeader != 0)
+		if dial != 0 {
+			t.config.DialTimeout = dial
+		}
+		if tlsHandshake != 0 {
+			t.config.TLSHandshakeTimeout = tlsHandshake
+		}
+		if responseHeader != 0 {
+			t.config.ResponseHeaderTimeout = responseHeader
+		}
+		if overall != 0 {
+			t.config.Timeout = overall
+		}
+		return nil
+	}
+}
+
+// WithTransport sends the requests through rt, e.g. to record or instrument
+// them. The TLS, proxy and timeout options require rt to be an
+// *http.Transport, which they configure on a copy.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) error {
+		if rt == nil {
+			return errors.New("harbor: nil transport")
+		}
+		c.transportOptions(false).roundTripper = rt
+		return nil
+	}
+}
+
+// WithTransportHook wraps the round tripper of the client, once the other
+// transport options are applied, e.g. to record the requests. Hooks given
+// later wrap the ones given earlier.
+func WithTransportHook(hook func(http.RoundTripper) http.RoundTripper) Option {
+	return func(c *Client) error {
+		if hook == nil {
+			return errors.New("harbor: nil transport hook")
+		}
+		t := c.transportOptions(false)
+		t.hooks = append(t.hooks, hook)
+		return nil
+	}
+}
+
+// buildTransport applies the transport options to the HTTP client of c. The
+// HTTP client is copied, so that one given by WithHTTPClient is left
+// untouched.
+func (c *Client) buildTransport() error {
+	opts := c.transport
+	if opts == nil {
+		return nil
+	}
+	rt := opts.roundTripper
+	if rt == nil {
+		rt = c.httpClient.Transport
+	}
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if opts.changed {
+		base, ok := rt.(*http.Transport)
+		if !ok {
+			return fmt.Errorf("harbor: TLS, proxy and timeout options require an *http.Transport, got %T", rt)
+		}
+		t := base.Clone()
+		if err := opts.apply(t); err != nil {
+			return err
+		}
+		rt = t
+	}
+	for _, hook := range opts.hooks {
+		rt = hook(rt)
+	}
+	hc := *c.httpClient
+	hc.Transport = rt
+	if opts.config.Timeout != 0 {
+		hc.Timeout = opts.config.Timeout
+	}
+	c.httpClient = &hc
+	return nil
+}
+
+func (opts *transportOptions) apply(t *http.Transport) error {
+	config := opts.config
+
+	tlsConfig := opts.tlsConfig
+	if tlsConfig == nil {
+		if t.TLSClientConfig != nil {
+			tlsConfig = t.TLSClientConfig.Clone()
+		} else {
+			tlsConfig = &tls.Config{}
+		}
+	}
+	if config.CAFile != "" {
+		pem, err := ioutil.ReadFile(config.CAFile)
+		if err != nil {
+			return err
+		}
+		pool := tlsConfig.RootCAs
+		if pool == nil {
+			if pool, err = x509.SystemCertPool(); err != nil {
+				pool = x509.NewCertPool()
+			}
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return errors.New("harbor: no certificate found in " + config.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if config.CertFile != "" || config.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(config.CertFile, config.KeyFile)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+	if config.ServerName != "" {
+		tlsConfig.ServerName = config.ServerName
+	}
+	if config.InsecureSkipVerify {
+		tlsConfig.InsecureSkipVerify = true
+	}
+	t.TLSClientConfig = tlsConfig
+
+	if config.Proxy != "" || config.NoProxy != "" {
+		proxy := httpproxy.FromEnvironment()
+		if config.Proxy != "" {
+			proxy.HTTPProxy = config.Proxy
+			proxy.HTTPSProxy = config.Proxy
+		}
+		if config.NoProxy != "" {
+			proxy.NoProxy = config.NoProxy
+		}
+		proxyFunc := proxy.ProxyFunc()
+		t.Proxy = func(req *http.Request) (*url.URL, error) {
+			return proxyFunc(req.URL)
+		}
+	}
+
+	if config.DialTimeout != 0 {
+		t.DialContext = (&net.Dialer{
+			Timeout:   config.DialTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext
+	}
+	if config.TLSHandshakeTimeout != 0 {
+		t.TLSHandshakeTimeout = config.TLSHandshakeTimeout
+	}
+	if config.ResponseHeaderTimeout != 0 {
+		t.ResponseHeaderTimeout = config.ResponseHeaderTimeout
+	}
+	return nil
+}
+
+// configTransport returns the transport settings of the transport section of
+// config, if any.
+func configTransport(config *viper.Viper) (TransportConfig, bool) {
+	if !config.IsSet("transport") {
+		return TransportConfig{}, false
+	}
+	return TransportConfig{
+		CAFile:                os.ExpandEnv(config.GetString("transport.tls.ca_file")),
+		CertFile:              os.ExpandEnv(config.GetString("transport.tls.cert_file")),
+		KeyFile:               os.ExpandEnv(config.GetString("transport.tls.key_file")),
+		ServerName:            config.GetString("transport.tls.server_name"),
+		InsecureSkipVerify:    config.GetBool("transport.tls.insecure_skip_verify"),
+		Proxy:                 config.GetString("transport.proxy.url"),
+		NoProxy:               config.GetString("transport.proxy.no_proxy"),
+		DialTimeout:           config.GetDuration("transport.timeout.dial"),
+		TLSHandshakeTimeout:   config.GetDuration("transport.timeout.tls_handshake"),
+		ResponseHeaderTimeout: config.GetDuration("transport.timeout.response_header"),
+		Timeout:               config.GetDuration("transport.timeout.overall"),
+	}, true
+}
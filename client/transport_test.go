@@ -0,0 +1,277 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parnurzeal/gorequest"
+	"github.com/spf13/viper"
+)
+
+// writePEM writes the certificate and the key of the test server srv, and
+// returns their paths.
+func writePEM(t *testing.T, srv *httptest.Server) (certFile, keyFile string) {
+	cert := srv.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := tempDir(t)
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	for path, block := range map[string]*pem.Block{
+		certFile: {Type: "CERTIFICATE", Bytes: cert.Certificate[0]},
+		keyFile:  {Type: "PRIVATE KEY", Bytes: key},
+	} {
+		if err := ioutil.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+// newTLSServer starts a TLS test server with config, which does not log
+// the handshakes the tests make fail.
+func newTLSServer(config *tls.Config) *httptest.Server {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = config
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.StartTLS()
+	return srv
+}
+
+func get(c *Client) []error {
+	_, errs := c.Do(context.Background(), c.NewRequest(gorequest.GET, "/systeminfo"), nil)
+	return errs
+}
+
+func TestTLSOptions(t *testing.T) {
+	srv := newTLSServer(nil)
+	defer srv.Close()
+	caFile, _ := writePEM(t, srv)
+
+	c, err := New(WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) == 0 {
+		t.Error("request to a server of an unknown CA succeeded")
+	}
+	// The certificate of the test server is issued for example.com
+	for name, opt := range map[string]Option{
+		"CA file":     WithCAFile(caFile),
+		"insecure":    WithInsecureSkipVerify(true),
+		"TLS config":  WithTLSConfig(&tls.Config{RootCAs: srv.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs}),
+		"config":      WithTransportConfig(TransportConfig{CAFile: caFile}),
+		"server name": WithTransportConfig(TransportConfig{CAFile: caFile, ServerName: "example.com"}),
+	} {
+		c, err := New(WithBaseURL(srv.URL), opt)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if errs := get(c); len(errs) != 0 {
+			t.Errorf("%s: %v", name, errs)
+		}
+	}
+
+	c, err = New(WithBaseURL(srv.URL), WithCAFile(caFile), WithServerName("harbor.example.org"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) == 0 || !strings.Contains(errs[0].Error(), "harbor.example.org") {
+		t.Errorf("request with a server name missing from the certificate: %v", errs)
+	}
+
+	if _, err := New(WithBaseURL(srv.URL), WithCAFile(filepath.Join(tempDir(t), "missing.pem"))); err == nil {
+		t.Error("New with a missing CA file succeeded")
+	}
+	notPEM := filepath.Join(tempDir(t), "ca.pem")
+	ioutil.WriteFile(notPEM, []byte("not a certificate"), 0600)
+	if _, err := New(WithBaseURL(srv.URL), WithCAFile(notPEM)); err == nil || !strings.Contains(err.Error(), "no certificate found") {
+		t.Errorf("New with an invalid CA file: %v", err)
+	}
+}
+
+func TestClientCert(t *testing.T) {
+	srv := newTLSServer(&tls.Config{ClientAuth: tls.RequireAnyClientCert})
+	defer srv.Close()
+	// The test server requires a client certificate, any one, e.g. its own
+	certFile, keyFile := writePEM(t, srv)
+
+	c, err := New(WithBaseURL(srv.URL), WithInsecureSkipVerify(true), WithClientCert(certFile, keyFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) != 0 {
+		t.Error(errs)
+	}
+	c, err = New(WithBaseURL(srv.URL), WithInsecureSkipVerify(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) == 0 {
+		t.Error("request without a client certificate succeeded")
+	}
+	if _, err := New(WithBaseURL(srv.URL), WithClientCert(certFile, "")); err == nil {
+		t.Error("New with a certificate without key succeeded")
+	}
+}
+
+func TestProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.Method+" "+r.URL.String())
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	c, err := New(WithBaseURL("http://harbor.example.com"), WithProxy(proxy.URL, "registry.example.com,.internal"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if want := []string{"GET http://harbor.example.com/api/systeminfo"}; !reflect.DeepEqual(proxied, want) {
+		t.Errorf("proxied %v, want %v", proxied, want)
+	}
+
+	proxyFunc := c.httpClient.Transport.(*http.Transport).Proxy
+	for rawurl, want := range map[string]string{
+		"https://harbor.example.com/api/projects": proxy.URL,
+		"https://registry.example.com/v2/":        "",
+		"https://harbor.internal/api/projects":    "",
+	} {
+		req, _ := http.NewRequest(http.MethodGet, rawurl, nil)
+		u, err := proxyFunc(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := urlString(u); got != want {
+			t.Errorf("proxy of %s = %q, want %q", rawurl, got, want)
+		}
+	}
+}
+
+func urlString(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+	return u.String()
+}
+
+func TestTimeouts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer srv.Close()
+
+	c, err := New(WithBaseURL(srv.URL), WithTimeouts(time.Second, 2*time.Second, 50*time.Millisecond, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	transport := c.httpClient.Transport.(*http.Transport)
+	if transport.TLSHandshakeTimeout != 2*time.Second || transport.ResponseHeaderTimeout != 50*time.Millisecond || c.httpClient.Timeout != 0 {
+		t.Errorf("timeouts %v %v %v", transport.TLSHandshakeTimeout, transport.ResponseHeaderTimeout, c.httpClient.Timeout)
+	}
+	if errs := get(c); len(errs) == 0 {
+		t.Error("request exceeding the response header timeout succeeded")
+	}
+
+	// The overall timeout alone does not need an *http.Transport
+	c, err = New(WithBaseURL(srv.URL), WithTransport(roundTripperFunc(http.DefaultTransport.RoundTrip)), WithTimeouts(0, 0, 0, 50*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.httpClient.Timeout != 50*time.Millisecond {
+		t.Errorf("overall timeout %v", c.httpClient.Timeout)
+	}
+	if errs := get(c); len(errs) == 0 {
+		t.Error("request exceeding the overall timeout succeeded")
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportHooks(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	var calls []string
+	hook := func(name string) Option {
+		return WithTransportHook(func(next http.RoundTripper) http.RoundTripper {
+			return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls = append(calls, name)
+				return next.RoundTrip(req)
+			})
+		})
+	}
+	hc := &http.Client{}
+	c, err := New(WithBaseURL(srv.URL), WithHTTPClient(hc), hook("inner"), hook("outer"), WithTimeouts(time.Second, 0, 0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := get(c); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if want := []string{"outer", "inner"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("hooks called in order %v, want %v", calls, want)
+	}
+	if hc.Transport != nil || c.httpClient == hc {
+		t.Error("the HTTP client given by WithHTTPClient was changed")
+	}
+}
+
+func TestTransportOptionErrors(t *testing.T) {
+	custom := roundTripperFunc(http.DefaultTransport.RoundTrip)
+	for name, opts := range map[string][]Option{
+		"TLS option on a custom transport": {WithTransport(custom), WithInsecureSkipVerify(true)},
+		"nil transport":                    {WithTransport(nil)},
+		"nil hook":                         {WithTransportHook(nil)},
+		"nil TLS config":                   {WithTLSConfig(nil)},
+		"invalid proxy":                    {WithProxy("http://proxy.example.com:port", "")},
+	} {
+		if _, err := New(append([]Option{WithBaseURL("https://harbor.example.com")}, opts...)...); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+}
+
+func TestConfigTransport(t *testing.T) {
+	config := viper.New()
+	if _, ok := configTransport(config); ok {
+		t.Error("transport settings found in an empty config")
+	}
+	defer os.Setenv("HARBOR_CERTS", os.Getenv("HARBOR_CERTS"))
+	os.Setenv("HARBOR_CERTS", "/etc/harbor")
+	config.Set("transport.tls.ca_file", "$HARBOR_CERTS/ca.pem")
+	config.Set("transport.tls.insecure_skip_verify", true)
+	config.Set("transport.proxy.url", "http://proxy.example.com:3128")
+	config.Set("transport.timeout.dial", "5s")
+	config.Set("transport.timeout.overall", "1m")
+	got, ok := configTransport(config)
+	want := TransportConfig{
+		CAFile:             "/etc/harbor/ca.pem",
+		InsecureSkipVerify: true,
+		Proxy:              "http://proxy.example.com:3128",
+		DialTimeout:        5 * time.Second,
+		Timeout:            time.Minute,
+	}
+	if !ok || got != want {
+		t.Errorf("configTransport = %+v, %v, want %+v", got, ok, want)
+	}
+}
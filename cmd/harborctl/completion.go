@@ -0,0 +1,169 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const bashCompletion = `# bash completion of harborctl, load it with
+#   source <(harborctl completion bash)
+_harborctl() {
+	local IFS=$'\n'
+	COMPREPLY=($(harborctl __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _harborctl harborctl
+`
+
+const zshCompletion = `#compdef harborctl
+# zsh completion of harborctl, load it with
+#   source <(harborctl completion zsh)
+_harborctl() {
+	local -a candidates
+	candidates=("${(@f)$(harborctl __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -- "${candidates[@]}"
+}
+compdef _harborctl harborctl
+`
+
+func completionCommand() *command {
+	return &command{
+		name:    "completion",
+		args:    "bash|zsh",
+		summary: "Print the shell completion script",
+		flags: noFlags(exactArgs(1, func(a *app, args []string) error {
+			var script string
+			switch args[0] {
+			case "bash":
+				script = bashCompletion
+			case "zsh":
+				script = zshCompletion
+			default:
+				return usagef("unknown shell %q, expected bash or zsh", args[0])
+			}
+			_, err := fmt.Fprint(a.stdout, script)
+			return err
+		})),
+	}
+}
+
+// complete prints the candidates completing the last of words, the command
+// line being typed.
+func (a *app) complete(tree *command, words []string) error {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, words := words[len(words)-1], words[:len(words)-1]
+
+	// The flags are declared on a throwaway app, so that the values parsed
+	// so far are kept. The flags of the command may shadow global ones.
+	global := flag.NewFlagSet("harborctl", flag.ContinueOnError)
+	(&app{}).globalFlags(global)
+	flags := []*flag.FlagSet{global}
+	cmd, path, previous := tree, []string{}, ""
+	for _, w := range words {
+		if takesValue(flags, previous) {
+			previous = ""
+			continue
+		}
+		previous = w
+		if strings.HasPrefix(w, "-") {
+			continue
+		}
+		if sub := cmd.lookup(w); sub != nil {
+			cmd, path = sub, append(path, sub.name)
+			if sub.flags != nil {
+				leaf := flag.NewFlagSet(sub.name, flag.ContinueOnError)
+				sub.flags(leaf)
+				flags = append([]*flag.FlagSet{leaf}, flags...)
+			}
+		}
+	}
+
+	var (
+		candidates []string
+		at         = strings.Join(path, " ")
+	)
+	switch name := flagName(previous); {
+	case takesValue(flags, previous) && (name == "o" || name == "output"):
+		candidates = []string{"table", "json", "yaml"}
+	case takesValue(flags, previous) && name == "format":
+		candidates = strings.Split(formatNames(), ", ")
+	case takesValue(flags, previous) && name == "profile":
+		candidates = a.profileNames()
+	case takesValue(flags, previous):
+		// Let the shell complete the value, e.g. a file name
+	case strings.HasPrefix(current, "-"):
+		seen := map[string]bool{}
+		for _, fs := range flags {
+			fs.VisitAll(func(f *flag.Flag) {
+				if seen[f.Name] {
+					return
+				}
+				seen[f.Name] = true
+				if len(f.Name) == 1 {
+					candidates = append(candidates, "-"+f.Name)
+				} else {
+					candidates = append(candidates, "--"+f.Name)
+				}
+			})
+		}
+		sort.Strings(candidates)
+	case at == "profile use" || at == "profile set" || at == "profile delete":
+		candidates = a.profileNames()
+	case at == "completion":
+		candidates = []string{"bash", "zsh"}
+	default:
+		for _, sub := range cmd.sub {
+			candidates = append(candidates, sub.name)
+		}
+	}
+	for _, c := range candidates {
+		if strings.HasPrefix(c, current) {
+			fmt.Fprintln(a.stdout, c)
+		}
+	}
+	return nil
+}
+
+// flagName returns the name of the flag of the word w, if any.
+func flagName(w string) string {
+	if !strings.HasPrefix(w, "-") || strings.Contains(w, "=") {
+		return ""
+	}
+	return strings.TrimLeft(w, "-")
+}
+
+// takesValue tells whether the word w is a flag of one of flags expecting
+// its value as the next word.
+func takesValue(flags []*flag.FlagSet, w string) bool {
+	name := flagName(w)
+	if name == "" {
+		return false
+	}
+	for _, fs := range flags {
+		f := fs.Lookup(name)
+		if f == nil {
+			continue
+		}
+		b, ok := f.Value.(interface{ IsBoolFlag() bool })
+		return !ok || !b.IsBoolFlag()
+	}
+	return false
+}
+
+// profileNames returns the names of the profiles, quietly none when the
+// profiles file cannot be read.
+func (a *app) profileNames() []string {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestComplete(t *testing.T) {
+	config := filepath.Join(tempDir(t), "config.yaml")
+	if err := ioutil.WriteFile(config, []byte("profiles:\n  lab:\n    url: https://harbor.lab.example.com\n  prod:\n    url: https://harbor.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		words []string
+		want  []string
+	}{
+		{nil, []string{"projects", "members", "metadata", "repositories", "tags", "users", "statistics", "scan", "profile", "completion"}},
+		{[]string{"pro"}, []string{"projects", "profile"}},
+		{[]string{"projects", ""}, []string{"list", "get", "create", "delete"}},
+		{[]string{"-o", "json", "projects", "c"}, []string{"create"}},
+		{[]string{"projects", "create", "--p"}, []string{"--password", "--profile", "--public"}},
+		{[]string{"-o", ""}, []string{"table", "json", "yaml"}},
+		{[]string{"users", "list", "--output", "y"}, []string{"yaml"}},
+		{[]string{"scan", "report", "-format", ""}, []string{"sarif", "cyclonedx", "csv", "junit"}},
+		{[]string{"-profile", ""}, []string{"lab", "prod"}},
+		{[]string{"profile", "use", "p"}, []string{"prod"}},
+		{[]string{"completion", ""}, []string{"bash", "zsh"}},
+		// The value of a flag is left to the shell
+		{[]string{"scan", "gate", "-policy", ""}, nil},
+		// A boolean flag takes no value
+		{[]string{"projects", "create", "--public", "x"}, nil},
+	} {
+		code, stdout, stderr := harborctl(append([]string{"-config", config, "__complete"}, test.words...)...)
+		var got []string
+		if stdout != "" {
+			got = strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+		}
+		if code != exitOK || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("__complete %q = %d, %q, %q, want %q", test.words, code, got, stderr, test.want)
+		}
+	}
+}
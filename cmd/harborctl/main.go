@@ -0,0 +1,372 @@
+// Command harborctl manages the projects, members, metadata, repositories,
+// tags, users and image scans of Harbor instances from the command line.
+//
+//	harborctl [global flags] <command> <subcommand> [flags] [args]
+//
+// The Harbor instance is taken from the current profile, see harborctl
+// profile, and can be overridden by the global flags or the HARBOR_URL,
+// HARBOR_USERNAME and HARBOR_PASSWORD environment variables.
+//
+// The exit code tells how a command failed:
+//
+//	0  success
+//	1  error
+//	2  invalid usage
+//	3  not found
+//	4  unauthorized or forbidden
+//	5  conflict
+//	6  bad request
+//	7  server error
+//	8  scan gate failed
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+)
+
+// Exit codes of harborctl
+const (
+	exitOK = iota
+	exitError
+	exitUsage
+	exitNotFound
+	exitUnauthorized
+	exitConflict
+	exitBadRequest
+	exitServerError
+	exitGateFailed
+)
+
+// usageError is an invalid invocation of a command.
+type usageError struct {
+	msg string
+	// Whether the help of the command is printed along
+	help bool
+}
+
+func (e usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errGateFailed is returned when an image does not pass a gate policy.
+var errGateFailed = errors.New("the image does not pass the gate policy")
+
+// exitCode maps err to the exit code of harborctl.
+func exitCode(err error) int {
+	var (
+		usage usageError
+		resp  *client2.ErrorResponse
+	)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errGateFailed):
+		return exitGateFailed
+	case client2.IsNotFound(err):
+		return exitNotFound
+	case client2.IsUnauthorized(err), client2.IsForbidden(err):
+		return exitUnauthorized
+	case client2.IsConflict(err):
+		return exitConflict
+	case client2.IsBadRequest(err):
+		return exitBadRequest
+	case errors.As(err, &resp) && resp.StatusCode >= http.StatusInternalServerError:
+		return exitServerError
+	}
+	return exitError
+}
+
+// check returns the first of errs, as returned by the service methods.
+func check(errs []error) error {
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// runFunc runs a command with its positional arguments, once its flags are
+// parsed.
+type runFunc func(a *app, args []string) error
+
+// command is a node of the command tree: either a group of subcommands, or
+// a command run with flags and arguments.
+type command struct {
+	name    string
+	args    string
+	summary string
+	sub     []*command
+	// flags declares the flags of the command on fs and returns the function
+	// running it.
+	flags func(fs *flag.FlagSet) runFunc
+}
+
+func (c *command) lookup(name string) *command {
+	for _, sub := range c.sub {
+		if sub.name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// noFlags declares no flag and runs run.
+func noFlags(run runFunc) func(fs *flag.FlagSet) runFunc {
+	return func(fs *flag.FlagSet) runFunc {
+		return run
+	}
+}
+
+// exactArgs wraps run to require n positional arguments.
+func exactArgs(n int, run runFunc) runFunc {
+	return func(a *app, args []string) error {
+		if len(args) != n {
+			return usageError{msg: fmt.Sprintf("wrong number of arguments, expected %d, got %d", n, len(args)), help: true}
+		}
+		return run(a, args)
+	}
+}
+
+// root returns the command tree of harborctl.
+func root() *command {
+	return &command{
+		name: "harborctl",
+		sub: []*command{
+			projectsCommand(),
+			membersCommand(),
+			metadataCommand(),
+			repositoriesCommand(),
+			tagsCommand(),
+			usersCommand(),
+			statisticsCommand(),
+			scanCommand(),
+			profileCommand(),
+			completionCommand(),
+		},
+	}
+}
+
+// app holds the global options of an invocation.
+type app struct {
+	ctx    context.Context
+	stdout io.Writer
+	stderr io.Writer
+
+	configPath string
+	profile    string
+	url        string
+	username   string
+	password   string
+	apiVersion string
+	insecure   bool
+	output     string
+
+	client *client2.Client
+}
+
+// globalFlags declares the flags accepted before the command.
+func (a *app) globalFlags(fs *flag.FlagSet) {
+	fs.StringVar(&a.configPath, "config", os.Getenv("HARBORCTL_CONFIG"), "path of the profiles file")
+	fs.StringVar(&a.profile, "profile", os.Getenv("HARBORCTL_PROFILE"), "profile to use instead of the current one")
+	fs.StringVar(&a.url, "url", os.Getenv("HARBOR_URL"), "URL of Harbor, overriding the profile")
+	fs.StringVar(&a.username, "username", os.Getenv("HARBOR_USERNAME"), "username, overriding the profile")
+	fs.StringVar(&a.password, "password", os.Getenv("HARBOR_PASSWORD"), "password, overriding the profile")
+	fs.StringVar(&a.apiVersion, "api-version", "", "API version of Harbor: v1, v2.0 or auto (the default)")
+	fs.BoolVar(&a.insecure, "insecure", false, "skip the verification of the certificate of Harbor")
+	a.outputFlag(fs)
+}
+
+// outputFlag declares the output flag, accepted by every command.
+func (a *app) outputFlag(fs *flag.FlagSet) {
+	if fs.Lookup("o") != nil {
+		return
+	}
+	fs.StringVar(&a.output, "o", a.output, "output format: table, json or yaml")
+	fs.StringVar(&a.output, "output", a.output, "output format: table, json or yaml")
+}
+
+// harbor returns the client of the selected Harbor instance.
+func (a *app) harbor() (*client2.Client, error) {
+	if a.client != nil {
+		return a.client, nil
+	}
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return nil, err
+	}
+	p, err := a.resolveProfile(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if a.client, err = p.client(); err != nil {
+		return nil, err
+	}
+	return a.client, nil
+}
+
+func main() {
+	ctx, stop := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	go func() {
+		<-signals
+		stop()
+	}()
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+// run runs the command line args and returns the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	a := &app{ctx: ctx, stdout: stdout, stderr: stderr, output: "table"}
+	err := a.run(args)
+	if a.client != nil {
+		a.client.Close()
+	}
+	code := exitCode(err)
+	if code != exitOK {
+		fmt.Fprintln(stderr, "harborctl:", err)
+	}
+	return code
+}
+
+func (a *app) run(args []string) error {
+	tree := root()
+	fs := flag.NewFlagSet("harborctl", flag.ContinueOnError)
+	fs.SetOutput(a.stderr)
+	a.globalFlags(fs)
+	fs.Usage = func() {
+		a.usage(tree, []string{"harborctl"}, fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		return flagError(err)
+	}
+	args = fs.Args()
+	if len(args) != 0 && args[0] == "__complete" {
+		return a.complete(tree, args[1:])
+	}
+
+	cmd, path := tree, []string{"harborctl"}
+	for len(cmd.sub) != 0 {
+		if len(args) == 0 {
+			a.usage(cmd, path, nil)
+			return usagef("missing command")
+		}
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			a.usage(cmd, path, nil)
+			return nil
+		}
+		sub := cmd.lookup(args[0])
+		if sub == nil {
+			a.usage(cmd, path, nil)
+			return usagef("unknown command %q", strings.Join(append(path[1:], args[0]), " "))
+		}
+		cmd, path, args = sub, append(path, sub.name), args[1:]
+	}
+
+	leaf := flag.NewFlagSet(strings.Join(path, " "), flag.ContinueOnError)
+	leaf.SetOutput(a.stderr)
+	run := cmd.flags(leaf)
+	a.outputFlag(leaf)
+	leaf.Usage = func() {
+		a.usage(cmd, path, leaf)
+	}
+	positional, err := parseFlags(leaf, args)
+	if err != nil {
+		return flagError(err)
+	}
+	switch a.output {
+	case "table", "json", "yaml":
+	default:
+		return usagef("unknown output format %q", a.output)
+	}
+	err = run(a, positional)
+	var usage usageError
+	if errors.As(err, &usage) && usage.help {
+		a.usage(cmd, path, leaf)
+	}
+	return err
+}
+
+// flagError turns an error of the flag package into a usage error.
+func flagError(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return usageError{msg: err.Error()}
+}
+
+// parseFlags parses the flags of fs found anywhere in args, until "--", and
+// returns the positional arguments.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// usage prints the help of cmd, reached by path.
+func (a *app) usage(cmd *command, path []string, fs *flag.FlagSet) {
+	w := a.stderr
+	name := strings.Join(path, " ")
+	if len(cmd.sub) != 0 {
+		if cmd.summary != "" {
+			fmt.Fprintf(w, "%s\n\n", cmd.summary)
+		}
+		fmt.Fprintf(w, "Usage:\n  %s <command> [flags] [args]\n\nCommands:\n", name)
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		for _, sub := range cmd.sub {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.name, sub.summary)
+		}
+		tw.Flush()
+	} else {
+		fmt.Fprintf(w, "%s\n\nUsage:\n  %s\n", cmd.summary, strings.TrimSpace(name+" [flags] "+cmd.args))
+	}
+	if fs != nil {
+		fmt.Fprintln(w, "\nFlags:")
+		fs.PrintDefaults()
+	}
+	if len(path) == 1 {
+		fmt.Fprintln(w, "\nRun harborctl -h for the global flags, harborctl <command> help for the subcommands.")
+	}
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// tempDir returns a directory removed at the end of the test.
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "harborctl")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestMain(m *testing.M) {
+	// The environment of the developer must not select a Harbor instance
+	for _, key := range []string{"HARBORCTL_CONFIG", "HARBORCTL_PROFILE", "HARBOR_URL", "HARBOR_USERNAME", "HARBOR_PASSWORD"} {
+		os.Unsetenv(key)
+	}
+	os.Exit(m.Run())
+}
+
+// harborctl runs the command line args and returns its exit code and
+// outputs.
+func harborctl(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// against returns the global flags selecting the Harbor instance srv as
+// its administrator, with an empty profiles file.
+func against(t *testing.T, srv *harbortest.Server) func(args ...string) (int, string, string) {
+	config := filepath.Join(tempDir(t), "config.yaml")
+	return func(args ...string) (int, string, string) {
+		global := []string{"-config", config, "-url", srv.URL, "-username", harbortest.AdminUsername, "-password", harbortest.AdminPassword, "-api-version", "v1"}
+		return harborctl(append(global, args...)...)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	cmd := against(t, srv)
+
+	code, stdout, stderr := cmd("projects", "create", "team", "--metadata", "auto_scan=true")
+	if code != exitOK || !strings.HasPrefix(stdout, "project team created") {
+		t.Fatalf("projects create = %d, %q, %q", code, stdout, stderr)
+	}
+	if code, _, stderr := cmd("projects", "create", "team"); code != exitConflict || !strings.HasPrefix(stderr, "harborctl: ") {
+		t.Errorf("projects create of an existing project = %d, %q", code, stderr)
+	}
+
+	code, stdout, stderr = cmd("projects", "get", "team", "-o", "json")
+	var p struct {
+		ProjectID int64             `json:"project_id"`
+		Name      string            `json:"name"`
+		Metadata  map[string]string `json:"metadata"`
+	}
+	if code != exitOK {
+		t.Fatalf("projects get = %d, %q", code, stderr)
+	}
+	if err := json.Unmarshal([]byte(stdout), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "team" || p.Metadata["auto_scan"] != "true" || p.Metadata["public"] != "false" {
+		t.Errorf("projects get = %+v", p)
+	}
+	if code, stdout, _ := cmd("projects", "get", fmt.Sprint(p.ProjectID), "-o", "yaml"); code != exitOK || !strings.Contains(stdout, "name: team\n") {
+		t.Errorf("projects get by ID = %d, %q", code, stdout)
+	}
+
+	code, stdout, _ = cmd("projects", "list")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != exitOK || len(lines) != 3 || !strings.HasPrefix(lines[0], "ID ") || !strings.Contains(lines[1]+lines[2], " team ") {
+		t.Errorf("projects list = %d, %q", code, stdout)
+	}
+	if code, stdout, _ := cmd("projects", "list", "--public", "-o", "json"); code != exitOK || strings.Contains(stdout, `"team"`) || !strings.Contains(stdout, `"library"`) {
+		t.Errorf("projects list --public = %d, %q", code, stdout)
+	}
+
+	if code, stdout, _ := cmd("projects", "delete", "team"); code != exitOK || stdout != "project team deleted\n" {
+		t.Errorf("projects delete = %d, %q", code, stdout)
+	}
+	if code, _, stderr := cmd("projects", "get", "team"); code != exitNotFound {
+		t.Errorf("projects get of a deleted project = %d, %q", code, stderr)
+	}
+	if code, _, _ := cmd("projects", "create", "x", "--metadata", "nokey"); code != exitUsage {
+		t.Errorf("projects create with an invalid metadata = %d", code)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("team", false)
+	alice := srv.AddUser("alice", "Alice12345", false)
+	cmd := against(t, srv)
+
+	if code, stdout, stderr := cmd("members", "add", "team", "alice", "developer"); code != exitOK || stdout != "alice added to project team as developer\n" {
+		t.Fatalf("members add = %d, %q, %q", code, stdout, stderr)
+	}
+	if code, stdout, _ := cmd("members", "update", "team", "alice", "maintainer"); code != exitOK || stdout != "alice is now maintainer of project team\n" {
+		t.Errorf("members update = %d, %q", code, stdout)
+	}
+	code, stdout, _ := cmd("members", "list", "team")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if want := []string{fmt.Sprint(alice.UserID), "alice", "maintainer"}; code != exitOK || !reflect.DeepEqual(strings.Fields(lines[len(lines)-1]), want) {
+		t.Errorf("members list = %d, %q", code, stdout)
+	}
+	if code, _, _ := cmd("members", "add", "team", "alice", "owner"); code != exitUsage {
+		t.Errorf("members add with an unknown role = %d", code)
+	}
+	if code, stdout, _ := cmd("members", "remove", "team", "alice"); code != exitOK || stdout != "alice removed from project team\n" {
+		t.Errorf("members remove = %d, %q", code, stdout)
+	}
+	if code, _, stderr := cmd("members", "remove", "team", "alice"); code != exitNotFound || !strings.Contains(stderr, "member alice of project team") {
+		t.Errorf("members remove of a removed member = %d, %q", code, stderr)
+	}
+}
+
+// Harbor 2.x addresses the members by their own ID.
+func TestMembersV2(t *testing.T) {
+	f, _ := fakeharbor.Start(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/projects":                 {Body: `[{"project_id":2,"name":"team"}]`},
+		"GET /api/v2.0/projects/2/members":       {Body: `[{"id":21,"project_id":2,"entity_name":"alice","role_id":2,"entity_id":3,"entity_type":"u"}]`},
+		"PUT /api/v2.0/projects/2/members/21":    {},
+		"DELETE /api/v2.0/projects/2/members/21": {},
+	})
+	cmd := func(args ...string) (int, string, string) {
+		global := []string{"-config", filepath.Join(tempDir(t), "config.yaml"), "-url", f.URL, "-username", "admin", "-password", "Harbor12345", "-api-version", "v2.0"}
+		return harborctl(append(global, args...)...)
+	}
+	code, stdout, stderr := cmd("members", "list", "team")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if want := []string{"21", "alice", "developer"}; code != exitOK || !reflect.DeepEqual(strings.Fields(lines[len(lines)-1]), want) {
+		t.Errorf("members list = %d, %q, %q", code, stdout, stderr)
+	}
+	if code, _, stderr := cmd("members", "update", "team", "alice", "maintainer"); code != exitOK {
+		t.Errorf("members update = %d, %q", code, stderr)
+	}
+	if r, _ := f.Request("PUT /api/v2.0/projects/2/members/21"); r.Body != `{"role_id":4}` {
+		t.Errorf("members update sent %s", r.Body)
+	}
+	if code, _, stderr := cmd("members", "remove", "team", "alice"); code != exitOK {
+		t.Errorf("members remove = %d, %q", code, stderr)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("team", false)
+	cmd := against(t, srv)
+
+	if code, stdout, stderr := cmd("metadata", "set", "team", "auto_scan=true", "severity=high"); code != exitOK || stdout != "metadata of project team set: auto_scan=true,severity=high\n" {
+		t.Fatalf("metadata set = %d, %q, %q", code, stdout, stderr)
+	}
+	if code, _, _ := cmd("metadata", "delete", "team", "severity"); code != exitOK {
+		t.Errorf("metadata delete = %d", code)
+	}
+	code, stdout, _ := cmd("metadata", "list", "team", "-o", "json")
+	var metadata map[string]string
+	if code != exitOK {
+		t.Fatalf("metadata list = %d", code)
+	}
+	if err := json.Unmarshal([]byte(stdout), &metadata); err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"public": "false", "auto_scan": "true"}; !reflect.DeepEqual(metadata, want) {
+		t.Errorf("metadata list = %v, want %v", metadata, want)
+	}
+	for _, args := range [][]string{
+		{"metadata", "set", "team"},
+		{"metadata", "set", "team", "=true"},
+	} {
+		if code, _, _ := cmd(args...); code != exitUsage {
+			t.Errorf("%v = %d, want %d", args, code, exitUsage)
+		}
+	}
+}
+
+func TestUsers(t *testing.T) {
+	srv := harbortest.Start(t)
+	alice := srv.AddUser("alice", "Alice12345", false)
+	srv.AddProject("library", true)
+	cmd := against(t, srv)
+
+	if code, stdout, _ := cmd("users", "current", "-o", "json"); code != exitOK || !strings.Contains(stdout, `"username": "admin"`) {
+		t.Errorf("users current = %d, %q", code, stdout)
+	}
+	code, stdout, _ := cmd("users", "list")
+	if code != exitOK || !strings.Contains(stdout, " admin ") || !strings.Contains(stdout, " alice ") {
+		t.Errorf("users list = %d, %q", code, stdout)
+	}
+	id := fmt.Sprint(alice.UserID)
+	if code, stdout, _ := cmd("users", "get", id); code != exitOK || !strings.HasPrefix(strings.Join(strings.Fields(strings.Split(stdout, "\n")[1]), " "), id+" alice ") {
+		t.Errorf("users get = %d, %q", code, stdout)
+	}
+	if code, _, _ := cmd("users", "get", "alice"); code != exitUsage {
+		t.Errorf("users get by name = %d", code)
+	}
+	if code, stdout, _ := cmd("users", "delete", id); code != exitOK || stdout != "user "+id+" deleted\n" {
+		t.Errorf("users delete = %d, %q", code, stdout)
+	}
+	if code, _, _ := cmd("users", "get", id); code != exitNotFound {
+		t.Errorf("users get of a deleted user = %d", code)
+	}
+
+	code, stdout, _ = cmd("statistics")
+	if code != exitOK || !strings.Contains(stdout, "public ") || !strings.Contains(stdout, "total ") {
+		t.Errorf("statistics = %d, %q", code, stdout)
+	}
+}
+
+func TestUnauthorized(t *testing.T) {
+	srv := harbortest.Start(t)
+	if code, _, _ := harborctl("-config", filepath.Join(tempDir(t), "config.yaml"), "-url", srv.URL, "-username", "admin", "-password", "wrong", "-api-version", "v1", "users", "current"); code != exitUnauthorized {
+		t.Errorf("users current with a wrong password = %d, want %d", code, exitUnauthorized)
+	}
+}
+
+func TestRepositoriesAndTags(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	for _, tag := range []string{"1.18", "1.19"} {
+		if _, err := srv.PushImage("library/nginx", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := against(t, srv)
+
+	if code, stdout, _ := cmd("repositories", "list", "--project", "library"); code != exitOK || !strings.Contains(stdout, "library/nginx ") {
+		t.Errorf("repositories list = %d, %q", code, stdout)
+	}
+	code, stdout, _ := cmd("tags", "list", "library/nginx")
+	if lines := strings.Split(strings.TrimSpace(stdout), "\n"); code != exitOK || len(lines) != 3 || !strings.HasPrefix(lines[0], "TAG ") {
+		t.Errorf("tags list = %d, %q", code, stdout)
+	}
+	if code, stdout, _ := cmd("tags", "delete", "library/nginx", "1.18"); code != exitOK || stdout != "tag library/nginx:1.18 deleted\n" {
+		t.Errorf("tags delete = %d, %q", code, stdout)
+	}
+	if code, _, _ := cmd("tags", "get", "library/nginx", "1.18"); code != exitNotFound {
+		t.Errorf("tags get of a deleted tag = %d", code)
+	}
+	if code, _, _ := cmd("tags", "list", "nginx"); code != exitUsage {
+		t.Errorf("tags list of a repository without project = %d", code)
+	}
+	if code, stdout, _ := cmd("repositories", "delete", "library/nginx"); code != exitOK || stdout != "repository library/nginx deleted\n" {
+		t.Errorf("repositories delete = %d, %q", code, stdout)
+	}
+}
+
+func TestScan(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	vulns := []repositories.VulnerabilityItem{
+		{ID: "CVE-2020-0001", Severity: repositories.SeverityCritical, Pkg: "openssl", Version: "1.1.1", Fixed: "1.1.1g"},
+		{ID: "CVE-2020-0002", Severity: repositories.SeverityLow, Pkg: "zlib", Version: "1.2.11"},
+	}
+	if err := srv.SetVulnerabilities("library/nginx", "1.19", vulns); err != nil {
+		t.Fatal(err)
+	}
+	cmd := against(t, srv)
+
+	if code, stdout, stderr := cmd("scan", "start", "library/nginx", "1.19"); code != exitOK || stdout != "scan of library/nginx:1.19 started\n" {
+		t.Fatalf("scan start = %d, %q, %q", code, stdout, stderr)
+	}
+	// The fake server runs the scan as the tag is read
+	for _, want := range []string{"Running", "Critical"} {
+		code, stdout, _ := cmd("tags", "get", "library/nginx", "1.19")
+		if code != exitOK || !strings.Contains(stdout, " "+want) {
+			t.Fatalf("tags get = %d, %q, want scan %s", code, stdout, want)
+		}
+	}
+
+	code, stdout, _ := cmd("scan", "report", "library/nginx", "1.19", "-o", "json")
+	var items []repositories.VulnerabilityItem
+	if code != exitOK {
+		t.Fatalf("scan report = %d", code)
+	}
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(items, vulns) {
+		t.Errorf("scan report = %+v, want %+v", items, vulns)
+	}
+	if code, stdout, _ := cmd("scan", "report", "library/nginx", "1.19", "--format", "csv"); code != exitOK || !strings.Contains(stdout, "CVE-2020-0001") {
+		t.Errorf("scan report --format csv = %d, %q", code, stdout)
+	}
+	if code, _, _ := cmd("scan", "report", "library/nginx", "1.19", "--format", "pdf"); code != exitUsage {
+		t.Errorf("scan report --format pdf = %d", code)
+	}
+
+	dir := tempDir(t)
+	strict := filepath.Join(dir, "strict.yaml")
+	if err := ioutil.WriteFile(strict, []byte("max_counts:\n  critical: 0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, stderr := cmd("scan", "gate", "library/nginx", "1.19", "--policy", strict)
+	if code != exitGateFailed || !strings.HasPrefix(stdout, "FAIL: 1 offending") || !strings.Contains(stdout, "CVE-2020-0001 openssl@1.1.1 (fixed in 1.1.1g)") {
+		t.Errorf("scan gate = %d, %q", code, stdout)
+	}
+	if !strings.Contains(stderr, "library/nginx:1.19: the image does not pass the gate policy") {
+		t.Errorf("scan gate error = %q", stderr)
+	}
+	lenient := filepath.Join(dir, "lenient.yaml")
+	if err := ioutil.WriteFile(lenient, []byte("max_counts:\n  critical: 0\nallowlist:\n- id: CVE-2020-0001\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, stdout, _ = cmd("scan", "gate", "library/nginx", "1.19", "--policy", lenient, "-o", "json")
+	var v struct {
+		Pass bool `json:"pass"`
+	}
+	if code != exitOK {
+		t.Fatalf("scan gate = %d, %q", code, stdout)
+	}
+	if err := json.Unmarshal([]byte(stdout), &v); err != nil || !v.Pass {
+		t.Errorf("scan gate = %q, %v", stdout, err)
+	}
+	if code, _, _ := cmd("scan", "gate", "library/nginx", "1.19"); code != exitUsage {
+		t.Errorf("scan gate without policy = %d", code)
+	}
+}
+
+func TestUsage(t *testing.T) {
+	for _, test := range []struct {
+		args   []string
+		code   int
+		stderr string
+	}{
+		{nil, exitUsage, "harborctl: missing command"},
+		{[]string{"help"}, exitOK, "Commands:"},
+		{[]string{"projects", "help"}, exitOK, "Manage the projects"},
+		{[]string{"projets"}, exitUsage, `harborctl: unknown command "projets"`},
+		{[]string{"projects", "lst"}, exitUsage, `harborctl: unknown command "projects lst"`},
+		{[]string{"projects", "get"}, exitUsage, "wrong number of arguments, expected 1, got 0"},
+		{[]string{"projects", "get", "-h"}, exitOK, "Show a project, by name or ID"},
+		{[]string{"-nope"}, exitUsage, "flag provided but not defined: -nope"},
+		{[]string{"-o", "xml", "users", "current"}, exitUsage, `unknown output format "xml"`},
+		{[]string{"completion", "fish"}, exitUsage, `unknown shell "fish"`},
+	} {
+		code, _, stderr := harborctl(test.args...)
+		if code != test.code || !strings.Contains(stderr, test.stderr) {
+			t.Errorf("%v = %d, %q, want %d, %q", test.args, code, stderr, test.code, test.stderr)
+		}
+	}
+	// The help of a command called wrongly lists its flags
+	if _, _, stderr := harborctl("projects", "create"); !strings.Contains(stderr, "Flags:\n") || !strings.Contains(stderr, "-public") {
+		t.Errorf("help of projects create = %q", stderr)
+	}
+	if code, stdout, _ := harborctl("completion", "bash"); code != exitOK || stdout != bashCompletion {
+		t.Errorf("completion bash = %d, %q", code, stdout)
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	for _, test := range []struct {
+		args       []string
+		public     bool
+		positional []string
+	}{
+		{[]string{"team"}, false, []string{"team"}},
+		{[]string{"team", "--public"}, true, []string{"team"}},
+		{[]string{"-public", "a", "b"}, true, []string{"a", "b"}},
+		{[]string{"a", "--", "-public"}, false, []string{"a", "-public"}},
+		{nil, false, nil},
+	} {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		public := fs.Bool("public", false, "")
+		positional, err := parseFlags(fs, test.args)
+		if err != nil || *public != test.public || !reflect.DeepEqual(positional, test.positional) {
+			t.Errorf("parseFlags(%q) = %v, %q, %v", test.args, *public, positional, err)
+		}
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	response := func(status int) error {
+		return &client2.ErrorResponse{StatusCode: status}
+	}
+	for _, test := range []struct {
+		err  error
+		code int
+	}{
+		{nil, exitOK},
+		{flag.ErrHelp, exitOK},
+		{usagef("bad"), exitUsage},
+		{fmt.Errorf("nginx: %w", errGateFailed), exitGateFailed},
+		{fmt.Errorf("project x: %w", client2.ErrNotFound), exitNotFound},
+		{response(401), exitUnauthorized},
+		{response(403), exitUnauthorized},
+		{response(404), exitNotFound},
+		{response(409), exitConflict},
+		{response(400), exitBadRequest},
+		{response(503), exitServerError},
+		{errors.New("boom"), exitError},
+	} {
+		if code := exitCode(test.err); code != test.code {
+			t.Errorf("exitCode(%v) = %d, want %d", test.err, code, test.code)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestPrint(t *testing.T) {
+	v := []struct {
+		Name  string `json:"name"`
+		Count int    `json:"count,omitempty"`
+	}{{"nginx", 3}, {"alpine", 0}}
+	header := []string{"NAME", "COUNT"}
+	rows := [][]string{{"nginx", "3"}, {"alpine", "0"}}
+	for output, want := range map[string]string{
+		"table": "NAME    COUNT\nnginx   3\nalpine  0\n",
+		"json":  "[\n  {\n    \"name\": \"nginx\",\n    \"count\": 3\n  },\n  {\n    \"name\": \"alpine\"\n  }\n]\n",
+		"yaml":  "- count: 3\n  name: nginx\n- name: alpine\n",
+	} {
+		var buf bytes.Buffer
+		a := &app{stdout: &buf, output: output}
+		if err := a.print(v, header, rows); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != want {
+			t.Errorf("%s: print =\n%s\nwant\n%s", output, buf.String(), want)
+		}
+
+		buf.Reset()
+		if err := a.done("project %s deleted", "team"); err != nil {
+			t.Fatal(err)
+		}
+		if want := map[string]string{"table": "project team deleted\n"}[output]; buf.String() != want {
+			t.Errorf("%s: done = %q, want %q", output, buf.String(), want)
+		}
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	for n, want := range map[int64]string{
+		0:                 "0B",
+		1023:              "1023B",
+		1024:              "1.0KiB",
+		1536:              "1.5KiB",
+		5 << 20:           "5.0MiB",
+		3<<30 + 512<<20:   "3.5GiB",
+		1 << 40:           "1.0TiB",
+		(1<<10 - 1) << 50: "1023.0PiB",
+	} {
+		if got := formatSize(n); got != want {
+			t.Errorf("formatSize(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
+
+func TestFormatTime(t *testing.T) {
+	if got := formatTime(time.Time{}); got != "-" {
+		t.Errorf("formatTime of the zero time = %q", got)
+	}
+	at := time.Date(2020, 6, 1, 10, 30, 0, 0, time.FixedZone("CST", 8*3600))
+	if got := formatTime(at); got != "2020-06-01T02:30:00Z" {
+		t.Errorf("formatTime = %q", got)
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"gopkg.in/yaml.v2"
+)
+
+// config is the profiles file, $HARBORCTL_CONFIG or harborctl/config.yaml
+// in the user configuration directory by default:
+//
+//	current: prod
+//	profiles:
+//	  prod:
+//	    url: https://harbor.example.com
+//	    username: admin
+//	    password_env: HARBOR_PROD_PASSWORD
+//	    api_version: v2.0
+//	  lab:
+//	    url: https://harbor.lab.example.com
+//	    docker_config: true
+//	    insecure: true
+type config struct {
+	// Name of the profile used by default
+	Current  string              `yaml:"current,omitempty"`
+	Profiles map[string]*profile `yaml:"profiles,omitempty"`
+
+	path string
+}
+
+// profile holds the address and credentials of a Harbor instance.
+type profile struct {
+	URL      string `yaml:"url"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	// Environment variable holding the password, preferred to Password
+	PasswordEnv string `yaml:"password_env,omitempty"`
+	// Authenticate with the credentials saved by docker login
+	DockerConfig bool `yaml:"docker_config,omitempty"`
+	// API version of Harbor, detected when empty
+	APIVersion string        `yaml:"api_version,omitempty"`
+	CAFile     string        `yaml:"ca_file,omitempty"`
+	Insecure   bool          `yaml:"insecure,omitempty"`
+	Timeout    time.Duration `yaml:"timeout,omitempty"`
+}
+
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "harborctl", "config.yaml"), nil
+}
+
+// loadConfig reads the profiles file at path, or at its default location
+// when path is empty. A missing file holds no profile.
+func loadConfig(path string) (*config, error) {
+	if path == "" {
+		var err error
+		if path, err = defaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+	cfg := &config{path: path}
+	data, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("invalid profiles file %s: %v", path, err)
+	}
+	return cfg, nil
+}
+
+// save writes the profiles file, readable by its owner only since it may
+// hold passwords.
+func (cfg *config) save() error {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.path), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(cfg.path, data, 0600)
+}
+
+// resolveProfile returns the selected profile, overridden by the global
+// flags and environment variables.
+func (a *app) resolveProfile(cfg *config) (profile, error) {
+	var p profile
+	name := a.profile
+	if name == "" {
+		name = cfg.Current
+	}
+	if name != "" {
+		selected, ok := cfg.Profiles[name]
+		if !ok {
+			return p, usagef("unknown profile %q", name)
+		}
+		p = *selected
+	}
+	if a.url != "" {
+		p.URL = a.url
+	}
+	if a.username != "" {
+		p.Username = a.username
+	}
+	if a.password != "" {
+		p.Password, p.PasswordEnv = a.password, ""
+	}
+	if a.apiVersion != "" {
+		p.APIVersion = a.apiVersion
+	}
+	if a.insecure {
+		p.Insecure = true
+	}
+	if p.URL == "" {
+		return p, usagef("no Harbor instance selected, create a profile with harborctl profile set or pass --url")
+	}
+	return p, nil
+}
+
+// client returns a client of the Harbor instance of the profile.
+func (p profile) client() (*client2.Client, error) {
+	version := client2.APIVersionAuto
+	if p.APIVersion != "" {
+		version = client2.APIVersion(p.APIVersion)
+	}
+	opts := []client2.Option{
+		client2.WithBaseURL(p.URL),
+		client2.WithUserAgent("harborctl"),
+		client2.WithAPIVersion(version),
+	}
+	password := p.Password
+	if p.PasswordEnv != "" {
+		password = os.Getenv(p.PasswordEnv)
+	}
+	switch {
+	case p.Username != "":
+		opts = append(opts, client2.WithBasicAuth(p.Username, password))
+	case p.DockerConfig:
+		opts = append(opts, client2.WithAuthenticator(client2.NewDockerConfigAuth("")))
+	}
+	if p.CAFile != "" {
+		opts = append(opts, client2.WithCAFile(p.CAFile))
+	}
+	if p.Insecure {
+		opts = append(opts, client2.WithInsecureSkipVerify(true))
+	}
+	if p.Timeout != 0 {
+		opts = append(opts, client2.WithTimeouts(0, 0, 0, p.Timeout))
+	}
+	return client2.New(opts...)
+}
+
+func profileCommand() *command {
+	return &command{
+		name:    "profile",
+		summary: "Manage the profiles of the Harbor instances",
+		sub: []*command{
+			{
+				name:    "list",
+				summary: "List the profiles",
+				flags:   noFlags(exactArgs(0, profileList)),
+			},
+			{
+				name:    "use",
+				args:    "NAME",
+				summary: "Make a profile the current one",
+				flags:   noFlags(exactArgs(1, profileUse)),
+			},
+			{
+				name:    "set",
+				args:    "NAME",
+				summary: "Create or update a profile",
+				flags:   profileSet,
+			},
+			{
+				name:    "delete",
+				args:    "NAME",
+				summary: "Delete a profile",
+				flags:   noFlags(exactArgs(1, profileDelete)),
+			},
+		},
+	}
+}
+
+func profileList(a *app, args []string) error {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	type item struct {
+		Name     string `json:"name"`
+		Current  bool   `json:"current"`
+		URL      string `json:"url"`
+		Username string `json:"username,omitempty"`
+	}
+	var (
+		items []item
+		rows  [][]string
+	)
+	names := make([]string, 0, len(cfg.Profiles))
+	for name := range cfg.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		p := cfg.Profiles[name]
+		it := item{Name: name, Current: name == cfg.Current, URL: p.URL, Username: p.Username}
+		items = append(items, it)
+		current := ""
+		if it.Current {
+			current = "*"
+		}
+		rows = append(rows, []string{current, name, p.URL, orDash(p.Username)})
+	}
+	return a.print(items, []string{"CURRENT", "NAME", "URL", "USERNAME"}, rows)
+}
+
+func profileUse(a *app, args []string) error {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profiles[args[0]]; !ok {
+		return usagef("unknown profile %q", args[0])
+	}
+	cfg.Current = args[0]
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	return a.done("using profile %s", args[0])
+}
+
+func profileSet(fs *flag.FlagSet) runFunc {
+	var (
+		p        profile
+		password bool
+	)
+	fs.StringVar(&p.URL, "url", "", "URL of Harbor")
+	fs.StringVar(&p.Username, "username", "", "username")
+	fs.BoolVar(&password, "password-stdin", false, "read the password from the standard input")
+	fs.StringVar(&p.PasswordEnv, "password-env", "", "environment variable holding the password")
+	fs.BoolVar(&p.DockerConfig, "docker-config", false, "authenticate with the credentials of docker login")
+	fs.StringVar(&p.APIVersion, "api-version", "", "API version of Harbor: v1, v2.0 or auto (the default)")
+	fs.StringVar(&p.CAFile, "ca-file", "", "PEM bundle of the CAs to trust")
+	fs.BoolVar(&p.Insecure, "insecure", false, "skip the verification of the certificate of Harbor")
+	fs.DurationVar(&p.Timeout, "timeout", 0, "timeout of the requests")
+	return exactArgs(1, func(a *app, args []string) error {
+		cfg, err := loadConfig(a.configPath)
+		if err != nil {
+			return err
+		}
+		current, ok := cfg.Profiles[args[0]]
+		if !ok {
+			current = &profile{}
+		}
+		// Only the flags given change an existing profile
+		fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "url":
+				current.URL = p.URL
+			case "username":
+				current.Username = p.Username
+			case "password-env":
+				current.PasswordEnv = p.PasswordEnv
+			case "docker-config":
+				current.DockerConfig = p.DockerConfig
+			case "api-version":
+				current.APIVersion = p.APIVersion
+			case "ca-file":
+				current.CAFile = p.CAFile
+			case "insecure":
+				current.Insecure = p.Insecure
+			case "timeout":
+				current.Timeout = p.Timeout
+			}
+		})
+		if password {
+			data, err := ioutil.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			current.Password = string(trimNewline(data))
+		}
+		if current.URL == "" {
+			return usagef("the profile %s has no URL, pass --url", args[0])
+		}
+		if cfg.Profiles == nil {
+			cfg.Profiles = map[string]*profile{}
+		}
+		cfg.Profiles[args[0]] = current
+		if cfg.Current == "" {
+			cfg.Current = args[0]
+		}
+		if err := cfg.save(); err != nil {
+			return err
+		}
+		return a.done("profile %s saved to %s", args[0], cfg.path)
+	})
+}
+
+func trimNewline(data []byte) []byte {
+	for len(data) != 0 && (data[len(data)-1] == '\n' || data[len(data)-1] == '\r') {
+		data = data[:len(data)-1]
+	}
+	return data
+}
+
+func profileDelete(a *app, args []string) error {
+	cfg, err := loadConfig(a.configPath)
+	if err != nil {
+		return err
+	}
+	if _, ok := cfg.Profiles[args[0]]; !ok {
+		return usagef("unknown profile %q", args[0])
+	}
+	delete(cfg.Profiles, args[0])
+	if cfg.Current == args[0] {
+		cfg.Current = ""
+	}
+	if err := cfg.save(); err != nil {
+		return err
+	}
+	return a.done("profile %s deleted", args[0])
+}
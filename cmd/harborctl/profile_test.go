@@ -0,0 +1,150 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/harbortest"
+)
+
+func TestProfile(t *testing.T) {
+	srv := harbortest.Start(t)
+	path := filepath.Join(tempDir(t), "harborctl", "config.yaml")
+	cmd := func(args ...string) (int, string, string) {
+		return harborctl(append([]string{"-config", path}, args...)...)
+	}
+
+	// Without profile, the commands need the URL of Harbor
+	if code, _, stderr := cmd("users", "current"); code != exitUsage || !strings.Contains(stderr, "no Harbor instance selected") {
+		t.Errorf("users current without profile = %d, %q", code, stderr)
+	}
+	if code, _, _ := cmd("profile", "set", "lab", "--username", "admin"); code != exitUsage {
+		t.Errorf("profile set without URL = %d", code)
+	}
+
+	code, stdout, stderr := cmd("profile", "set", "lab", "--url", srv.URL, "--username", "admin", "--password-env", "LAB_PASSWORD", "--api-version", "v1", "--timeout", "30s")
+	if code != exitOK || stdout != "profile lab saved to "+path+"\n" {
+		t.Fatalf("profile set = %d, %q, %q", code, stdout, stderr)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0600 {
+		t.Errorf("profiles file mode = %v, want 0600", perm)
+	}
+	if code, _, _ := cmd("profile", "set", "prod", "--url", "https://harbor.example.com", "--docker-config"); code != exitOK {
+		t.Errorf("profile set = %d", code)
+	}
+	// Only the flags given change an existing profile
+	if code, _, _ := cmd("profile", "set", "lab", "--insecure"); code != exitOK {
+		t.Errorf("profile set of an existing profile = %d", code)
+	}
+
+	cfg, err := loadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]*profile{
+		"lab":  {URL: srv.URL, Username: "admin", PasswordEnv: "LAB_PASSWORD", APIVersion: "v1", Insecure: true, Timeout: 30 * time.Second},
+		"prod": {URL: "https://harbor.example.com", DockerConfig: true},
+	}
+	if cfg.Current != "lab" || !reflect.DeepEqual(cfg.Profiles, want) {
+		t.Errorf("loadConfig = %q, %+v", cfg.Current, cfg.Profiles)
+	}
+
+	os.Setenv("LAB_PASSWORD", harbortest.AdminPassword)
+	defer os.Unsetenv("LAB_PASSWORD")
+	if code, stdout, stderr := cmd("users", "current", "-o", "json"); code != exitOK || !strings.Contains(stdout, `"username": "admin"`) {
+		t.Errorf("users current with the current profile = %d, %q, %q", code, stdout, stderr)
+	}
+	// The global flags override the profile
+	if code, _, _ := cmd("-password", "wrong", "users", "current"); code != exitUnauthorized {
+		t.Errorf("users current with a wrong password = %d", code)
+	}
+
+	if code, stdout, _ := cmd("profile", "use", "prod"); code != exitOK || stdout != "using profile prod\n" {
+		t.Errorf("profile use = %d, %q", code, stdout)
+	}
+	code, stdout, _ = cmd("profile", "list")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if code != exitOK || len(lines) != 3 || !reflect.DeepEqual(strings.Fields(lines[2]), []string{"*", "prod", "https://harbor.example.com", "-"}) {
+		t.Errorf("profile list = %d, %q", code, stdout)
+	}
+	if code, stdout, _ := cmd("-profile", "lab", "users", "current", "-o", "json"); code != exitOK || !strings.Contains(stdout, `"username": "admin"`) {
+		t.Errorf("users current with -profile = %d, %q", code, stdout)
+	}
+
+	if code, _, _ := cmd("profile", "delete", "prod"); code != exitOK {
+		t.Errorf("profile delete = %d", code)
+	}
+	for _, args := range [][]string{
+		{"profile", "use", "prod"},
+		{"profile", "delete", "prod"},
+		{"-profile", "prod", "users", "current"},
+	} {
+		if code, _, stderr := cmd(args...); code != exitUsage || !strings.Contains(stderr, `unknown profile "prod"`) {
+			t.Errorf("%v = %d, %q", args, code, stderr)
+		}
+	}
+	if cfg, err := loadConfig(path); err != nil || cfg.Current != "" || len(cfg.Profiles) != 1 {
+		t.Errorf("loadConfig = %+v, %v", cfg, err)
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := tempDir(t)
+	cfg, err := loadConfig(filepath.Join(dir, "missing.yaml"))
+	if err != nil || cfg.Current != "" || len(cfg.Profiles) != 0 {
+		t.Errorf("loadConfig of a missing file = %+v, %v", cfg, err)
+	}
+	path := filepath.Join(dir, "config.yaml")
+	if err := ioutil.WriteFile(path, []byte("profiles:\n  lab:\n    address: https://harbor.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadConfig(path); err == nil || !strings.Contains(err.Error(), "invalid profiles file") {
+		t.Errorf("loadConfig of an unknown field = %v", err)
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	cfg := &config{
+		Current: "lab",
+		Profiles: map[string]*profile{
+			"lab":  {URL: "https://harbor.lab.example.com", Username: "robot", PasswordEnv: "LAB_PASSWORD"},
+			"prod": {URL: "https://harbor.example.com", APIVersion: "v2.0"},
+		},
+	}
+	for _, test := range []struct {
+		a    app
+		want profile
+	}{
+		{app{}, *cfg.Profiles["lab"]},
+		{app{profile: "prod"}, *cfg.Profiles["prod"]},
+		{
+			app{url: "https://other.example.com", password: "secret", apiVersion: "v1", insecure: true},
+			profile{URL: "https://other.example.com", Username: "robot", Password: "secret", APIVersion: "v1", Insecure: true},
+		},
+	} {
+		p, err := test.a.resolveProfile(cfg)
+		if err != nil || p != test.want {
+			t.Errorf("resolveProfile(%+v) = %+v, %v, want %+v", test.a, p, err, test.want)
+		}
+	}
+	if _, err := (&app{}).resolveProfile(&config{}); exitCode(err) != exitUsage {
+		t.Errorf("resolveProfile without profile = %v", err)
+	}
+}
+
+func TestTrimNewline(t *testing.T) {
+	for in, want := range map[string]string{"secret": "secret", "secret\n": "secret", "secret\r\n\n": "secret", "": ""} {
+		if got := string(trimNewline([]byte(in))); got != want {
+			t.Errorf("trimNewline(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/reconcile"
+)
+
+func (a *app) projects() (projects.Service, error) {
+	c, err := a.harbor()
+	if err != nil {
+		return nil, err
+	}
+	return projects.NewProjectService(c), nil
+}
+
+// project returns the project named or identified by ref.
+func (a *app) project(s projects.Service, ref string) (projects.Project, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		p, _, errs := s.GetContext(a.ctx, id)
+		return p, check(errs)
+	}
+	list, _, errs := s.ListContext(a.ctx, &projects.ListProjectsOptions{Name: ref})
+	if err := check(errs); err != nil {
+		return projects.Project{}, err
+	}
+	for _, p := range list {
+		if p.Name == ref {
+			return p, nil
+		}
+	}
+	return projects.Project{}, fmt.Errorf("project %s: %w", ref, client2.ErrNotFound)
+}
+
+func projectRow(p projects.Project) []string {
+	return []string{
+		strconv.FormatInt(p.ProjectID, 10),
+		p.Name,
+		orDash(p.Metadata["public"]),
+		strconv.FormatInt(p.RepoCount, 10),
+		orDash(p.OwnerName),
+		formatTime(p.CreationTime),
+	}
+}
+
+var projectHeader = []string{"ID", "NAME", "PUBLIC", "REPOSITORIES", "OWNER", "CREATED"}
+
+func projectsCommand() *command {
+	return &command{
+		name:    "projects",
+		summary: "Manage the projects",
+		sub: []*command{
+			{
+				name:    "list",
+				summary: "List the projects",
+				flags:   projectsList,
+			},
+			{
+				name:    "get",
+				args:    "PROJECT",
+				summary: "Show a project, by name or ID",
+				flags:   noFlags(exactArgs(1, projectsGet)),
+			},
+			{
+				name:    "create",
+				args:    "NAME",
+				summary: "Create a project",
+				flags:   projectsCreate,
+			},
+			{
+				name:    "delete",
+				args:    "PROJECT",
+				summary: "Delete a project, which must hold no repository",
+				flags:   noFlags(exactArgs(1, projectsDelete)),
+			},
+		},
+	}
+}
+
+func projectsList(fs *flag.FlagSet) runFunc {
+	var opt projects.ListProjectsOptions
+	fs.StringVar(&opt.Name, "name", "", "only the projects whose name contains this")
+	fs.BoolVar(&opt.Public, "public", false, "only the public projects")
+	fs.StringVar(&opt.Owner, "owner", "", "only the projects of this owner")
+	return exactArgs(0, func(a *app, args []string) error {
+		s, err := a.projects()
+		if err != nil {
+			return err
+		}
+		list, errs := s.ListAll(a.ctx, &opt, 4)
+		if err := check(errs); err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(list))
+		for _, p := range list {
+			rows = append(rows, projectRow(p))
+		}
+		return a.print(list, projectHeader, rows)
+	})
+}
+
+func projectsGet(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(p, projectHeader, [][]string{projectRow(p)})
+}
+
+func projectsCreate(fs *flag.FlagSet) runFunc {
+	var (
+		public   bool
+		metadata = metadataFlag{}
+	)
+	fs.BoolVar(&public, "public", false, "make the project public")
+	fs.Var(metadata, "metadata", "metadata of the project as KEY=VALUE, repeatable")
+	return exactArgs(1, func(a *app, args []string) error {
+		s, err := a.projects()
+		if err != nil {
+			return err
+		}
+		metadata["public"] = strconv.FormatBool(public)
+		resp, errs := s.CreateContext(a.ctx, &projects.ProjectRequest{Name: args[0], Metadata: metadata})
+		if err := check(errs); err != nil {
+			return err
+		}
+		if id, ok := client2.LocationID(resp); ok {
+			return a.done("project %s created with ID %d", args[0], id)
+		}
+		return a.done("project %s created", args[0])
+	})
+}
+
+func projectsDelete(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteContext(a.ctx, p.ProjectID)
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("project %s deleted", p.Name)
+}
+
+// metadataFlag collects KEY=VALUE flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for _, k := range sortedKeys(m) {
+		pairs = append(pairs, k+"="+m[k])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m metadataFlag) Set(s string) error {
+	k, v, err := splitPair(s)
+	if err != nil {
+		return err
+	}
+	m[k] = v
+	return nil
+}
+
+func splitPair(s string) (string, string, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return "", "", usagef("expected KEY=VALUE, got %q", s)
+	}
+	return s[:i], s[i+1:], nil
+}
+
+func membersCommand() *command {
+	return &command{
+		name:    "members",
+		summary: "Manage the members of the projects",
+		sub: []*command{
+			{
+				name:    "list",
+				args:    "PROJECT",
+				summary: "List the members of a project",
+				flags:   noFlags(exactArgs(1, membersList)),
+			},
+			{
+				name:    "add",
+				args:    "PROJECT USERNAME ROLE",
+				summary: "Add a member to a project, as projectAdmin, maintainer, developer, guest or limitedGuest",
+				flags:   noFlags(exactArgs(3, membersAdd)),
+			},
+			{
+				name:    "update",
+				args:    "PROJECT USERNAME ROLE",
+				summary: "Change the role of a member of a project",
+				flags:   noFlags(exactArgs(3, membersUpdate)),
+			},
+			{
+				name:    "remove",
+				args:    "PROJECT USERNAME",
+				summary: "Remove a member from a project",
+				flags:   noFlags(exactArgs(2, membersRemove)),
+			},
+		},
+	}
+}
+
+func parseRole(s string) (int, error) {
+	var role reconcile.Role
+	if err := role.UnmarshalText([]byte(s)); err != nil {
+		return 0, usageError{msg: err.Error()}
+	}
+	return int(role), nil
+}
+
+// member returns the member username of the project.
+func (a *app) member(s projects.Service, p projects.Project, username string) (projects.Member, error) {
+	members, _, errs := s.ListMembersContext(a.ctx, p.ProjectID)
+	if err := check(errs); err != nil {
+		return projects.Member{}, err
+	}
+	for _, m := range members {
+		if m.EntityType == projects.EntityUser && m.EntityName == username {
+			return m, nil
+		}
+	}
+	return projects.Member{}, fmt.Errorf("member %s of project %s: %w", username, p.Name, client2.ErrNotFound)
+}
+
+func membersList(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	members, _, errs := s.ListMembersContext(a.ctx, p.ProjectID)
+	if err := check(errs); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(members))
+	for _, m := range members {
+		rows = append(rows, []string{strconv.FormatInt(m.ID, 10), m.EntityName, reconcile.Role(m.RoleID).String()})
+	}
+	return a.print(members, []string{"MEMBER ID", "NAME", "ROLE"}, rows)
+}
+
+func membersAdd(a *app, args []string) error {
+	role, err := parseRole(args[2])
+	if err != nil {
+		return err
+	}
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	_, errs := s.CreateMemberContext(a.ctx, p.ProjectID, projects.ProjectMemberRequest{RoleID: role, MemberUser: &projects.MemberUser{Username: args[1]}})
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("%s added to project %s as %s", args[1], p.Name, reconcile.Role(role))
+}
+
+func membersUpdate(a *app, args []string) error {
+	role, err := parseRole(args[2])
+	if err != nil {
+		return err
+	}
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	m, err := a.member(s, p, args[1])
+	if err != nil {
+		return err
+	}
+	_, errs := s.UpdateMemberContext(a.ctx, p.ProjectID, m.ID, role)
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("%s is now %s of project %s", m.EntityName, reconcile.Role(role), p.Name)
+}
+
+func membersRemove(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	m, err := a.member(s, p, args[1])
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteMemberContext(a.ctx, int(p.ProjectID), int(m.ID))
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("%s removed from project %s", m.EntityName, p.Name)
+}
+
+func metadataCommand() *command {
+	return &command{
+		name:    "metadata",
+		summary: "Manage the metadata of the projects, e.g. public, auto_scan, severity and prevent_vul",
+		sub: []*command{
+			{
+				name:    "list",
+				args:    "PROJECT",
+				summary: "List the metadata of a project",
+				flags:   noFlags(exactArgs(1, metadataList)),
+			},
+			{
+				name:    "set",
+				args:    "PROJECT KEY=VALUE...",
+				summary: "Set metadata of a project",
+				flags:   noFlags(metadataSet),
+			},
+			{
+				name:    "delete",
+				args:    "PROJECT KEY",
+				summary: "Delete a metadata of a project",
+				flags:   noFlags(exactArgs(2, metadataDelete)),
+			},
+		},
+	}
+}
+
+func metadataList(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	metadata, _, errs := s.GetMetadataByIdContext(a.ctx, p.ProjectID)
+	if err := check(errs); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(metadata))
+	for _, k := range sortedKeys(metadata) {
+		rows = append(rows, []string{k, metadata[k]})
+	}
+	return a.print(metadata, []string{"KEY", "VALUE"}, rows)
+}
+
+func metadataSet(a *app, args []string) error {
+	if len(args) < 2 {
+		return usagef("expected a project and KEY=VALUE pairs")
+	}
+	metadata := metadataFlag{}
+	for _, pair := range args[1:] {
+		if err := metadata.Set(pair); err != nil {
+			return err
+		}
+	}
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	// The metadata endpoint of Harbor 1.x only adds new keys, while the
+	// update of the project changes existing ones as well.
+	_, errs := s.UpdateContext(a.ctx, p.ProjectID, projects.Project{Metadata: metadata})
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("metadata of project %s set: %s", p.Name, metadata)
+}
+
+func metadataDelete(a *app, args []string) error {
+	s, err := a.projects()
+	if err != nil {
+		return err
+	}
+	p, err := a.project(s, args[0])
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteMetadataContext(a.ctx, p.ProjectID, args[1])
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("metadata %s of project %s deleted", args[1], p.Name)
+}
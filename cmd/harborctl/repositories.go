@@ -0,0 +1,402 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/module/gate"
+	"github.com/codingXiang/go-harbor-client/module/report"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+func (a *app) repositories() (repositories.Service, error) {
+	c, err := a.harbor()
+	if err != nil {
+		return nil, err
+	}
+	return repositories.NewRepositoriesService(c), nil
+}
+
+// splitRepository splits a full repository name, e.g. library/nginx, into
+// its project and repository parts.
+func splitRepository(name string) (string, string, error) {
+	i := strings.Index(name, "/")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", usagef("expected a repository as PROJECT/NAME, got %q", name)
+	}
+	return name[:i], name[i+1:], nil
+}
+
+func repositoriesCommand() *command {
+	return &command{
+		name:    "repositories",
+		summary: "Manage the repositories",
+		sub: []*command{
+			{
+				name:    "list",
+				summary: "List the repositories",
+				flags:   repositoriesList,
+			},
+			{
+				name:    "top",
+				summary: "List the most popular public repositories, on Harbor 1.x",
+				flags:   repositoriesTop,
+			},
+			{
+				name:    "delete",
+				args:    "REPOSITORY",
+				summary: "Delete a repository and all its tags",
+				flags:   noFlags(exactArgs(1, repositoriesDelete)),
+			},
+		},
+	}
+}
+
+func repositoriesList(fs *flag.FlagSet) runFunc {
+	var (
+		project string
+		opt     repositories.ListRepositoriesOption
+	)
+	fs.StringVar(&project, "project", "", "project of the repositories, by name or ID, required on Harbor 1.x")
+	fs.StringVar(&opt.Q, "q", "", "only the repositories whose name contains this")
+	return exactArgs(0, func(a *app, args []string) error {
+		if project != "" {
+			projects, err := a.projects()
+			if err != nil {
+				return err
+			}
+			p, err := a.project(projects, project)
+			if err != nil {
+				return err
+			}
+			opt.ProjectId = p.ProjectID
+		}
+		s, err := a.repositories()
+		if err != nil {
+			return err
+		}
+		list, errs := s.ListAll(a.ctx, &opt, 4)
+		if err := check(errs); err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(list))
+		for _, r := range list {
+			rows = append(rows, []string{r.Name, strconv.FormatInt(r.PullCount, 10), formatTime(r.UpdateTime), orDash(r.Description)})
+		}
+		return a.print(list, []string{"NAME", "PULLS", "UPDATED", "DESCRIPTION"}, rows)
+	})
+}
+
+func repositoriesTop(fs *flag.FlagSet) runFunc {
+	count := fs.Int("count", 10, "number of repositories")
+	return exactArgs(0, func(a *app, args []string) error {
+		s, err := a.repositories()
+		if err != nil {
+			return err
+		}
+		list, _, errs := s.GetTopContext(a.ctx, *count)
+		if err := check(errs); err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(list))
+		for _, r := range list {
+			rows = append(rows, []string{r.Name, strconv.FormatInt(r.PullCount, 10), strconv.FormatInt(r.TagsCount, 10)})
+		}
+		return a.print(list, []string{"NAME", "PULLS", "TAGS"}, rows)
+	})
+}
+
+func repositoriesDelete(a *app, args []string) error {
+	if _, _, err := splitRepository(args[0]); err != nil {
+		return err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteContext(a.ctx, args[0])
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("repository %s deleted", args[0])
+}
+
+func tagsCommand() *command {
+	return &command{
+		name:    "tags",
+		summary: "Manage the tags of the repositories",
+		sub: []*command{
+			{
+				name:    "list",
+				args:    "REPOSITORY",
+				summary: "List the tags of a repository",
+				flags:   noFlags(exactArgs(1, tagsList)),
+			},
+			{
+				name:    "get",
+				args:    "REPOSITORY TAG",
+				summary: "Show a tag",
+				flags:   noFlags(exactArgs(2, tagsGet)),
+			},
+			{
+				name:    "delete",
+				args:    "REPOSITORY TAG",
+				summary: "Delete a tag",
+				flags:   noFlags(exactArgs(2, tagsDelete)),
+			},
+		},
+	}
+}
+
+func tagRow(t repositories.TagResp) []string {
+	signed := "no"
+	if t.Signature != nil {
+		signed = "yes"
+	}
+	scan := "-"
+	if o := t.ScanOverview; o != nil {
+		scan = string(o.Status.Normalize())
+		if o.Status.Succeeded() {
+			scan = o.Sev.String()
+		}
+	}
+	return []string{t.Name, shortDigest(t.Digest), formatSize(t.Size), formatTime(t.Created), signed, scan}
+}
+
+var tagHeader = []string{"TAG", "DIGEST", "SIZE", "CREATED", "SIGNED", "SCAN"}
+
+// shortDigest abbreviates a digest the way docker images does.
+func shortDigest(digest string) string {
+	if i := strings.Index(digest, ":"); i >= 0 && len(digest) > i+13 {
+		return digest[:i+13]
+	}
+	return orDash(digest)
+}
+
+func tagsList(a *app, args []string) error {
+	project, repo, err := splitRepository(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return err
+	}
+	tags, _, errs := s.ListTagsContext(a.ctx, project, repo)
+	if err := check(errs); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(tags))
+	for _, t := range tags {
+		rows = append(rows, tagRow(t))
+	}
+	return a.print(tags, tagHeader, rows)
+}
+
+func tagsGet(a *app, args []string) error {
+	project, repo, err := splitRepository(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return err
+	}
+	t, _, errs := s.GetTagContext(a.ctx, project, repo, args[1])
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.print(t, tagHeader, [][]string{tagRow(t)})
+}
+
+func tagsDelete(a *app, args []string) error {
+	project, repo, err := splitRepository(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteTagContext(a.ctx, project, repo, args[1])
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("tag %s:%s deleted", args[0], args[1])
+}
+
+func scanCommand() *command {
+	return &command{
+		name:    "scan",
+		summary: "Scan images and check their vulnerabilities",
+		sub: []*command{
+			{
+				name:    "start",
+				args:    "REPOSITORY TAG",
+				summary: "Start the scan of an image",
+				flags:   noFlags(exactArgs(2, scanStart)),
+			},
+			{
+				name:    "wait",
+				args:    "REPOSITORY TAG",
+				summary: "Scan an image and wait for its report",
+				flags:   scanWait,
+			},
+			{
+				name:    "report",
+				args:    "REPOSITORY TAG",
+				summary: "Show the vulnerabilities of the last scan of an image, as a table or " + formatNames(),
+				flags:   scanReport,
+			},
+			{
+				name:    "gate",
+				args:    "REPOSITORY TAG",
+				summary: "Check the vulnerabilities of an image against a gate policy",
+				flags:   scanGate,
+			},
+		},
+	}
+}
+
+func formatNames() string {
+	names := make([]string, 0, len(report.Formats()))
+	for _, f := range report.Formats() {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
+
+func scanStart(a *app, args []string) error {
+	if _, _, err := splitRepository(args[0]); err != nil {
+		return err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return err
+	}
+	_, errs := s.ScanImageContext(a.ctx, args[0], args[1])
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("scan of %s:%s started", args[0], args[1])
+}
+
+// scanFlags declares the flags selecting how the scan report is obtained.
+func scanFlags(fs *flag.FlagSet) (scan *bool, timeout *time.Duration) {
+	scan = fs.Bool("scan", false, "scan the image and wait for the report first")
+	timeout = fs.Duration("timeout", 10*time.Minute, "limit of the wait for the scan")
+	return scan, timeout
+}
+
+// imageReport returns the scan report of the image, scanned first if scan.
+func (a *app) imageReport(repository, tag string, scan bool, timeout time.Duration) (repositories.ScanReport, error) {
+	project, repo, err := splitRepository(repository)
+	if err != nil {
+		return repositories.ScanReport{}, err
+	}
+	s, err := a.repositories()
+	if err != nil {
+		return repositories.ScanReport{}, err
+	}
+	if scan {
+		r, _, errs := s.ScanAndWait(a.ctx, repository, tag, &repositories.ScanWaitOptions{Timeout: timeout})
+		return r, check(errs)
+	}
+	t, _, errs := s.GetTagContext(a.ctx, project, repo, tag)
+	if err := check(errs); err != nil {
+		return repositories.ScanReport{}, err
+	}
+	items, _, errs := s.GetImageDetailsContext(a.ctx, repository, tag)
+	if err := check(errs); err != nil {
+		return repositories.ScanReport{}, err
+	}
+	r := repositories.ScanReport{Repository: repository, Tag: tag, Digest: t.Digest, Vulnerabilities: items, Total: len(items)}
+	r.Summary, r.Severity = repositories.Summarize(items)
+	if o := t.ScanOverview; o != nil {
+		r.Status, r.EndTime = o.Status, o.UpdateTime
+	}
+	return r, nil
+}
+
+func scanWait(fs *flag.FlagSet) runFunc {
+	timeout := fs.Duration("timeout", 10*time.Minute, "limit of the wait for the scan")
+	return exactArgs(2, func(a *app, args []string) error {
+		r, err := a.imageReport(args[0], args[1], true, *timeout)
+		if err != nil {
+			return err
+		}
+		return a.print(r, []string{"SEVERITY", "COUNT"}, summaryRows(r))
+	})
+}
+
+// summaryRows returns the number of vulnerabilities per severity, from the
+// highest.
+func summaryRows(r repositories.ScanReport) [][]string {
+	var rows [][]string
+	for sev := repositories.SeverityCritical; sev >= repositories.SeverityUnknown; sev-- {
+		if n := r.Summary[sev]; n != 0 {
+			rows = append(rows, []string{sev.String(), strconv.Itoa(n)})
+		}
+	}
+	return append(rows, []string{"Total", strconv.Itoa(r.Total)})
+}
+
+func scanReport(fs *flag.FlagSet) runFunc {
+	format := fs.String("format", "", "export format: "+formatNames())
+	scan, timeout := scanFlags(fs)
+	return exactArgs(2, func(a *app, args []string) error {
+		var f report.Format
+		if *format != "" {
+			var err error
+			if f, err = report.ParseFormat(*format); err != nil {
+				return usageError{msg: err.Error()}
+			}
+		}
+		r, err := a.imageReport(args[0], args[1], *scan, *timeout)
+		if err != nil {
+			return err
+		}
+		if f != "" {
+			return report.Write(a.stdout, f, report.FromReport(r))
+		}
+		rows := make([][]string, 0, len(r.Vulnerabilities))
+		for _, v := range r.Vulnerabilities {
+			rows = append(rows, []string{v.ID, v.Severity.String(), v.Pkg, v.Version, orDash(v.Fixed)})
+		}
+		return a.print(r.Vulnerabilities, []string{"ID", "SEVERITY", "PACKAGE", "VERSION", "FIXED"}, rows)
+	})
+}
+
+func scanGate(fs *flag.FlagSet) runFunc {
+	policyFile := fs.String("policy", "", "gate policy file, in YAML or JSON (required)")
+	scan, timeout := scanFlags(fs)
+	return exactArgs(2, func(a *app, args []string) error {
+		if *policyFile == "" {
+			return usagef("the -policy flag is required")
+		}
+		policy, err := gate.LoadPolicy(*policyFile)
+		if err != nil {
+			return err
+		}
+		r, err := a.imageReport(args[0], args[1], *scan, *timeout)
+		if err != nil {
+			return err
+		}
+		v := policy.EvaluateReport(r)
+		if a.output == "table" {
+			err = v.Write(a.stdout)
+		} else {
+			err = a.print(v, nil, nil)
+		}
+		if err != nil {
+			return err
+		}
+		if !v.Pass {
+			return fmt.Errorf("%s:%s: %w", args[0], args[1], errGateFailed)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+func (a *app) users() (user.Service, error) {
+	c, err := a.harbor()
+	if err != nil {
+		return nil, err
+	}
+	return user.NewUserService(c), nil
+}
+
+func userRow(u user.User) []string {
+	admin := "no"
+	if u.HasAdminRole {
+		admin = "yes"
+	}
+	return []string{strconv.Itoa(u.UserID), u.Username, orDash(u.Email), orDash(u.Realname), admin, formatTime(u.CreationTime)}
+}
+
+var userHeader = []string{"ID", "USERNAME", "EMAIL", "NAME", "ADMIN", "CREATED"}
+
+func usersCommand() *command {
+	return &command{
+		name:    "users",
+		summary: "Manage the users",
+		sub: []*command{
+			{
+				name:    "list",
+				summary: "List the users, as an administrator",
+				flags:   noFlags(exactArgs(0, usersList)),
+			},
+			{
+				name:    "current",
+				summary: "Show the user logged in",
+				flags:   noFlags(exactArgs(0, usersCurrent)),
+			},
+			{
+				name:    "get",
+				args:    "ID",
+				summary: "Show a user",
+				flags:   noFlags(exactArgs(1, usersGet)),
+			},
+			{
+				name:    "delete",
+				args:    "ID",
+				summary: "Delete a user",
+				flags:   noFlags(exactArgs(1, usersDelete)),
+			},
+		},
+	}
+}
+
+func parseUserID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, usagef("expected a user ID, got %q", s)
+	}
+	return id, nil
+}
+
+func usersList(a *app, args []string) error {
+	s, err := a.users()
+	if err != nil {
+		return err
+	}
+	users, _, errs := s.ListContext(a.ctx)
+	if err := check(errs); err != nil {
+		return err
+	}
+	rows := make([][]string, 0, len(users))
+	for _, u := range users {
+		rows = append(rows, userRow(u))
+	}
+	return a.print(users, userHeader, rows)
+}
+
+func usersCurrent(a *app, args []string) error {
+	s, err := a.users()
+	if err != nil {
+		return err
+	}
+	u, _, errs := s.CurrentContext(a.ctx)
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.print(u, userHeader, [][]string{userRow(u)})
+}
+
+func usersGet(a *app, args []string) error {
+	id, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := a.users()
+	if err != nil {
+		return err
+	}
+	u, _, errs := s.GetContext(a.ctx, id)
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.print(u, userHeader, [][]string{userRow(u)})
+}
+
+func usersDelete(a *app, args []string) error {
+	id, err := parseUserID(args[0])
+	if err != nil {
+		return err
+	}
+	s, err := a.users()
+	if err != nil {
+		return err
+	}
+	_, errs := s.DeleteContext(a.ctx, id)
+	if err := check(errs); err != nil {
+		return err
+	}
+	return a.done("user %d deleted", id)
+}
+
+func statisticsCommand() *command {
+	return &command{
+		name:    "statistics",
+		summary: "Count the projects and repositories visible to the user",
+		flags:   noFlags(exactArgs(0, statistics)),
+	}
+}
+
+func statistics(a *app, args []string) error {
+	c, err := a.harbor()
+	if err != nil {
+		return err
+	}
+	stats, _, errs := c.GetStatisticsContext(a.ctx)
+	if err := check(errs); err != nil {
+		return err
+	}
+	rows := [][]string{
+		{"private", strconv.Itoa(stats.PrivateProjectCount), strconv.Itoa(stats.PrivateRepoCount)},
+		{"public", strconv.Itoa(stats.PublicProjectCount), strconv.Itoa(stats.PublicRepoCount)},
+		{"total", strconv.Itoa(stats.TotalProjectCount), strconv.Itoa(stats.TotalRepoCount)},
+	}
+	return a.print(stats, []string{"", "PROJECTS", "REPOSITORIES"}, rows)
+}
@@ -0,0 +1,90 @@
+package harbortest
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Fault alters the requests it matches, to test how clients handle slow or
+// failing servers.
+type Fault struct {
+	// Method of the requests matched, any when empty
+	Method string
+	// Prefix of the paths matched, e.g. /api/projects; any when empty
+	Path string
+	// Delay before the request is handled
+	Latency time.Duration
+	// Status answered instead of handling the request, e.g. 503
+	Status int
+	// Close the connection without answering. The transport of net/http
+	// sends idempotent requests again once when a reused connection is
+	// closed, so they must be dropped twice to fail.
+	Drop bool
+	// Number of requests altered, every one when 0
+	Times int
+}
+
+// Inject adds a fault, applied along with the faults already injected.
+func (s *Server) Inject(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes the faults injected.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// fault applies the faults matching r, and reports whether r was answered.
+func (s *Server) fault(w http.ResponseWriter, r *http.Request) bool {
+	var (
+		latency time.Duration
+		status  int
+		drop    bool
+	)
+	s.mu.Lock()
+	faults := s.faults[:0]
+	for _, f := range s.faults {
+		matches := (f.Method == "" || strings.EqualFold(f.Method, r.Method)) && strings.HasPrefix(r.URL.Path, f.Path)
+		if matches {
+			latency += f.Latency
+			if f.Status != 0 {
+				status = f.Status
+			}
+			drop = drop || f.Drop
+			if f.Times--; f.Times == 0 {
+				// Spent
+				continue
+			}
+		}
+		faults = append(faults, f)
+	}
+	s.faults = faults
+	s.mu.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return true
+		}
+	}
+	switch {
+	case drop:
+		if hj, ok := w.(http.Hijacker); ok {
+			if conn, _, err := hj.Hijack(); err == nil {
+				conn.Close()
+				return true
+			}
+		}
+		panic(http.ErrAbortHandler)
+	case status != 0:
+		writeError(w, status, http.StatusText(status))
+		return true
+	}
+	return false
+}
@@ -0,0 +1,296 @@
+package harbortest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+func noErrors(t *testing.T, call string, errs []error) {
+	t.Helper()
+	if len(errs) != 0 {
+		t.Fatalf("%s: %v", call, errs)
+	}
+}
+
+func TestProjects(t *testing.T) {
+	srv := harbortest.Start(t)
+	s := projects.NewProjectService(srv.Client())
+
+	resp, errs := s.Create(&projects.ProjectRequest{Name: "library", Metadata: map[string]string{"public": "true"}})
+	noErrors(t, "Create", errs)
+	id, ok := client.LocationID(resp)
+	if !ok {
+		t.Fatal("Create returned no Location")
+	}
+	if _, errs := s.Create(&projects.ProjectRequest{Name: "library"}); len(errs) == 0 || !client.IsConflict(errs[0]) {
+		t.Errorf("Create of an existing project: %v, want conflict", errs)
+	}
+	_, errs = s.Check("library")
+	noErrors(t, "Check", errs)
+	if _, errs := s.Check("missing"); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("Check of a missing project: %v, want not found", errs)
+	}
+
+	p, _, errs := s.Get(id)
+	noErrors(t, "Get", errs)
+	if p.Name != "library" || p.Metadata["public"] != "true" || p.OwnerName != harbortest.AdminUsername {
+		t.Errorf("Get = %+v", p)
+	}
+	list, _, errs := s.List(&projects.ListProjectsOptions{Name: "library"})
+	noErrors(t, "List", errs)
+	if len(list) != 1 || list[0].ProjectID != id {
+		t.Errorf("List = %+v", list)
+	}
+
+	_, errs = s.Delete(id)
+	noErrors(t, "Delete", errs)
+	if _, _, errs := s.Get(id); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("Get of a deleted project: %v, want not found", errs)
+	}
+}
+
+func TestMetadata(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", false)
+	s := projects.NewProjectService(srv.Client())
+
+	_, errs := s.AddMetadata(p.ProjectID, map[string]string{"auto_scan": "true"})
+	noErrors(t, "AddMetadata", errs)
+	metadata, _, errs := s.GetMetadataById(p.ProjectID)
+	noErrors(t, "GetMetadataById", errs)
+	if want := map[string]string{"public": "false", "auto_scan": "true"}; !reflect.DeepEqual(metadata, want) {
+		t.Errorf("GetMetadataById = %v, want %v", metadata, want)
+	}
+	metadata, _, errs = s.GetMetadata(p.ProjectID, "auto_scan")
+	noErrors(t, "GetMetadata", errs)
+	if metadata["auto_scan"] != "true" {
+		t.Errorf("GetMetadata = %v", metadata)
+	}
+	_, errs = s.DeleteMetadata(p.ProjectID, "auto_scan")
+	noErrors(t, "DeleteMetadata", errs)
+	if _, _, errs := s.GetMetadata(p.ProjectID, "auto_scan"); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("GetMetadata of deleted metadata: %v, want not found", errs)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", false)
+	dev := srv.AddUser("dev", "Passw0rd", false)
+	s := projects.NewProjectService(srv.Client())
+
+	// The developer sees nothing of a private project before joining it
+	asDev := projects.NewProjectService(srv.Client(client.WithBasicAuth("dev", "Passw0rd")))
+	if _, _, errs := asDev.Get(p.ProjectID); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("Get by a non member: %v, want not found", errs)
+	}
+
+	_, errs := s.AddMember(p.ProjectID, projects.MemberRequest{UserName: "dev", Roles: []int{harbortest.RoleDeveloper}})
+	noErrors(t, "AddMember", errs)
+	members, _, errs := s.GetMembers(p.ProjectID)
+	noErrors(t, "GetMembers", errs)
+	if len(members) != 2 || members[1].Username != "dev" || members[1].Role != harbortest.RoleDeveloper {
+		t.Errorf("GetMembers = %+v", members)
+	}
+	_, _, errs = asDev.Get(p.ProjectID)
+	noErrors(t, "Get by a member", errs)
+
+	_, errs = s.UpdateMemberRole(int(p.ProjectID), dev.UserID, projects.MemberRequest{Roles: []int{harbortest.RoleMaintainer}})
+	noErrors(t, "UpdateMemberRole", errs)
+	role, _, errs := s.GetMemberRole(int(p.ProjectID), dev.UserID)
+	noErrors(t, "GetMemberRole", errs)
+	if role.RoleID != harbortest.RoleMaintainer || role.Name != "maintainer" {
+		t.Errorf("GetMemberRole = %+v", role)
+	}
+	if _, errs := s.UpdateMemberRole(int(p.ProjectID), dev.UserID, projects.MemberRequest{Roles: []int{42}}); len(errs) == 0 || !client.IsBadRequest(errs[0]) {
+		t.Errorf("UpdateMemberRole to an unknown role: %v, want bad request", errs)
+	}
+
+	_, errs = s.DeleteMember(int(p.ProjectID), dev.UserID)
+	noErrors(t, "DeleteMember", errs)
+	if _, _, errs := s.GetMemberRole(int(p.ProjectID), dev.UserID); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("GetMemberRole of a removed member: %v, want not found", errs)
+	}
+}
+
+func TestRepositoriesAndTags(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", true)
+	for _, tag := range []string{"1.18", "1.19"} {
+		if _, err := srv.PushImage("library/nginx", tag); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := srv.SignTag("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+
+	repos, _, errs := s.List(&repositories.ListRepositoriesOption{ProjectId: p.ProjectID})
+	noErrors(t, "List", errs)
+	if len(repos) != 1 || repos[0].Name != "library/nginx" {
+		t.Fatalf("List = %+v", repos)
+	}
+	tags, _, errs := s.ListTags("library", "nginx")
+	noErrors(t, "ListTags", errs)
+	if len(tags) != 2 {
+		t.Fatalf("ListTags = %+v", tags)
+	}
+	tag, _, errs := s.GetTag("library", "nginx", "1.19")
+	noErrors(t, "GetTag", errs)
+	if tag.Name != "1.19" || tag.Digest == "" || tag.Signature == nil {
+		t.Errorf("GetTag = %+v", tag)
+	}
+	manifest, _, errs := s.GetTagManifests("library/nginx", "1.19", "")
+	noErrors(t, "GetTagManifests", errs)
+	if manifest.Manifest == nil {
+		t.Errorf("GetTagManifests = %+v", manifest)
+	}
+
+	_, errs = s.DeleteTag("library", "nginx", "1.18")
+	noErrors(t, "DeleteTag", errs)
+	if _, _, errs := s.GetTag("library", "nginx", "1.18"); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("GetTag of a deleted tag: %v, want not found", errs)
+	}
+	logs, _, errs := projects.NewProjectService(srv.Client()).GetLog(p.ProjectID, projects.ListLogOptions{Operations: []string{"delete"}})
+	noErrors(t, "GetLog", errs)
+	if len(logs) != 1 || logs[0].RepoTag != "1.18" {
+		t.Errorf("GetLog(delete) = %+v", logs)
+	}
+}
+
+func TestScan(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	vulns := []repositories.VulnerabilityItem{
+		{ID: "CVE-2020-0001", Severity: repositories.SeverityHigh, Pkg: "openssl", Version: "1.1.1d", Fixed: "1.1.1g"},
+		{ID: "CVE-2020-0002", Severity: repositories.SeverityLow, Pkg: "zlib", Version: "1.2.11"},
+	}
+	if err := srv.SetVulnerabilities("library/nginx", "1.19", vulns); err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+
+	report, _, errs := s.ScanAndWait(context.Background(), "library/nginx", "1.19", &repositories.ScanWaitOptions{Interval: time.Millisecond, Timeout: 5 * time.Second})
+	noErrors(t, "ScanAndWait", errs)
+	if report.Severity != repositories.SeverityHigh || report.Total != 2 || report.Count(repositories.SeverityHigh) != 1 {
+		t.Errorf("ScanAndWait = %+v", report)
+	}
+	items, _, errs := s.GetImageDetails("library/nginx", "1.19")
+	noErrors(t, "GetImageDetails", errs)
+	if len(items) != 2 {
+		t.Errorf("GetImageDetails = %+v", items)
+	}
+}
+
+func TestUsers(t *testing.T) {
+	srv := harbortest.Start(t)
+	s := user.NewUserService(srv.Client())
+
+	_, errs := s.Create(&user.User{Username: "dev", Password: "Passw0rd", Email: "dev@example.com", Realname: "Dev"})
+	noErrors(t, "Create", errs)
+	users, _, errs := s.List()
+	noErrors(t, "List", errs)
+	if len(users) != 2 || users[1].Username != "dev" || users[1].Password != "" {
+		t.Fatalf("List = %+v", users)
+	}
+	dev := users[1]
+
+	asDev := user.NewUserService(srv.Client(client.WithBasicAuth("dev", "Passw0rd")))
+	current, _, errs := asDev.Current()
+	noErrors(t, "Current", errs)
+	if current.UserID != dev.UserID {
+		t.Errorf("Current = %+v, want user %d", current, dev.UserID)
+	}
+	if _, _, errs := asDev.List(); len(errs) == 0 || !client.IsForbidden(errs[0]) {
+		t.Errorf("List by a non administrator: %v, want forbidden", errs)
+	}
+
+	_, errs = asDev.ChangePassword(dev.UserID, user.UpdatePassword{OldPassword: "Passw0rd", NewPassword: "N3wPassw0rd"})
+	noErrors(t, "ChangePassword", errs)
+	_, _, errs = user.NewUserService(srv.Client(client.WithBasicAuth("dev", "N3wPassw0rd"))).Current()
+	noErrors(t, "Current with the new password", errs)
+
+	_, errs = s.ChangeSysadmin(dev.UserID, user.UpdateRole{HasAdminRole: 1})
+	noErrors(t, "ChangeSysadmin", errs)
+	got, _, errs := s.Get(dev.UserID)
+	noErrors(t, "Get", errs)
+	if !got.HasAdminRole {
+		t.Errorf("Get = %+v, want an administrator", got)
+	}
+
+	_, errs = s.Delete(dev.UserID)
+	noErrors(t, "Delete", errs)
+	if _, _, errs := s.Get(dev.UserID); len(errs) == 0 || !client.IsNotFound(errs[0]) {
+		t.Errorf("Get of a deleted user: %v, want not found", errs)
+	}
+}
+
+func TestStatistics(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	srv.AddProject("private", false)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	srv.AddUser("dev", "Passw0rd", false)
+
+	stats, _, errs := srv.Client().GetStatistics()
+	noErrors(t, "GetStatistics", errs)
+	if stats.PublicProjectCount != 1 || stats.PublicRepoCount != 1 || stats.TotalProjectCount != 2 {
+		t.Errorf("GetStatistics as administrator = %+v", stats)
+	}
+	stats, _, errs = srv.Client(client.WithBasicAuth("dev", "Passw0rd")).GetStatistics()
+	noErrors(t, "GetStatistics", errs)
+	if stats.PublicProjectCount != 1 || stats.PrivateProjectCount != 0 || stats.TotalProjectCount != 0 {
+		t.Errorf("GetStatistics as a user = %+v", stats)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", true)
+	s := projects.NewProjectService(srv.Client())
+
+	srv.Inject(harbortest.Fault{Path: "/api/projects", Status: http.StatusServiceUnavailable, Times: 1})
+	var errResp *client.ErrorResponse
+	if _, _, errs := s.Get(p.ProjectID); len(errs) == 0 || !errors.As(errs[0], &errResp) || errResp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Get with an injected 503: %v, want a server error", errs)
+	}
+	_, _, errs := s.Get(p.ProjectID)
+	noErrors(t, "Get once the fault is spent", errs)
+
+	srv.Inject(harbortest.Fault{Method: "GET", Latency: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, _, errs := s.GetContext(ctx, p.ProjectID); len(errs) == 0 {
+		t.Error("Get with a latency above its timeout succeeded")
+	}
+	srv.ClearFaults()
+
+	srv.Inject(harbortest.Fault{Drop: true})
+	if _, _, errs := s.Get(p.ProjectID); len(errs) == 0 {
+		t.Error("Get on a dropped connection succeeded")
+	}
+	srv.ClearFaults()
+	_, _, errs = s.Get(p.ProjectID)
+	noErrors(t, "Get once the connection is back", errs)
+
+	srv.Inject(harbortest.Fault{Path: "/api/projects", Status: http.StatusServiceUnavailable, Times: 2})
+	c := srv.Client(client.WithRetryPolicy(&client.RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond, RetryableStatus: []int{http.StatusServiceUnavailable}}))
+	_, _, errs = projects.NewProjectService(c).Get(p.ProjectID)
+	noErrors(t, "Get retried past the faults", errs)
+}
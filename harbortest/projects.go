@@ -0,0 +1,296 @@
+package harbortest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+// serveProjects serves /api/projects and its sub-resources.
+func (s *Server) serveProjects(r *request) {
+	if len(r.segments) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			s.listProjects(r)
+		case http.MethodHead:
+			s.checkProject(r)
+		case http.MethodPost:
+			s.createProjectRequest(r)
+		default:
+			r.methodNotAllowed()
+		}
+		return
+	}
+	id, ok := r.intSegment(1)
+	if !ok {
+		return
+	}
+	p, ok := s.projects[id]
+	if !ok || !p.visible(r.user) {
+		r.error(http.StatusNotFound, fmt.Sprintf("project %d not found", id))
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && !p.manageable(r.user) {
+		r.error(http.StatusForbidden, "forbidden")
+		return
+	}
+	if len(r.segments) == 2 {
+		switch r.Method {
+		case http.MethodGet:
+			r.json(http.StatusOK, p.view())
+		case http.MethodPut:
+			var update projects.Project
+			if !r.decode(&update) {
+				return
+			}
+			for k, v := range update.Metadata {
+				p.Metadata[k] = v
+			}
+			p.UpdateTime = now()
+			r.ok()
+		case http.MethodDelete:
+			if len(p.repos) != 0 {
+				r.error(http.StatusPreconditionFailed, "the project contains repositories, can not be deleted")
+				return
+			}
+			delete(s.projects, id)
+			r.ok()
+		default:
+			r.methodNotAllowed()
+		}
+		return
+	}
+	switch r.segments[2] {
+	case "metadatas":
+		s.serveMetadata(r, p)
+	case "members":
+		s.serveMembers(r, p)
+	case "logs":
+		s.listLogs(r, p)
+	default:
+		r.error(http.StatusNotFound, "not found")
+	}
+}
+
+func (s *Server) listProjects(r *request) {
+	query := r.URL.Query()
+	var list []projects.Project
+	for _, p := range s.projects {
+		if !p.visible(r.user) {
+			continue
+		}
+		if name := query.Get("name"); name != "" && !strings.Contains(p.Name, name) {
+			continue
+		}
+		if public := query.Get("public"); public != "" && strconv.FormatBool(p.public()) != public {
+			continue
+		}
+		if owner := query.Get("owner"); owner != "" && p.OwnerName != owner {
+			continue
+		}
+		list = append(list, p.view())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	lo, hi := r.page(len(list))
+	r.json(http.StatusOK, append([]projects.Project{}, list[lo:hi]...))
+}
+
+func (s *Server) checkProject(r *request) {
+	if s.projectByName(r.URL.Query().Get("project_name")) == nil {
+		r.w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	r.ok()
+}
+
+func (s *Server) createProjectRequest(r *request) {
+	var req projects.ProjectRequest
+	if !r.decode(&req) {
+		return
+	}
+	if req.Name == "" {
+		r.error(http.StatusBadRequest, "project name is required")
+		return
+	}
+	if s.projectByName(req.Name) != nil {
+		r.error(http.StatusConflict, "project "+req.Name+" already exists")
+		return
+	}
+	metadata := map[string]string{}
+	if req.Public != nil {
+		metadata["public"] = strconv.FormatBool(*req.Public == 1)
+	}
+	for k, v := range req.Metadata {
+		metadata[k] = v
+	}
+	p := s.createProject(req.Name, metadata, r.user)
+	r.created(fmt.Sprintf("%sprojects/%d", apiRoot, p.ProjectID))
+}
+
+// serveMetadata serves /api/projects/{id}/metadatas.
+func (s *Server) serveMetadata(r *request, p *project) {
+	if len(r.segments) == 3 {
+		switch r.Method {
+		case http.MethodGet:
+			r.json(http.StatusOK, p.view().Metadata)
+		case http.MethodPost:
+			var metadata map[string]string
+			if !r.decode(&metadata) {
+				return
+			}
+			for k, v := range metadata {
+				p.Metadata[k] = v
+			}
+			r.ok()
+		default:
+			r.methodNotAllowed()
+		}
+		return
+	}
+	name := r.segments[3]
+	value, ok := p.Metadata[name]
+	if !ok {
+		r.error(http.StatusNotFound, "metadata "+name+" not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		r.json(http.StatusOK, map[string]string{name: value})
+	case http.MethodPut:
+		// The value is optional, sent as {"name": "value"}
+		var metadata map[string]string
+		if r.ContentLength != 0 && !r.decode(&metadata) {
+			return
+		}
+		if v, ok := metadata[name]; ok {
+			p.Metadata[name] = v
+		}
+		r.ok()
+	case http.MethodDelete:
+		delete(p.Metadata, name)
+		r.ok()
+	default:
+		r.methodNotAllowed()
+	}
+}
+
+// member is a member of a project, listed as a user with its role.
+func (s *Server) member(p *project, uid int) user.User {
+	u := *s.users[uid]
+	u.Password = ""
+	u.Role = p.members[uid]
+	u.Rolename = roles[u.Role].Name
+	return u
+}
+
+// serveMembers serves /api/projects/{id}/members.
+func (s *Server) serveMembers(r *request, p *project) {
+	if len(r.segments) == 3 {
+		switch r.Method {
+		case http.MethodGet:
+			members := []user.User{}
+			for uid := range p.members {
+				members = append(members, s.member(p, uid))
+			}
+			sort.Slice(members, func(i, j int) bool { return members[i].UserID < members[j].UserID })
+			r.json(http.StatusOK, members)
+		case http.MethodPost:
+			var req projects.MemberRequest
+			if !r.decode(&req) {
+				return
+			}
+			u := s.userByName(req.UserName)
+			if u == nil {
+				r.error(http.StatusNotFound, "user "+req.UserName+" not found")
+				return
+			}
+			if _, ok := p.members[u.UserID]; ok {
+				r.error(http.StatusConflict, "user "+req.UserName+" is already a member")
+				return
+			}
+			role, ok := memberRole(r, req)
+			if !ok {
+				return
+			}
+			p.members[u.UserID] = role
+			r.created(fmt.Sprintf("%sprojects/%d/members/%d", apiRoot, p.ProjectID, u.UserID))
+		default:
+			r.methodNotAllowed()
+		}
+		return
+	}
+	uid, ok := r.intSegment(3)
+	if !ok {
+		return
+	}
+	role, ok := p.members[int(uid)]
+	if !ok {
+		r.error(http.StatusNotFound, fmt.Sprintf("user %d is not a member", uid))
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		r.json(http.StatusOK, roles[role])
+	case http.MethodPut:
+		var req projects.MemberRequest
+		if !r.decode(&req) {
+			return
+		}
+		if role, ok := memberRole(r, req); ok {
+			p.members[int(uid)] = role
+			r.ok()
+		}
+	case http.MethodDelete:
+		delete(p.members, int(uid))
+		r.ok()
+	default:
+		r.methodNotAllowed()
+	}
+}
+
+// memberRole returns the role of a member request, answering 400 if it is
+// not a known one.
+func memberRole(r *request, req projects.MemberRequest) (int, bool) {
+	if len(req.Roles) != 1 {
+		r.error(http.StatusBadRequest, "exactly one role is required")
+		return 0, false
+	}
+	if _, ok := roles[req.Roles[0]]; !ok {
+		r.error(http.StatusBadRequest, fmt.Sprintf("invalid role %d", req.Roles[0]))
+		return 0, false
+	}
+	return req.Roles[0], true
+}
+
+// listLogs serves /api/projects/{id}/logs, from the most recent log.
+func (s *Server) listLogs(r *request, p *project) {
+	if r.Method != http.MethodGet {
+		r.methodNotAllowed()
+		return
+	}
+	query := r.URL.Query()
+	operations := map[string]bool{}
+	for _, op := range query["operation"] {
+		operations[op] = true
+	}
+	logs := []projects.AccessLog{}
+	for i := len(s.logs) - 1; i >= 0; i-- {
+		l := s.logs[i]
+		switch {
+		case l.ProjectID != p.ProjectID,
+			query.Get("username") != "" && l.Username != query.Get("username"),
+			query.Get("repository") != "" && !strings.Contains(l.RepoName, query.Get("repository")),
+			query.Get("tag") != "" && l.RepoTag != query.Get("tag"),
+			len(operations) != 0 && !operations[l.Operation]:
+			continue
+		}
+		logs = append(logs, l)
+	}
+	lo, hi := r.page(len(logs))
+	r.json(http.StatusOK, logs[lo:hi])
+}
@@ -0,0 +1,283 @@
+package harbortest
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codingXiang/go-harbor-client/module/manifest"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// Scan statuses of Harbor 1.x
+const (
+	scanPending  repositories.ScanStatus = "pending"
+	scanRunning  repositories.ScanStatus = "running"
+	scanFinished repositories.ScanStatus = "finished"
+)
+
+// tagPath matches the tag routes below /api/repositories/, the repository
+// name having any number of segments.
+var tagPath = regexp.MustCompile(`^(.+)/tags(?:/([^/]+)(/manifest|/scan|/vulnerability/details)?)?$`)
+
+// serveRepositories serves /api/repositories and its sub-resources.
+func (s *Server) serveRepositories(r *request) {
+	rest := strings.Join(r.segments[1:], "/")
+	switch {
+	case rest == "":
+		if r.Method != http.MethodGet {
+			r.methodNotAllowed()
+			return
+		}
+		s.listRepositories(r)
+		return
+	case rest == "top":
+		if r.Method != http.MethodGet {
+			r.methodNotAllowed()
+			return
+		}
+		s.topRepositories(r)
+		return
+	}
+
+	var (
+		name, tagName, action string
+		signatures            bool
+	)
+	if m := tagPath.FindStringSubmatch(rest); m != nil {
+		name, tagName, action = m[1], m[2], m[3]
+	} else if strings.HasSuffix(rest, "/signatures") {
+		name, signatures = strings.TrimSuffix(rest, "/signatures"), true
+	} else {
+		name = rest
+	}
+	repo, ok := s.repos[name]
+	if !ok || !repo.project.visible(r.user) {
+		r.error(http.StatusNotFound, "repository "+name+" not found")
+		return
+	}
+	if r.Method != http.MethodGet && !repo.project.manageable(r.user) {
+		r.error(http.StatusForbidden, "forbidden")
+		return
+	}
+	switch {
+	case signatures:
+		s.listSignatures(r, repo)
+	case strings.HasSuffix(rest, "/tags"):
+		s.listTags(r, repo)
+	case tagName != "":
+		t, ok := repo.tags[tagName]
+		if !ok {
+			r.error(http.StatusNotFound, "tag "+tagName+" not found")
+			return
+		}
+		s.serveTag(r, repo, t, action)
+	default:
+		s.serveRepository(r, repo)
+	}
+}
+
+func (s *Server) listRepositories(r *request) {
+	query := r.URL.Query()
+	projectID, err := strconv.ParseInt(query.Get("project_id"), 10, 64)
+	if err != nil {
+		r.error(http.StatusBadRequest, "project_id is required")
+		return
+	}
+	p, ok := s.projects[projectID]
+	if !ok || !p.visible(r.user) {
+		r.error(http.StatusNotFound, fmt.Sprintf("project %d not found", projectID))
+		return
+	}
+	var list []repositories.RepoRecord
+	for _, repo := range p.repos {
+		if q := query.Get("q"); q != "" && !strings.Contains(repo.Name, q) {
+			continue
+		}
+		list = append(list, repo.RepoRecord)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	lo, hi := r.page(len(list))
+	r.json(http.StatusOK, append([]repositories.RepoRecord{}, list[lo:hi]...))
+}
+
+func (s *Server) topRepositories(r *request) {
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 1 {
+		count = 10
+	}
+	top := []repositories.RepoResp{}
+	for _, repo := range s.repos {
+		if repo.project.public() {
+			top = append(top, repositories.RepoResp{
+				ID:           repo.RepositoryID,
+				Name:         repo.Name,
+				ProjectID:    repo.ProjectID,
+				Description:  repo.Description,
+				PullCount:    repo.PullCount,
+				StarCount:    repo.StarCount,
+				TagsCount:    int64(len(repo.tags)),
+				CreationTime: repo.CreationTime,
+				UpdateTime:   repo.UpdateTime,
+			})
+		}
+	}
+	sort.Slice(top, func(i, j int) bool {
+		if top[i].PullCount != top[j].PullCount {
+			return top[i].PullCount > top[j].PullCount
+		}
+		return top[i].Name < top[j].Name
+	})
+	if len(top) > count {
+		top = top[:count]
+	}
+	r.json(http.StatusOK, top)
+}
+
+// serveRepository serves /api/repositories/{name}.
+func (s *Server) serveRepository(r *request, repo *repository) {
+	switch r.Method {
+	case http.MethodPut:
+		var d repositories.RepositoryDescription
+		if !r.decode(&d) {
+			return
+		}
+		repo.Description = d.Description
+		repo.UpdateTime = now()
+		r.ok()
+	case http.MethodDelete:
+		for name := range repo.tags {
+			s.log(repo.project, repo.Name, name, r.user.Username, "delete")
+		}
+		delete(s.repos, repo.Name)
+		delete(repo.project.repos, repo.Name)
+		r.ok()
+	default:
+		r.methodNotAllowed()
+	}
+}
+
+func (s *Server) listSignatures(r *request, repo *repository) {
+	if r.Method != http.MethodGet {
+		r.methodNotAllowed()
+		return
+	}
+	signatures := []repositories.Signature{}
+	for _, t := range repo.sortedTags() {
+		if t.Signature != nil {
+			signatures = append(signatures, *t.Signature)
+		}
+	}
+	r.json(http.StatusOK, signatures)
+}
+
+func (s *Server) listTags(r *request, repo *repository) {
+	if r.Method != http.MethodGet {
+		r.methodNotAllowed()
+		return
+	}
+	tags := []repositories.TagResp{}
+	for _, t := range repo.sortedTags() {
+		s.advanceScan(t)
+		tags = append(tags, t.TagResp)
+	}
+	r.json(http.StatusOK, tags)
+}
+
+// serveTag serves /api/repositories/{name}/tags/{tag} and its actions.
+func (s *Server) serveTag(r *request, repo *repository, t *tag, action string) {
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		s.advanceScan(t)
+		r.json(http.StatusOK, t.TagResp)
+	case action == "" && r.Method == http.MethodDelete:
+		delete(repo.tags, t.Name)
+		s.log(repo.project, repo.Name, t.Name, r.user.Username, "delete")
+		r.ok()
+	case action == "/manifest" && r.Method == http.MethodGet:
+		repo.PullCount++
+		s.log(repo.project, repo.Name, t.Name, r.user.Username, "pull")
+		r.json(http.StatusOK, imageManifest(t))
+	case action == "/scan" && r.Method == http.MethodPost:
+		if status := t.scanStatus(); status == scanPending || status == scanRunning {
+			r.error(http.StatusConflict, "the image is being scanned")
+			return
+		}
+		t.ScanOverview = &repositories.ImgScanOverview{
+			Digest:       t.Digest,
+			Status:       scanPending,
+			JobID:        s.id(),
+			CreationTime: now(),
+			UpdateTime:   now(),
+		}
+		r.ok()
+	case action == "/vulnerability/details" && r.Method == http.MethodGet:
+		items := t.found
+		if items == nil {
+			items = []repositories.VulnerabilityItem{}
+		}
+		r.json(http.StatusOK, items)
+	default:
+		r.methodNotAllowed()
+	}
+}
+
+func (t *tag) scanStatus() repositories.ScanStatus {
+	if t.ScanOverview == nil {
+		return ""
+	}
+	return t.ScanOverview.Status
+}
+
+// advanceScan moves the scan of the tag to its next status every time the
+// tag is read: pending, running, then finished with the vulnerabilities set
+// by SetVulnerabilities.
+func (s *Server) advanceScan(t *tag) {
+	o := t.ScanOverview
+	switch t.scanStatus() {
+	case scanPending:
+		o.Status = scanRunning
+	case scanRunning:
+		t.found = append([]repositories.VulnerabilityItem(nil), t.vulnerabilities...)
+		summary, highest := repositories.Summarize(t.found)
+		o.Status = scanFinished
+		o.Sev = highest
+		o.CompOverview = &repositories.ComponentsOverview{Total: len(t.found)}
+		for _, sev := range repositories.Severities() {
+			if count := summary[sev]; count != 0 {
+				o.CompOverview.Summary = append(o.CompOverview.Summary, &repositories.ComponentsOverviewEntry{Sev: sev, Count: count})
+			}
+		}
+	default:
+		return
+	}
+	o.UpdateTime = now()
+}
+
+// imageManifest returns a schema 2 manifest of the tag, with its image
+// config.
+func imageManifest(t *tag) repositories.ManifestResp {
+	config := manifest.ImageConfig{
+		Architecture: t.Architecture,
+		OS:           t.OS,
+		Created:      &t.Created,
+		Config:       manifest.ContainerConfig{Env: []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}},
+		RootFS:       manifest.RootFS{Type: "layers", DiffIDs: []string{t.Digest}},
+	}
+	if t.Config != nil {
+		config.Config.Labels = t.Config.Labels
+	}
+	data, _ := json.Marshal(config)
+	m := manifest.Image{
+		SchemaVersion: 2,
+		MediaType:     manifest.MediaTypeDockerSchema2,
+		Config:        manifest.Descriptor{MediaType: manifest.MediaTypeDockerConfig, Size: int64(len(data)), Digest: fmt.Sprintf("sha256:%x", sha256.Sum256(data))},
+		Layers:        []manifest.Descriptor{{MediaType: manifest.MediaTypeDockerLayer, Size: t.Size, Digest: t.Digest}},
+	}
+	return repositories.ManifestResp{Manifest: m, Config: string(data)}
+}
@@ -0,0 +1,299 @@
+// Package harbortest provides an in-memory Harbor server for the tests of
+// the consumers of this library.
+//
+// The server speaks the Harbor 1.x API of the route table of the client:
+// projects with their members, metadata and access logs, repositories and
+// their tags, users, statistics and image scans. It keeps its state in
+// memory, can be seeded through its methods, and can inject faults to test
+// how errors are handled:
+//
+//	srv := harbortest.Start(t)
+//	srv.AddProject("library", true)
+//	srv.PushImage("library/nginx", "1.19")
+//	c := srv.Client()
+//	tags, _, errs := repositories.NewRepositoriesService(c).ListTags("library", "nginx")
+package harbortest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+// Credentials of the administrator every server starts with.
+const (
+	AdminUsername = "admin"
+	AdminPassword = "Harbor12345"
+)
+
+// apiRoot is the path prefix of the Harbor 1.x API.
+const apiRoot = "/api/"
+
+// Server is an in-memory Harbor server. Requests authenticate with basic
+// auth, as one of the users of the server.
+type Server struct {
+	// Base URL of the server, e.g. http://127.0.0.1:34567
+	URL string
+
+	server *httptest.Server
+
+	mu       sync.Mutex
+	nextID   int64
+	users    map[int]*user.User
+	projects map[int64]*project
+	repos    map[string]*repository
+	logs     []projects.AccessLog
+	faults   []*Fault
+}
+
+// NewServer starts a server, to be closed by the caller.
+func NewServer() *Server {
+	s := &Server{
+		users:    map[int]*user.User{},
+		projects: map[int64]*project{},
+		repos:    map[string]*repository{},
+	}
+	s.AddUser(AdminUsername, AdminPassword, true)
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	return s
+}
+
+// Start starts a server closed at the end of the test.
+func Start(tb testing.TB) *Server {
+	s := NewServer()
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Close shuts the server down.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a client of the server, authenticated as the administrator
+// unless opts say otherwise. It panics if opts are invalid.
+func (s *Server) Client(opts ...client.Option) *client.Client {
+	opts = append([]client.Option{
+		client.WithBaseURL(s.URL),
+		client.WithAPIVersion(client.APIVersion1),
+		client.WithBasicAuth(AdminUsername, AdminPassword),
+	}, opts...)
+	c, err := client.New(opts...)
+	if err != nil {
+		panic("harbortest: " + err.Error())
+	}
+	return c
+}
+
+// ServeHTTP serves the Harbor API.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.fault(w, r) {
+		return
+	}
+	if !strings.HasPrefix(r.URL.Path, apiRoot) {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, apiRoot), "/"), "/")
+	if segments[0] == "systeminfo" && r.Method == http.MethodGet {
+		writeJSON(w, http.StatusOK, client.SystemInfo{HarborVersion: "v1.10.0-harbortest", AuthMode: "db_auth", ProjectCreationRestriction: "everyone"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	current := s.authenticate(r)
+	if current == nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="harbortest"`)
+		writeError(w, http.StatusUnauthorized, "UnAuthorize")
+		return
+	}
+	req := &request{Request: r, w: w, user: current, segments: segments}
+	switch segments[0] {
+	case "projects":
+		s.serveProjects(req)
+	case "repositories":
+		s.serveRepositories(req)
+	case "users":
+		s.serveUsers(req)
+	case "statistics":
+		s.serveStatistics(req)
+	default:
+		req.error(http.StatusNotFound, "not found")
+	}
+}
+
+// authenticate returns the user of the basic auth credentials of r, if
+// valid.
+func (s *Server) authenticate(r *http.Request) *user.User {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+	u := s.userByName(username)
+	if u == nil || u.Password != password {
+		return nil
+	}
+	return u
+}
+
+// now returns the current time, as precise as Harbor records it.
+func now() time.Time {
+	return time.Now().UTC().Truncate(time.Second)
+}
+
+// id returns a new ID, unique across the resources of the server.
+func (s *Server) id() int64 {
+	s.nextID++
+	return s.nextID
+}
+
+// request is a request being served, s.mu being held.
+type request struct {
+	*http.Request
+	w        http.ResponseWriter
+	user     *user.User
+	segments []string
+}
+
+// admin reports whether the user is a system administrator, answering 403
+// otherwise.
+func (r *request) admin() bool {
+	if !r.user.HasAdminRole {
+		r.error(http.StatusForbidden, "forbidden")
+		return false
+	}
+	return true
+}
+
+// decode decodes the JSON body into v, answering 400 if it is invalid.
+func (r *request) decode(v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		r.error(http.StatusBadRequest, "invalid JSON body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+// intSegment parses the segment i of the path, answering 400 if it is not
+// a number.
+func (r *request) intSegment(i int) (int64, bool) {
+	id, err := strconv.ParseInt(r.segments[i], 10, 64)
+	if err != nil {
+		r.error(http.StatusBadRequest, "invalid ID "+r.segments[i])
+		return 0, false
+	}
+	return id, true
+}
+
+func (r *request) json(status int, v interface{}) {
+	writeJSON(r.w, status, v)
+}
+
+func (r *request) error(status int, msg string) {
+	writeError(r.w, status, msg)
+}
+
+// created answers 201 with the Location of the resource created.
+func (r *request) created(location string) {
+	r.w.Header().Set("Location", location)
+	r.w.WriteHeader(http.StatusCreated)
+}
+
+func (r *request) ok() {
+	r.w.WriteHeader(http.StatusOK)
+}
+
+func (r *request) methodNotAllowed() {
+	r.error(http.StatusMethodNotAllowed, "method not allowed")
+}
+
+// page returns the bounds of the page requested among n items, and sets the
+// X-Total-Count and Link headers the way Harbor does.
+func (r *request) page(n int) (int, int) {
+	query := r.URL.Query()
+	page, _ := strconv.Atoi(query.Get("page"))
+	size, _ := strconv.Atoi(query.Get("page_size"))
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = 10
+	}
+	r.w.Header().Set("X-Total-Count", strconv.Itoa(n))
+	var links []string
+	link := func(page int, rel string) {
+		u := url.URL{Path: r.URL.Path}
+		q := r.URL.Query()
+		q.Set("page", strconv.Itoa(page))
+		q.Set("page_size", strconv.Itoa(size))
+		u.RawQuery = q.Encode()
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, u.String(), rel))
+	}
+	if page > 1 {
+		link(page-1, "prev")
+	}
+	if page*size < n {
+		link(page+1, "next")
+	}
+	if len(links) != 0 {
+		r.w.Header().Set("Link", strings.Join(links, " , "))
+	}
+	lo, hi := (page-1)*size, page*size
+	if lo > n {
+		lo = n
+	}
+	if hi > n {
+		hi = n
+	}
+	return lo, hi
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError answers with an error body in the format of Harbor 1.x.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]interface{}{"code": status, "message": msg})
+}
+
+// Statistics
+
+func (s *Server) serveStatistics(r *request) {
+	if r.Method != http.MethodGet || len(r.segments) != 1 {
+		r.methodNotAllowed()
+		return
+	}
+	var stats client.StatisticMap
+	for _, p := range s.projects {
+		repos := len(p.repos)
+		switch {
+		case p.public():
+			stats.PublicProjectCount++
+			stats.PublicRepoCount += repos
+		case p.role(r.user) != 0:
+			stats.PrivateProjectCount++
+			stats.PrivateRepoCount += repos
+		}
+		if r.user.HasAdminRole {
+			stats.TotalProjectCount++
+			stats.TotalRepoCount += repos
+		}
+	}
+	r.json(http.StatusOK, stats)
+}
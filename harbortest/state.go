@@ -0,0 +1,264 @@
+package harbortest
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+// Roles of the members of a project
+const (
+	RoleProjectAdmin = 1
+	RoleDeveloper    = 2
+	RoleGuest        = 3
+	RoleMaintainer   = 4
+)
+
+var roles = map[int]projects.Role{
+	RoleProjectAdmin: {RoleID: RoleProjectAdmin, RoleCode: "MDRWS", Name: "projectAdmin"},
+	RoleDeveloper:    {RoleID: RoleDeveloper, RoleCode: "RWS", Name: "developer"},
+	RoleGuest:        {RoleID: RoleGuest, RoleCode: "RS", Name: "guest"},
+	RoleMaintainer:   {RoleID: RoleMaintainer, RoleCode: "DRWS", Name: "maintainer"},
+}
+
+type project struct {
+	projects.Project
+	// Role per user ID
+	members map[int]int
+	repos   map[string]*repository
+}
+
+func (p *project) public() bool {
+	return p.Metadata["public"] == "true"
+}
+
+// role returns the role of u in the project, 0 if it is not a member.
+func (p *project) role(u *user.User) int {
+	return p.members[u.UserID]
+}
+
+// visible reports whether u can see the project.
+func (p *project) visible(u *user.User) bool {
+	return p.public() || u.HasAdminRole || p.role(u) != 0
+}
+
+// manageable reports whether u can change the project.
+func (p *project) manageable(u *user.User) bool {
+	return u.HasAdminRole || p.role(u) == RoleProjectAdmin
+}
+
+func (p *project) view() projects.Project {
+	v := p.Project
+	v.RepoCount = int64(len(p.repos))
+	v.Metadata = map[string]string{}
+	for k, value := range p.Metadata {
+		v.Metadata[k] = value
+	}
+	return v
+}
+
+type repository struct {
+	repositories.RepoRecord
+	project *project
+	tags    map[string]*tag
+}
+
+type tag struct {
+	repositories.TagResp
+	// Vulnerabilities found by the next scans
+	vulnerabilities []repositories.VulnerabilityItem
+	// Vulnerabilities found by the last scan
+	found []repositories.VulnerabilityItem
+}
+
+// sortedTags returns the tags of the repository by name.
+func (r *repository) sortedTags() []*tag {
+	tags := make([]*tag, 0, len(r.tags))
+	for _, t := range r.tags {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+func (s *Server) userByName(username string) *user.User {
+	for _, u := range s.users {
+		if u.Username == username {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) projectByName(name string) *project {
+	for _, p := range s.projects {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// AddUser adds a user, a system administrator if admin is set.
+func (s *Server) AddUser(username, password string, admin bool) user.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := &user.User{
+		Username:     username,
+		Password:     password,
+		Email:        username + "@harbortest.local",
+		Realname:     username,
+		HasAdminRole: admin,
+	}
+	s.createUser(u)
+	return *u
+}
+
+func (s *Server) createUser(u *user.User) {
+	u.UserID = int(s.id())
+	u.CreationTime = now()
+	u.UpdateTime = u.CreationTime
+	s.users[u.UserID] = u
+}
+
+// AddProject adds a project owned by the administrator.
+func (s *Server) AddProject(name string, public bool) projects.Project {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createProject(name, map[string]string{"public": fmt.Sprint(public)}, s.userByName(AdminUsername)).view()
+}
+
+func (s *Server) createProject(name string, metadata map[string]string, owner *user.User) *project {
+	p := &project{
+		Project: projects.Project{
+			ProjectID:    s.id(),
+			Name:         name,
+			OwnerID:      owner.UserID,
+			OwnerName:    owner.Username,
+			CreationTime: now(),
+			Metadata:     map[string]string{"public": "false"},
+		},
+		members: map[int]int{owner.UserID: RoleProjectAdmin},
+		repos:   map[string]*repository{},
+	}
+	p.UpdateTime = p.CreationTime
+	for k, v := range metadata {
+		p.Metadata[k] = v
+	}
+	s.projects[p.ProjectID] = p
+	return p
+}
+
+// AddMember adds a user to a project with role, e.g. RoleDeveloper.
+func (s *Server) AddMember(projectID int64, userID int, role int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.projects[projectID]
+	if !ok {
+		return fmt.Errorf("harbortest: no project %d", projectID)
+	}
+	if _, ok := s.users[userID]; !ok {
+		return fmt.Errorf("harbortest: no user %d", userID)
+	}
+	p.members[userID] = role
+	return nil
+}
+
+// PushImage adds a tag to a repository, e.g. library/nginx, creating the
+// repository if needed. The project of the repository must exist.
+func (s *Server) PushImage(name, tagName string) (repositories.TagResp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return repositories.TagResp{}, fmt.Errorf("harbortest: repository %q without project", name)
+	}
+	p := s.projectByName(name[:i])
+	if p == nil {
+		return repositories.TagResp{}, fmt.Errorf("harbortest: no project %s", name[:i])
+	}
+	repo, ok := s.repos[name]
+	if !ok {
+		repo = &repository{
+			RepoRecord: repositories.RepoRecord{
+				RepositoryID: s.id(),
+				Name:         name,
+				ProjectID:    p.ProjectID,
+				CreationTime: now(),
+			},
+			project: p,
+			tags:    map[string]*tag{},
+		}
+		s.repos[name] = repo
+		p.repos[name] = repo
+	}
+	repo.UpdateTime = now()
+	t := &tag{}
+	t.Name = tagName
+	t.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", name, tagName, s.id()))))
+	t.Size = 1 << 20
+	t.Architecture = "amd64"
+	t.OS = "linux"
+	t.DockerVersion = "19.03.12"
+	t.Created = now()
+	if old, ok := repo.tags[tagName]; ok {
+		t.vulnerabilities = old.vulnerabilities
+	}
+	repo.tags[tagName] = t
+	s.log(p, name, tagName, AdminUsername, "push")
+	return t.TagResp, nil
+}
+
+// SetVulnerabilities sets the vulnerabilities the next scans of a tag find.
+func (s *Server) SetVulnerabilities(repository, tagName string, items []repositories.VulnerabilityItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.tag(repository, tagName)
+	if err != nil {
+		return err
+	}
+	t.vulnerabilities = append([]repositories.VulnerabilityItem(nil), items...)
+	return nil
+}
+
+// SignTag marks a tag as signed.
+func (s *Server) SignTag(repository, tagName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, err := s.tag(repository, tagName)
+	if err != nil {
+		return err
+	}
+	t.Signature = &repositories.Signature{Tag: tagName, Hashes: map[string][]byte{"sha256": []byte(t.Digest)}}
+	return nil
+}
+
+func (s *Server) tag(repository, tagName string) (*tag, error) {
+	repo, ok := s.repos[repository]
+	if !ok {
+		return nil, fmt.Errorf("harbortest: no repository %s", repository)
+	}
+	t, ok := repo.tags[tagName]
+	if !ok {
+		return nil, fmt.Errorf("harbortest: no tag %s:%s", repository, tagName)
+	}
+	return t, nil
+}
+
+// log records an access log of a project.
+func (s *Server) log(p *project, repository, tagName, username, operation string) {
+	s.logs = append(s.logs, projects.AccessLog{
+		LogID:     int(s.id()),
+		Username:  username,
+		ProjectID: p.ProjectID,
+		RepoName:  repository,
+		RepoTag:   tagName,
+		Operation: operation,
+		OpTime:    now(),
+	})
+}
@@ -0,0 +1,189 @@
+package harbortest
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+// view returns the user without its password, as Harbor lists it.
+func view(u *user.User) user.User {
+	v := *u
+	v.Password = ""
+	return v
+}
+
+// serveUsers serves /api/users and its sub-resources.
+func (s *Server) serveUsers(r *request) {
+	if len(r.segments) == 1 {
+		switch r.Method {
+		case http.MethodGet:
+			if !r.admin() {
+				return
+			}
+			username := r.URL.Query().Get("username")
+			list := []user.User{}
+			for _, u := range s.users {
+				if username == "" || u.Username == username {
+					list = append(list, view(u))
+				}
+			}
+			sort.Slice(list, func(i, j int) bool { return list[i].UserID < list[j].UserID })
+			lo, hi := r.page(len(list))
+			r.json(http.StatusOK, list[lo:hi])
+		case http.MethodPost:
+			s.createUserRequest(r)
+		default:
+			r.methodNotAllowed()
+		}
+		return
+	}
+	if r.segments[1] == "current" {
+		if r.Method != http.MethodGet {
+			r.methodNotAllowed()
+			return
+		}
+		r.json(http.StatusOK, view(r.user))
+		return
+	}
+	id, ok := r.intSegment(1)
+	if !ok {
+		return
+	}
+	u, ok := s.users[int(id)]
+	self := ok && u.UserID == r.user.UserID
+	if !self && !r.admin() {
+		return
+	}
+	if !ok {
+		r.error(http.StatusNotFound, fmt.Sprintf("user %d not found", id))
+		return
+	}
+	if len(r.segments) == 3 {
+		switch {
+		case r.segments[2] == "password" && r.Method == http.MethodPut:
+			s.changePassword(r, u, self)
+		case r.segments[2] == "sysadmin" && r.Method == http.MethodPut:
+			s.changeSysadmin(r, u)
+		default:
+			r.error(http.StatusNotFound, "not found")
+		}
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		r.json(http.StatusOK, view(u))
+	case http.MethodPut, http.MethodPost:
+		// Only the profile of the user can be changed this way
+		var update user.User
+		if !r.decode(&update) {
+			return
+		}
+		if update.Email != "" {
+			if other := s.userByEmail(update.Email); other != nil && other != u {
+				r.error(http.StatusConflict, "email "+update.Email+" is already used")
+				return
+			}
+			u.Email = update.Email
+		}
+		if update.Realname != "" {
+			u.Realname = update.Realname
+		}
+		u.Comment = update.Comment
+		u.UpdateTime = now()
+		r.ok()
+	case http.MethodDelete:
+		if !r.admin() {
+			return
+		}
+		if self {
+			r.error(http.StatusForbidden, "can not delete yourself")
+			return
+		}
+		delete(s.users, u.UserID)
+		for _, p := range s.projects {
+			delete(p.members, u.UserID)
+		}
+		r.ok()
+	default:
+		r.methodNotAllowed()
+	}
+}
+
+func (s *Server) userByEmail(email string) *user.User {
+	for _, u := range s.users {
+		if u.Email == email {
+			return u
+		}
+	}
+	return nil
+}
+
+func (s *Server) createUserRequest(r *request) {
+	if !r.admin() {
+		return
+	}
+	var u user.User
+	if !r.decode(&u) {
+		return
+	}
+	if u.Username == "" || u.Password == "" {
+		r.error(http.StatusBadRequest, "username and password are required")
+		return
+	}
+	if s.userByName(u.Username) != nil {
+		r.error(http.StatusConflict, "username "+u.Username+" is already used")
+		return
+	}
+	if u.Email != "" && s.userByEmail(u.Email) != nil {
+		r.error(http.StatusConflict, "email "+u.Email+" is already used")
+		return
+	}
+	s.createUser(&u)
+	r.created(fmt.Sprintf("%susers/%d", apiRoot, u.UserID))
+}
+
+// changePassword changes the password of u, the old one being required when
+// users change their own.
+func (s *Server) changePassword(r *request, u *user.User, self bool) {
+	var req user.UpdatePassword
+	if !r.decode(&req) {
+		return
+	}
+	switch {
+	case req.NewPassword == "":
+		r.error(http.StatusBadRequest, "new password is required")
+	case self && req.OldPassword != u.Password:
+		r.error(http.StatusForbidden, "incorrect old_password")
+	default:
+		u.Password = req.NewPassword
+		u.UpdateTime = now()
+		r.ok()
+	}
+}
+
+func (s *Server) changeSysadmin(r *request, u *user.User) {
+	if !r.admin() {
+		return
+	}
+	// Harbor accepts a boolean or, as sent by user.UpdateRole, a number
+	var req struct {
+		HasAdminRole interface{} `json:"has_admin_role"`
+	}
+	if !r.decode(&req) {
+		return
+	}
+	switch v := req.HasAdminRole.(type) {
+	case bool:
+		u.HasAdminRole = v
+	case float64:
+		u.HasAdminRole = v != 0
+	default:
+		r.error(http.StatusBadRequest, "has_admin_role is required")
+		return
+	}
+	u.UpdateTime = now()
+	r.ok()
+}
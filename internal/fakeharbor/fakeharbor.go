@@ -0,0 +1,131 @@
+// Package fakeharbor provides a scripted Harbor server for the tests of the
+// services: it answers every route with a canned response and records the
+// requests, for the tests to check what the services sent.
+//
+//	srv, c := fakeharbor.Start(t, client.APIVersion2, map[string]fakeharbor.Response{
+//		"GET /api/v2.0/robots/5": {Body: `{"id":5,"name":"robot$ci"}`},
+//	})
+//	robot, _, errs := robots.NewRobotsService(c).GetRobot(5)
+//	r, _ := srv.Request("GET /api/v2.0/robots/5")
+//
+// Unlike harbortest, it keeps no state, so that any answer of Harbor,
+// including the ones of Harbor 2.x, can be played.
+package fakeharbor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/client"
+)
+
+// Response is the canned answer to a route.
+type Response struct {
+	// 200 when zero
+	Status int
+	Body   string
+	// Location header, e.g. the path of a created resource
+	Location string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	// Method and escaped path, e.g. GET /api/projects/1
+	Route string
+	Query url.Values
+	Body  string
+}
+
+// Decode decodes the JSON body of the request into v, failing the test if
+// it cannot.
+func (r Request) Decode(tb testing.TB, v interface{}) {
+	tb.Helper()
+	if err := json.Unmarshal([]byte(r.Body), v); err != nil {
+		tb.Fatalf("%s: body %q: %v", r.Route, r.Body, err)
+	}
+}
+
+// Server answers the requests with the responses of its routes, keyed by
+// method and escaped path. A request of another route fails the test.
+type Server struct {
+	// URL of the server, e.g. http://127.0.0.1:1234
+	URL string
+
+	tb testing.TB
+
+	mu       sync.Mutex
+	routes   map[string]Response
+	requests []Request
+}
+
+// Start starts a server answering with routes, closed at the end of the
+// test, and returns it with a client of the API version.
+func Start(tb testing.TB, version client.APIVersion, routes map[string]Response) (*Server, *client.Client) {
+	s := &Server{tb: tb, routes: routes}
+	srv := httptest.NewServer(s)
+	tb.Cleanup(srv.Close)
+	s.URL = srv.URL
+	c, err := client.New(client.WithBaseURL(srv.URL), client.WithAPIVersion(version))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return s, c
+}
+
+// ServeHTTP records the request and answers it with the response of its
+// route.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := ioutil.ReadAll(r.Body)
+	route := r.Method + " " + r.URL.EscapedPath()
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Route: route, Query: r.URL.Query(), Body: string(body)})
+	resp, ok := s.routes[route]
+	s.mu.Unlock()
+	if !ok {
+		s.tb.Errorf("unexpected request %s", route)
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if resp.Location != "" {
+		w.Header().Set("Location", resp.Location)
+	}
+	if resp.Status != 0 {
+		w.WriteHeader(resp.Status)
+	}
+	w.Write([]byte(resp.Body))
+}
+
+// SetRoute changes the response to route.
+func (s *Server) SetRoute(route string, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[route] = resp
+}
+
+// Request returns the last request of route.
+func (s *Server) Request(route string) (Request, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.requests) - 1; i >= 0; i-- {
+		if s.requests[i].Route == route {
+			return s.requests[i], true
+		}
+	}
+	return Request{}, false
+}
+
+// Routes returns the routes requested, in order.
+func (s *Server) Routes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var routes []string
+	for _, r := range s.requests {
+		routes = append(routes, r.Route)
+	}
+	return routes
+}
@@ -0,0 +1,325 @@
+package artifacts
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	repo      = "api.v2.repositories.artifacts."
+	root      = repo + "root"
+	base      = repo + "base"
+	tagRoot   = repo + "tags.root"
+	tagBase   = repo + "tags.base"
+	additions = repo + "additions"
+)
+
+// ArtifactsService handles communication with the artifact related methods
+// of the Harbor 2.x API. Every call returns client.ErrNotSupported against
+// Harbor 1.x, which only knows about tags.
+//
+// Harbor API docs: https://github.com/goharbor/harbor/blob/v2.0.0/api/v2.0/swagger.yaml
+type Service interface {
+	//列出 repository 中的 artifact
+	List(projectName, repoName string, opt *ListArtifactsOptions) ([]Artifact, *gorequest.Response, []error)
+	ListContext(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions) ([]Artifact, *gorequest.Response, []error)
+	//逐頁列出 repository 中的 artifact
+	Iterate(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions) *ArtifactIterator
+	//列出 repository 中所有的 artifact
+	ListAll(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions, concurrency int) ([]Artifact, []error)
+	//取得特定 artifact，reference 可為 tag 或 digest
+	Get(projectName, repoName, reference string, opt *GetArtifactOptions) (Artifact, *gorequest.Response, []error)
+	GetContext(ctx context.Context, projectName, repoName, reference string, opt *GetArtifactOptions) (Artifact, *gorequest.Response, []error)
+	//刪除 artifact
+	Delete(projectName, repoName, reference string) (*gorequest.Response, []error)
+	DeleteContext(ctx context.Context, projectName, repoName, reference string) (*gorequest.Response, []error)
+	//從其他 repository 複製 artifact，from 格式為 project/repository:tag 或 project/repository@digest
+	CopyFrom(projectName, repoName, from string) (*gorequest.Response, []error)
+	CopyFromContext(ctx context.Context, projectName, repoName, from string) (*gorequest.Response, []error)
+	//列出 artifact 的 tag
+	ListTags(projectName, repoName, reference string, opt *ListTagsOptions) ([]Tag, *gorequest.Response, []error)
+	ListTagsContext(ctx context.Context, projectName, repoName, reference string, opt *ListTagsOptions) ([]Tag, *gorequest.Response, []error)
+	//為 artifact 加上 tag
+	CreateTag(projectName, repoName, reference, tag string) (*gorequest.Response, []error)
+	CreateTagContext(ctx context.Context, projectName, repoName, reference, tag string) (*gorequest.Response, []error)
+	//刪除 artifact 的 tag
+	DeleteTag(projectName, repoName, reference, tag string) (*gorequest.Response, []error)
+	DeleteTagContext(ctx context.Context, projectName, repoName, reference, tag string) (*gorequest.Response, []error)
+	//取得 artifact 的 addition 原始內容
+	GetAddition(projectName, repoName, reference, addition string) ([]byte, *gorequest.Response, []error)
+	GetAdditionContext(ctx context.Context, projectName, repoName, reference, addition string) ([]byte, *gorequest.Response, []error)
+	//取得 image 的 build history
+	GetBuildHistory(projectName, repoName, reference string) ([]BuildHistoryEntry, *gorequest.Response, []error)
+	GetBuildHistoryContext(ctx context.Context, projectName, repoName, reference string) ([]BuildHistoryEntry, *gorequest.Response, []error)
+	//取得 chart 的 values.yaml
+	GetValues(projectName, repoName, reference string) (string, *gorequest.Response, []error)
+	GetValuesContext(ctx context.Context, projectName, repoName, reference string) (string, *gorequest.Response, []error)
+	//取得 chart 的 README
+	GetReadme(projectName, repoName, reference string) (string, *gorequest.Response, []error)
+	GetReadmeContext(ctx context.Context, projectName, repoName, reference string) (string, *gorequest.Response, []error)
+	//取得 chart 的相依套件
+	GetDependencies(projectName, repoName, reference string) ([]ChartDependency, *gorequest.Response, []error)
+	GetDependenciesContext(ctx context.Context, projectName, repoName, reference string) ([]ChartDependency, *gorequest.Response, []error)
+}
+
+type ArtifactsService struct {
+	client client2.ClientInterface
+}
+
+func NewArtifactsService(client client2.ClientInterface) Service {
+	return &ArtifactsService{client: client}
+}
+
+func (s *ArtifactsService) getConfigString(key string) string {
+	return s.client.GetConfig().GetString(key)
+}
+
+// path formats the route key with the project and the escaped repository
+// names, Harbor expecting the slashes of nested repositories to be encoded
+// twice.
+func (s *ArtifactsService) path(key, projectName, repoName string, args ...interface{}) string {
+	repoName = url.PathEscape(url.PathEscape(repoName))
+	return fmt.Sprintf(s.getConfigString(key), append([]interface{}{projectName, repoName}, args...)...)
+}
+
+// supported reports whether the server speaks the 2.x API.
+func (s *ArtifactsService) supported(ctx context.Context) []error {
+	v, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return []error{err}
+	}
+	if v != client2.APIVersion2 {
+		return []error{client2.ErrNotSupported}
+	}
+	return nil
+}
+
+// List artifacts of a repository.
+//
+// This endpoint lists the artifacts under the specified project and
+// repository, filtered by opt.
+func (s *ArtifactsService) List(projectName, repoName string, opt *ListArtifactsOptions) ([]Artifact, *gorequest.Response, []error) {
+	return s.ListContext(context.Background(), projectName, repoName, opt)
+}
+
+// ListContext is like List but carries ctx to the request.
+func (s *ArtifactsService) ListContext(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions) ([]Artifact, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	var v []Artifact
+	req := s.client.NewRequest(gorequest.GET, s.path(root, projectName, repoName))
+	if opt != nil {
+		req.Query(*opt)
+		if opt.WithoutTag {
+			req.Query("with_tag=false")
+		}
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Get the specific artifact.
+//
+// This endpoint returns the artifact referenced by a tag or a digest.
+func (s *ArtifactsService) Get(projectName, repoName, reference string, opt *GetArtifactOptions) (Artifact, *gorequest.Response, []error) {
+	return s.GetContext(context.Background(), projectName, repoName, reference, opt)
+}
+
+// GetContext is like Get but carries ctx to the request.
+func (s *ArtifactsService) GetContext(ctx context.Context, projectName, repoName, reference string, opt *GetArtifactOptions) (Artifact, *gorequest.Response, []error) {
+	var v Artifact
+	if errs := s.supported(ctx); errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.GET, s.path(base, projectName, repoName, reference))
+	if opt != nil {
+		req.Query(*opt)
+		if opt.WithoutTag {
+			req.Query("with_tag=false")
+		}
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Delete the specific artifact.
+//
+// This endpoint deletes the artifact referenced by a tag or a digest, along
+// with all of its tags.
+func (s *ArtifactsService) Delete(projectName, repoName, reference string) (*gorequest.Response, []error) {
+	return s.DeleteContext(context.Background(), projectName, repoName, reference)
+}
+
+// DeleteContext is like Delete but carries ctx to the request.
+func (s *ArtifactsService) DeleteContext(ctx context.Context, projectName, repoName, reference string) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.DELETE, s.path(base, projectName, repoName, reference))
+	return s.client.Do(ctx, req, nil)
+}
+
+// Copy an artifact.
+//
+// This endpoint copies the artifact referenced by from, in the form
+// project/repository:tag or project/repository@digest, into the specified
+// repository.
+func (s *ArtifactsService) CopyFrom(projectName, repoName, from string) (*gorequest.Response, []error) {
+	return s.CopyFromContext(context.Background(), projectName, repoName, from)
+}
+
+// CopyFromContext is like CopyFrom but carries ctx to the request.
+func (s *ArtifactsService) CopyFromContext(ctx context.Context, projectName, repoName, from string) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.POST, s.path(root, projectName, repoName)).
+		Param("from", from)
+	return s.client.Do(ctx, req, nil)
+}
+
+// List tags of an artifact.
+//
+// This endpoint lists the tags attached to the artifact referenced by a tag
+// or a digest.
+func (s *ArtifactsService) ListTags(projectName, repoName, reference string, opt *ListTagsOptions) ([]Tag, *gorequest.Response, []error) {
+	return s.ListTagsContext(context.Background(), projectName, repoName, reference, opt)
+}
+
+// ListTagsContext is like ListTags but carries ctx to the request.
+func (s *ArtifactsService) ListTagsContext(ctx context.Context, projectName, repoName, reference string, opt *ListTagsOptions) ([]Tag, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	var v []Tag
+	req := s.client.NewRequest(gorequest.GET, s.path(tagRoot, projectName, repoName, reference))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Create a tag.
+//
+// This endpoint attaches a new tag to the artifact referenced by a tag or a
+// digest.
+func (s *ArtifactsService) CreateTag(projectName, repoName, reference, tag string) (*gorequest.Response, []error) {
+	return s.CreateTagContext(context.Background(), projectName, repoName, reference, tag)
+}
+
+// CreateTagContext is like CreateTag but carries ctx to the request.
+func (s *ArtifactsService) CreateTagContext(ctx context.Context, projectName, repoName, reference, tag string) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.POST, s.path(tagRoot, projectName, repoName, reference)).
+		Send(struct {
+			Name string `json:"name"`
+		}{tag})
+	return s.client.Do(ctx, req, nil)
+}
+
+// Delete a tag.
+//
+// This endpoint detaches the tag from the artifact referenced by a tag or a
+// digest, the artifact itself is kept.
+func (s *ArtifactsService) DeleteTag(projectName, repoName, reference, tag string) (*gorequest.Response, []error) {
+	return s.DeleteTagContext(context.Background(), projectName, repoName, reference, tag)
+}
+
+// DeleteTagContext is like DeleteTag but carries ctx to the request.
+func (s *ArtifactsService) DeleteTagContext(ctx context.Context, projectName, repoName, reference, tag string) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.DELETE, s.path(tagBase, projectName, repoName, reference, tag))
+	return s.client.Do(ctx, req, nil)
+}
+
+// Get the addition of an artifact.
+//
+// This endpoint returns the raw content of an addition, one of the
+// Addition constants, of the artifact referenced by a tag or a digest.
+func (s *ArtifactsService) GetAddition(projectName, repoName, reference, addition string) ([]byte, *gorequest.Response, []error) {
+	return s.GetAdditionContext(context.Background(), projectName, repoName, reference, addition)
+}
+
+// GetAdditionContext is like GetAddition but carries ctx to the request.
+func (s *ArtifactsService) GetAdditionContext(ctx context.Context, projectName, repoName, reference, addition string) ([]byte, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.GET, s.path(additions, projectName, repoName, reference, addition))
+	resp, errs := s.client.Do(ctx, req, nil)
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	body, err := ioutil.ReadAll((*resp).Body)
+	if err != nil {
+		return nil, resp, []error{err}
+	}
+	return body, resp, nil
+}
+
+// GetBuildHistory returns the build history of an image.
+func (s *ArtifactsService) GetBuildHistory(projectName, repoName, reference string) ([]BuildHistoryEntry, *gorequest.Response, []error) {
+	return s.GetBuildHistoryContext(context.Background(), projectName, repoName, reference)
+}
+
+// GetBuildHistoryContext is like GetBuildHistory but carries ctx to the
+// request.
+func (s *ArtifactsService) GetBuildHistoryContext(ctx context.Context, projectName, repoName, reference string) ([]BuildHistoryEntry, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	var v []BuildHistoryEntry
+	req := s.client.NewRequest(gorequest.GET, s.path(additions, projectName, repoName, reference, AdditionBuildHistory))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// GetValues returns the values.yaml of a Helm chart.
+func (s *ArtifactsService) GetValues(projectName, repoName, reference string) (string, *gorequest.Response, []error) {
+	return s.GetValuesContext(context.Background(), projectName, repoName, reference)
+}
+
+// GetValuesContext is like GetValues but carries ctx to the request.
+func (s *ArtifactsService) GetValuesContext(ctx context.Context, projectName, repoName, reference string) (string, *gorequest.Response, []error) {
+	body, resp, errs := s.GetAdditionContext(ctx, projectName, repoName, reference, AdditionValuesYAML)
+	return string(body), resp, errs
+}
+
+// GetReadme returns the README of a Helm chart.
+func (s *ArtifactsService) GetReadme(projectName, repoName, reference string) (string, *gorequest.Response, []error) {
+	return s.GetReadmeContext(context.Background(), projectName, repoName, reference)
+}
+
+// GetReadmeContext is like GetReadme but carries ctx to the request.
+func (s *ArtifactsService) GetReadmeContext(ctx context.Context, projectName, repoName, reference string) (string, *gorequest.Response, []error) {
+	body, resp, errs := s.GetAdditionContext(ctx, projectName, repoName, reference, AdditionReadme)
+	return string(body), resp, errs
+}
+
+// GetDependencies returns the dependencies of a Helm chart.
+func (s *ArtifactsService) GetDependencies(projectName, repoName, reference string) ([]ChartDependency, *gorequest.Response, []error) {
+	return s.GetDependenciesContext(context.Background(), projectName, repoName, reference)
+}
+
+// GetDependenciesContext is like GetDependencies but carries ctx to the
+// request.
+func (s *ArtifactsService) GetDependenciesContext(ctx context.Context, projectName, repoName, reference string) ([]ChartDependency, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	var v []ChartDependency
+	req := s.client.NewRequest(gorequest.GET, s.path(additions, projectName, repoName, reference, AdditionDependencies))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
@@ -0,0 +1,126 @@
+package artifacts
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+)
+
+func newFakeHarbor(t *testing.T, version client2.APIVersion, routes map[string]fakeharbor.Response) (*fakeharbor.Server, Service) {
+	f, c := fakeharbor.Start(t, version, routes)
+	return f, NewArtifactsService(c)
+}
+
+const artifactsPath = "/api/v2.0/projects/library/repositories/tools%252Fnginx/artifacts"
+
+func TestList(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET " + artifactsPath: {Body: `[{"id":1,"type":"IMAGE","digest":"sha256:a","tags":[{"name":"1.19"}]}]`},
+	})
+	opt := &ListArtifactsOptions{
+		ListOptions:      client2.ListOptions{Page: 2, PageSize: 5},
+		Q:                Query{"tags": "~v1", "type": TypeImage}.String(),
+		WithoutTag:       true,
+		WithScanOverview: true,
+	}
+	list, _, errs := s.List("library", "tools/nginx", opt)
+	if len(errs) != 0 || len(list) != 1 || list[0].Digest != "sha256:a" || list[0].Tags[0].Name != "1.19" {
+		t.Fatalf("List = %+v, %v", list, errs)
+	}
+	r, _ := f.Request("GET " + artifactsPath)
+	want := url.Values{
+		"page":               {"2"},
+		"page_size":          {"5"},
+		"q":                  {"tags=~v1,type=IMAGE"},
+		"with_tag":           {"false"},
+		"with_scan_overview": {"true"},
+	}
+	if !reflect.DeepEqual(r.Query, want) {
+		t.Errorf("List query %v, want %v", r.Query, want)
+	}
+}
+
+func TestArtifactAndTags(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET " + artifactsPath + "/1.19":            {Body: `{"id":1,"digest":"sha256:a"}`},
+		"DELETE " + artifactsPath + "/sha256:a":     {},
+		"POST " + artifactsPath:                     {Status: http.StatusCreated},
+		"GET " + artifactsPath + "/sha256:a/tags":   {Body: `[{"name":"1.19"},{"name":"latest"}]`},
+		"POST " + artifactsPath + "/sha256:a/tags":  {Status: http.StatusCreated},
+		"DELETE " + artifactsPath + "/1.19/tags/v1": {},
+	})
+	a, _, errs := s.Get("library", "tools/nginx", "1.19", &GetArtifactOptions{WithSignature: true})
+	if len(errs) != 0 || a.Digest != "sha256:a" {
+		t.Fatalf("Get = %+v, %v", a, errs)
+	}
+	if r, _ := f.Request("GET " + artifactsPath + "/1.19"); r.Query.Get("with_signature") != "true" {
+		t.Errorf("Get query %v", r.Query)
+	}
+	if _, errs := s.Delete("library", "tools/nginx", "sha256:a"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if _, errs := s.CopyFrom("library", "tools/nginx", "library/nginx:1.19"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("POST " + artifactsPath); r.Query.Get("from") != "library/nginx:1.19" {
+		t.Errorf("CopyFrom query %v", r.Query)
+	}
+
+	tags, _, errs := s.ListTags("library", "tools/nginx", "sha256:a", nil)
+	if len(errs) != 0 || len(tags) != 2 {
+		t.Fatalf("ListTags = %+v, %v", tags, errs)
+	}
+	if _, errs := s.CreateTag("library", "tools/nginx", "sha256:a", "stable"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	r, _ := f.Request("POST " + artifactsPath + "/sha256:a/tags")
+	if r.Body != `{"name":"stable"}` {
+		t.Errorf("CreateTag body %s", r.Body)
+	}
+	if _, errs := s.DeleteTag("library", "tools/nginx", "1.19", "v1"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestAdditions(t *testing.T) {
+	_, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET " + artifactsPath + "/0.1.0/additions/values.yaml":  {Body: "replicas: 1\n"},
+		"GET " + artifactsPath + "/0.1.0/additions/dependencies": {Body: `[{"name":"redis","version":"10.5.7","repository":"https://charts.example.com"}]`},
+		"GET " + artifactsPath + "/0.1.0/additions/readme.md":    {Body: "# nginx\n"},
+		"GET " + artifactsPath + "/1.19/additions/build_history": {Body: `[{"created":"2020-06-01T00:00:00Z","created_by":"/bin/sh -c #(nop) CMD [\"nginx\"]","empty_layer":true}]`},
+	})
+	values, _, errs := s.GetValues("library", "tools/nginx", "0.1.0")
+	if len(errs) != 0 || values != "replicas: 1\n" {
+		t.Errorf("GetValues = %q, %v", values, errs)
+	}
+	readme, _, errs := s.GetReadmeContext(context.Background(), "library", "tools/nginx", "0.1.0")
+	if len(errs) != 0 || readme != "# nginx\n" {
+		t.Errorf("GetReadmeContext = %q, %v", readme, errs)
+	}
+	deps, _, errs := s.GetDependencies("library", "tools/nginx", "0.1.0")
+	if want := []ChartDependency{{Name: "redis", Version: "10.5.7", Repository: "https://charts.example.com"}}; len(errs) != 0 || !reflect.DeepEqual(deps, want) {
+		t.Errorf("GetDependencies = %+v, %v", deps, errs)
+	}
+	history, _, errs := s.GetBuildHistory("library", "tools/nginx", "1.19")
+	if len(errs) != 0 || len(history) != 1 || !history[0].EmptyLayer {
+		t.Errorf("GetBuildHistory = %+v, %v", history, errs)
+	}
+}
+
+func TestNotSupportedOnV1(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion1, map[string]fakeharbor.Response{})
+	if _, _, errs := s.List("library", "nginx", nil); len(errs) != 1 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("List on 1.x: %v, want ErrNotSupported", errs)
+	}
+	if _, errs := s.DeleteTag("library", "nginx", "1.19", "1.19"); len(errs) != 1 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("DeleteTag on 1.x: %v, want ErrNotSupported", errs)
+	}
+	if routes := f.Routes(); len(routes) != 0 {
+		t.Errorf("requests sent to 1.x: %v", routes)
+	}
+}
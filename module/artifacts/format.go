@@ -0,0 +1,175 @@
+package artifacts
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+)
+
+// Types of the artifacts stored in Harbor
+const (
+	TypeImage = "IMAGE"
+	TypeChart = "CHART"
+	TypeCNAB  = "CNAB"
+)
+
+// Additions that can be fetched for an artifact, depending on its type
+const (
+	AdditionBuildHistory    = "build_history"
+	AdditionValuesYAML      = "values.yaml"
+	AdditionReadme          = "readme.md"
+	AdditionDependencies    = "dependencies"
+	AdditionVulnerabilities = "vulnerabilities"
+)
+
+// Artifact holds the details of an artifact: an image, an image index, a
+// Helm chart or a CNAB bundle.
+type Artifact struct {
+	ID                int64                          `json:"id"`
+	Type              string                         `json:"type"`
+	MediaType         string                         `json:"media_type"`
+	ManifestMediaType string                         `json:"manifest_media_type"`
+	ProjectID         int64                          `json:"project_id"`
+	RepositoryID      int64                          `json:"repository_id"`
+	Digest            string                         `json:"digest"`
+	Size              int64                          `json:"size"`
+	Icon              string                         `json:"icon"`
+	PushTime          time.Time                      `json:"push_time"`
+	PullTime          time.Time                      `json:"pull_time"`
+	ExtraAttrs        map[string]interface{}         `json:"extra_attrs"`
+	Annotations       map[string]string              `json:"annotations"`
+	References        []Reference                    `json:"references"`
+	Tags              []Tag                          `json:"tags"`
+	AdditionLinks     map[string]AdditionLink        `json:"addition_links"`
+	Labels            []Label                        `json:"labels"`
+	ScanOverview      map[string]NativeReportSummary `json:"scan_overview"`
+}
+
+// Reference links an image index or manifest list to one of its children.
+type Reference struct {
+	ParentID    int64             `json:"parent_id"`
+	ChildID     int64             `json:"child_id"`
+	ChildDigest string            `json:"child_digest"`
+	Platform    *Platform         `json:"platform"`
+	Annotations map[string]string `json:"annotations"`
+	URLs        []string          `json:"urls"`
+}
+
+// Platform describes the platform an image of an index runs on.
+type Platform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// Tag holds the details of a tag attached to an artifact.
+type Tag struct {
+	ID           int64     `json:"id,omitempty"`
+	RepositoryID int64     `json:"repository_id,omitempty"`
+	ArtifactID   int64     `json:"artifact_id,omitempty"`
+	Name         string    `json:"name"`
+	PushTime     time.Time `json:"push_time,omitempty"`
+	PullTime     time.Time `json:"pull_time,omitempty"`
+	Immutable    bool      `json:"immutable,omitempty"`
+	Signed       bool      `json:"signed,omitempty"`
+}
+
+// AdditionLink points to an addition of an artifact.
+type AdditionLink struct {
+	Href     string `json:"href"`
+	Absolute bool   `json:"absolute"`
+}
+
+// Label holds the details of a label attached to an artifact.
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Color       string `json:"color"`
+	Scope       string `json:"scope"`
+	ProjectID   int64  `json:"project_id"`
+}
+
+// NativeReportSummary is the scan overview of an artifact, keyed by the mime
+// type of the report in Artifact.ScanOverview.
+type NativeReportSummary struct {
+	ReportID        string                `json:"report_id"`
+	ScanStatus      string                `json:"scan_status"`
+	Severity        string                `json:"severity"`
+	Duration        int64                 `json:"duration"`
+	Summary         *VulnerabilitySummary `json:"summary"`
+	StartTime       time.Time             `json:"start_time"`
+	EndTime         time.Time             `json:"end_time"`
+	CompletePercent int                   `json:"complete_percent"`
+}
+
+// VulnerabilitySummary counts the vulnerabilities of an artifact.
+type VulnerabilitySummary struct {
+	Total   int            `json:"total"`
+	Fixable int            `json:"fixable"`
+	Summary map[string]int `json:"summary"`
+}
+
+// BuildHistoryEntry is a step of the build history of an image.
+type BuildHistoryEntry struct {
+	Created    time.Time `json:"created"`
+	CreatedBy  string    `json:"created_by"`
+	Comment    string    `json:"comment,omitempty"`
+	EmptyLayer bool      `json:"empty_layer,omitempty"`
+}
+
+// ChartDependency is a dependency of a Helm chart.
+type ChartDependency struct {
+	Name       string `json:"name"`
+	Version    string `json:"version"`
+	Repository string `json:"repository"`
+}
+
+// Query builds the q parameter of ListArtifactsOptions, e.g.
+// Query{"tags": "~v1"} filters the artifacts whose tags contain v1.
+type Query map[string]string
+
+func (q Query) String() string {
+	pairs := make([]string, 0, len(q))
+	for k, v := range q {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// ListArtifactsOptions specifies the optional parameters of List.
+type ListArtifactsOptions struct {
+	client.ListOptions
+	// Filter, see Query
+	Q    string `json:"q,omitempty"`
+	Sort string `json:"sort,omitempty"`
+	// Harbor lists the tags of the artifacts unless WithoutTag is set
+	WithoutTag          bool `json:"-"`
+	WithLabel           bool `json:"with_label,omitempty"`
+	WithScanOverview    bool `json:"with_scan_overview,omitempty"`
+	WithSignature       bool `json:"with_signature,omitempty"`
+	WithImmutableStatus bool `json:"with_immutable_status,omitempty"`
+}
+
+// GetArtifactOptions specifies the optional parameters of Get.
+type GetArtifactOptions struct {
+	WithoutTag          bool `json:"-"`
+	WithLabel           bool `json:"with_label,omitempty"`
+	WithScanOverview    bool `json:"with_scan_overview,omitempty"`
+	WithSignature       bool `json:"with_signature,omitempty"`
+	WithImmutableStatus bool `json:"with_immutable_status,omitempty"`
+}
+
+// ListTagsOptions specifies the optional parameters of ListTags.
+type ListTagsOptions struct {
+	client.ListOptions
+	Q                   string `json:"q,omitempty"`
+	Sort                string `json:"sort,omitempty"`
+	WithSignature       bool   `json:"with_signature,omitempty"`
+	WithImmutableStatus bool   `json:"with_immutable_status,omitempty"`
+}
@@ -0,0 +1,98 @@
+package artifacts
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+// ArtifactIterator walks the artifacts of a repository matching a
+// ListArtifactsOptions, fetching the pages on demand.
+type ArtifactIterator struct {
+	pager *client2.Pager
+	page  []Artifact
+	cur   Artifact
+}
+
+// Next advances to the next artifact, fetching the next page when needed.
+func (it *ArtifactIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.pager.Next() {
+			return false
+		}
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Artifact returns the current artifact.
+func (it *ArtifactIterator) Artifact() Artifact { return it.cur }
+
+// Total returns the total number of artifacts reported by Harbor, or -1
+// when no page has been fetched yet.
+func (it *ArtifactIterator) Total() int { return it.pager.Total() }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ArtifactIterator) Err() error { return it.pager.Err() }
+
+// Stop ends the iteration early.
+func (it *ArtifactIterator) Stop() {
+	it.page = nil
+	it.pager.Stop()
+}
+
+// Iterate returns an iterator over every artifact of the repository matching
+// opt, following the pages returned by Harbor.
+func (s *ArtifactsService) Iterate(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions) *ArtifactIterator {
+	o := ListArtifactsOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	it := &ArtifactIterator{}
+	it.pager = client2.NewPager(ctx, o.ListOptions, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		o.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, projectName, repoName, &o)
+		it.page = v
+		return len(v), resp, errs
+	})
+	return it
+}
+
+// ListAll returns every artifact of the repository matching opt. Up to
+// concurrency pages are fetched at the same time once the total count is
+// known.
+func (s *ArtifactsService) ListAll(ctx context.Context, projectName, repoName string, opt *ListArtifactsOptions, concurrency int) ([]Artifact, []error) {
+	o := ListArtifactsOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	var (
+		mu    sync.Mutex
+		pages = map[int][]Artifact{}
+	)
+	errs := client2.FetchAll(ctx, o.ListOptions, concurrency, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		po := o
+		po.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, projectName, repoName, &po)
+		mu.Lock()
+		pages[page.Page] = v
+		mu.Unlock()
+		return len(v), resp, errs
+	})
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	keys := make([]int, 0, len(pages))
+	for page := range pages {
+		keys = append(keys, page)
+	}
+	sort.Ints(keys)
+	var all []Artifact
+	for _, page := range keys {
+		all = append(all, pages[page]...)
+	}
+	return all, nil
+}
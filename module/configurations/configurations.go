@@ -0,0 +1,111 @@
+package configurations
+
+import (
+	"context"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	root  = "api.configurations.root"
+	reset = "api.configurations.reset"
+)
+
+// ConfigurationsService handles communication with the system
+// configurations related methods of the Harbor API.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+type Service interface {
+	//取得系統設定
+	Get() (Configurations, *gorequest.Response, []error)
+	GetContext(ctx context.Context) (Configurations, *gorequest.Response, []error)
+	//更新系統設定
+	Update(u Update) (*gorequest.Response, []error)
+	UpdateContext(ctx context.Context, u Update) (*gorequest.Response, []error)
+	//預覽更新會變更的設定
+	Preview(u Update) ([]Change, *gorequest.Response, []error)
+	PreviewContext(ctx context.Context, u Update) ([]Change, *gorequest.Response, []error)
+	//重設系統設定
+	Reset() (*gorequest.Response, []error)
+	ResetContext(ctx context.Context) (*gorequest.Response, []error)
+}
+
+type ConfigurationsService struct {
+	client client2.ClientInterface
+}
+
+func NewConfigurationsService(client client2.ClientInterface) Service {
+	return &ConfigurationsService{client: client}
+}
+
+func (s *ConfigurationsService) getConfigString(key string) string {
+	return s.client.GetConfig().GetString(key)
+}
+
+// Get system configurations.
+//
+// This endpoint returns the system settings, each with whether it can be
+// updated. Only the system administrator can call it.
+func (s *ConfigurationsService) Get() (Configurations, *gorequest.Response, []error) {
+	return s.GetContext(context.Background())
+}
+
+// GetContext is like Get but carries ctx to the request.
+func (s *ConfigurationsService) GetContext(ctx context.Context) (Configurations, *gorequest.Response, []error) {
+	var v Configurations
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(root))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Update system configurations.
+//
+// This endpoint changes the settings set in u, leaving the others
+// untouched. Only the system administrator can call it.
+func (s *ConfigurationsService) Update(u Update) (*gorequest.Response, []error) {
+	return s.UpdateContext(context.Background(), u)
+}
+
+// UpdateContext is like Update but carries ctx to the request.
+func (s *ConfigurationsService) UpdateContext(ctx context.Context, u Update) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, s.getConfigString(root)).
+		Send(u)
+	return s.client.Do(ctx, req, nil)
+}
+
+// Preview returns the settings that Update would change, see Diff.
+func (s *ConfigurationsService) Preview(u Update) ([]Change, *gorequest.Response, []error) {
+	return s.PreviewContext(context.Background(), u)
+}
+
+// PreviewContext is like Preview but carries ctx to the request.
+func (s *ConfigurationsService) PreviewContext(ctx context.Context, u Update) ([]Change, *gorequest.Response, []error) {
+	current, resp, errs := s.GetContext(ctx)
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	return Diff(current, u), resp, nil
+}
+
+// Reset system configurations.
+//
+// This endpoint reloads the settings from the environment of Harbor,
+// dropping the changes made through the API. Harbor 2.x removed it, so it
+// returns client.ErrNotSupported there.
+func (s *ConfigurationsService) Reset() (*gorequest.Response, []error) {
+	return s.ResetContext(context.Background())
+}
+
+// ResetContext is like Reset but carries ctx to the request.
+func (s *ConfigurationsService) ResetContext(ctx context.Context) (*gorequest.Response, []error) {
+	version, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return new(gorequest.Response), []error{err}
+	}
+	if version == client2.APIVersion2 {
+		return new(gorequest.Response), []error{client2.ErrNotSupported}
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(reset))
+	return s.client.Do(ctx, req, nil)
+}
@@ -0,0 +1,113 @@
+package configurations
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+)
+
+const configurations = `{
+	"auth_mode": {"value": "db_auth", "editable": false},
+	"self_registration": {"value": true, "editable": true},
+	"token_expiration": {"value": 30, "editable": true},
+	"scan_all_policy": {"value": {"type": "daily", "parameter": {"daily_time": 3600}}, "editable": true}
+}`
+
+// newConfigurationsServer returns a service of a server answering the
+// configurations of Harbor, and the requests it received, with their body.
+func newConfigurationsServer(t *testing.T, version client2.APIVersion) (Service, func() []string) {
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path+" "+string(body))
+		mu.Unlock()
+		if r.Method == http.MethodGet {
+			w.Write([]byte(configurations))
+		}
+	}))
+	t.Cleanup(srv.Close)
+	c, err := client2.New(client2.WithBaseURL(srv.URL), client2.WithAPIVersion(version))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewConfigurationsService(c), func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), requests...)
+	}
+}
+
+func TestGet(t *testing.T) {
+	s, _ := newConfigurationsServer(t, client2.APIVersion1)
+	c, _, errs := s.Get()
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if c.AuthMode != (StringValue{Value: AuthDB}) || !c.SelfRegistration.Value || c.TokenExpiration.Value != 30 {
+		t.Errorf("Get = %+v", c)
+	}
+	if p := c.ScanAllPolicy.Value; p.Type != "daily" || p.Parameter.DailyTime != 3600 {
+		t.Errorf("scan all policy %+v", p)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	s, requests := newConfigurationsServer(t, client2.APIVersion1)
+	if _, errs := s.Update(Update{SelfRegistration: Bool(false), EmailPort: Int(25)}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	got := requests()
+	if len(got) != 1 {
+		t.Fatalf("requests %v", got)
+	}
+	var body map[string]interface{}
+	if err := json.Unmarshal([]byte(got[0][len("PUT /api/configurations "):]), &body); err != nil {
+		t.Fatalf("%v in %q", err, got[0])
+	}
+	// Only the settings to change are sent, false included
+	if want := map[string]interface{}{"self_registration": false, "email_port": float64(25)}; !reflect.DeepEqual(body, want) {
+		t.Errorf("Update sent %v, want %v", body, want)
+	}
+}
+
+func TestPreview(t *testing.T) {
+	s, requests := newConfigurationsServer(t, client2.APIVersion1)
+	changes, _, errs := s.Preview(Update{AuthMode: String(AuthOIDC), TokenExpiration: Int(30)})
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if want := []Change{{Key: "auth_mode", Old: AuthDB, New: AuthOIDC}}; !reflect.DeepEqual(changes, want) {
+		t.Errorf("Preview = %+v, want %+v", changes, want)
+	}
+	if got := requests(); !reflect.DeepEqual(got, []string{"GET /api/configurations "}) {
+		t.Errorf("Preview requested %v, want only the current configurations", got)
+	}
+}
+
+func TestReset(t *testing.T) {
+	s, requests := newConfigurationsServer(t, client2.APIVersion1)
+	if _, errs := s.Reset(); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if got := requests(); !reflect.DeepEqual(got, []string{"POST /api/configurations/reset "}) {
+		t.Errorf("Reset requested %v", got)
+	}
+
+	s, requests = newConfigurationsServer(t, client2.APIVersion2)
+	if _, errs := s.Reset(); len(errs) != 1 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("Reset on 2.x: %v, want ErrNotSupported", errs)
+	}
+	if got := requests(); len(got) != 0 {
+		t.Errorf("Reset on 2.x requested %v", got)
+	}
+}
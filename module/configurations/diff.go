@@ -0,0 +1,68 @@
+package configurations
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Change is a setting that an Update would change.
+type Change struct {
+	// Key of the setting, e.g. auth_mode
+	Key string
+	// Current value, nil for the secrets Harbor does not return
+	Old interface{}
+	New interface{}
+	// Whether Harbor accepts to update the setting
+	Editable bool
+}
+
+func (c Change) String() string {
+	// Secrets have no current value and are not printed
+	if c.Old == nil {
+		return c.Key + ": (changed)"
+	}
+	return fmt.Sprintf("%s: %v -> %v", c.Key, c.Old, c.New)
+}
+
+// Diff returns the settings of current that u would change, in the order of
+// the fields of Update. Secrets are always reported as changed, with a nil
+// Old value, since their current value is unknown.
+func Diff(current Configurations, u Update) []Change {
+	settings := map[string]reflect.Value{}
+	cv := reflect.ValueOf(current)
+	for i := 0; i < cv.NumField(); i++ {
+		settings[jsonKey(cv.Type().Field(i))] = cv.Field(i)
+	}
+
+	var changes []Change
+	uv := reflect.ValueOf(u)
+	for i := 0; i < uv.NumField(); i++ {
+		f := uv.Field(i)
+		if f.IsNil() {
+			continue
+		}
+		key := jsonKey(uv.Type().Field(i))
+		next := f.Elem().Interface()
+		setting, ok := settings[key]
+		if !ok {
+			changes = append(changes, Change{Key: key, New: next, Editable: true})
+			continue
+		}
+		old := setting.FieldByName("Value").Interface()
+		if reflect.DeepEqual(old, next) {
+			continue
+		}
+		changes = append(changes, Change{
+			Key:      key,
+			Old:      old,
+			New:      next,
+			Editable: setting.FieldByName("Editable").Bool(),
+		})
+	}
+	return changes
+}
+
+func jsonKey(f reflect.StructField) string {
+	return strings.Split(f.Tag.Get("json"), ",")[0]
+}
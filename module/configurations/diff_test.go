@@ -0,0 +1,56 @@
+package configurations
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	var current Configurations
+	current.AuthMode = StringValue{Value: AuthDB, Editable: false}
+	current.SelfRegistration = BoolValue{Value: true, Editable: true}
+	current.TokenExpiration = IntValue{Value: 30, Editable: true}
+	current.ScanAllPolicy.Value.Type = "none"
+
+	var daily ScanAllPolicy
+	daily.Type = "daily"
+	daily.Parameter.DailyTime = 3600
+	u := Update{
+		// Listed in the order of the fields, not of the assignments
+		ScanAllPolicy:      &daily,
+		EmailPassword:      String("s3cret"),
+		TokenExpiration:    Int(30),
+		SelfRegistration:   Bool(false),
+		AuthMode:           String(AuthLDAP),
+		LDAPSearchPassword: String("s3cret"),
+	}
+	got := Diff(current, u)
+	want := []Change{
+		{Key: "auth_mode", Old: AuthDB, New: AuthLDAP, Editable: false},
+		{Key: "self_registration", Old: true, New: false, Editable: true},
+		{Key: "ldap_search_password", New: "s3cret", Editable: true},
+		{Key: "email_password", New: "s3cret", Editable: true},
+		{Key: "scan_all_policy", Old: current.ScanAllPolicy.Value, New: daily},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff =\n%+v\nwant\n%+v", got, want)
+	}
+	if changes := Diff(current, Update{}); len(changes) != 0 {
+		t.Errorf("Diff of an empty update = %+v", changes)
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	for _, tt := range []struct {
+		change Change
+		want   string
+	}{
+		{Change{Key: "auth_mode", Old: AuthDB, New: AuthLDAP}, "auth_mode: db_auth -> ldap_auth"},
+		{Change{Key: "token_expiration", Old: int64(30), New: int64(60)}, "token_expiration: 30 -> 60"},
+		{Change{Key: "email_password", New: "s3cret"}, "email_password: (changed)"},
+	} {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
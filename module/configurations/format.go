@@ -0,0 +1,148 @@
+package configurations
+
+// Authentication modes of Harbor
+const (
+	AuthDB   = "db_auth"
+	AuthLDAP = "ldap_auth"
+	AuthUAA  = "uaa_auth"
+	AuthHTTP = "http_auth"
+	AuthOIDC = "oidc_auth"
+)
+
+// Values of the project creation restriction
+const (
+	EveryoneCanCreate  = "everyone"
+	AdminOnlyCanCreate = "adminonly"
+)
+
+// StringValue is a string setting along with whether it can be updated.
+type StringValue struct {
+	Value    string `json:"value"`
+	Editable bool   `json:"editable"`
+}
+
+// IntValue is an integer setting along with whether it can be updated.
+type IntValue struct {
+	Value    int64 `json:"value"`
+	Editable bool  `json:"editable"`
+}
+
+// BoolValue is a boolean setting along with whether it can be updated.
+type BoolValue struct {
+	Value    bool `json:"value"`
+	Editable bool `json:"editable"`
+}
+
+// ScanAllPolicy schedules the scan of every image.
+type ScanAllPolicy struct {
+	// none or daily
+	Type      string `json:"type"`
+	Parameter struct {
+		// Seconds after midnight UTC
+		DailyTime int64 `json:"daily_time"`
+	} `json:"parameter"`
+}
+
+// ScanAllPolicyValue is the scan-all schedule along with whether it can be
+// updated.
+type ScanAllPolicyValue struct {
+	Value    ScanAllPolicy `json:"value"`
+	Editable bool          `json:"editable"`
+}
+
+// Configurations holds the system settings of Harbor. Passwords and secrets
+// are never returned by Harbor, they can only be set through Update.
+type Configurations struct {
+	AuthMode                   StringValue `json:"auth_mode"`
+	SelfRegistration           BoolValue   `json:"self_registration"`
+	ProjectCreationRestriction StringValue `json:"project_creation_restriction"`
+	ReadOnly                   BoolValue   `json:"read_only"`
+	// Minutes
+	TokenExpiration IntValue `json:"token_expiration"`
+	// Minutes
+	RobotTokenDuration IntValue `json:"robot_token_duration"`
+
+	LDAPURL                StringValue `json:"ldap_url"`
+	LDAPSearchDN           StringValue `json:"ldap_search_dn"`
+	LDAPBaseDN             StringValue `json:"ldap_base_dn"`
+	LDAPFilter             StringValue `json:"ldap_filter"`
+	LDAPUID                StringValue `json:"ldap_uid"`
+	LDAPScope              IntValue    `json:"ldap_scope"`
+	LDAPTimeout            IntValue    `json:"ldap_timeout"`
+	LDAPVerifyCert         BoolValue   `json:"ldap_verify_cert"`
+	LDAPGroupBaseDN        StringValue `json:"ldap_group_base_dn"`
+	LDAPGroupSearchFilter  StringValue `json:"ldap_group_search_filter"`
+	LDAPGroupAttributeName StringValue `json:"ldap_group_attribute_name"`
+	LDAPGroupSearchScope   IntValue    `json:"ldap_group_search_scope"`
+	LDAPGroupAdminDN       StringValue `json:"ldap_group_admin_dn"`
+
+	OIDCName        StringValue `json:"oidc_name"`
+	OIDCEndpoint    StringValue `json:"oidc_endpoint"`
+	OIDCClientID    StringValue `json:"oidc_client_id"`
+	OIDCScope       StringValue `json:"oidc_scope"`
+	OIDCGroupsClaim StringValue `json:"oidc_groups_claim"`
+	OIDCUserClaim   StringValue `json:"oidc_user_claim"`
+	OIDCVerifyCert  BoolValue   `json:"oidc_verify_cert"`
+	OIDCAutoOnboard BoolValue   `json:"oidc_auto_onboard"`
+
+	EmailHost     StringValue `json:"email_host"`
+	EmailPort     IntValue    `json:"email_port"`
+	EmailUsername StringValue `json:"email_username"`
+	EmailFrom     StringValue `json:"email_from"`
+	EmailIdentity StringValue `json:"email_identity"`
+	EmailSSL      BoolValue   `json:"email_ssl"`
+	EmailInsecure BoolValue   `json:"email_insecure"`
+
+	ScanAllPolicy ScanAllPolicyValue `json:"scan_all_policy"`
+}
+
+// Update holds the settings to change, nil fields being left untouched.
+type Update struct {
+	AuthMode                   *string `json:"auth_mode,omitempty"`
+	SelfRegistration           *bool   `json:"self_registration,omitempty"`
+	ProjectCreationRestriction *string `json:"project_creation_restriction,omitempty"`
+	ReadOnly                   *bool   `json:"read_only,omitempty"`
+	TokenExpiration            *int64  `json:"token_expiration,omitempty"`
+	RobotTokenDuration         *int64  `json:"robot_token_duration,omitempty"`
+
+	LDAPURL                *string `json:"ldap_url,omitempty"`
+	LDAPSearchDN           *string `json:"ldap_search_dn,omitempty"`
+	LDAPSearchPassword     *string `json:"ldap_search_password,omitempty"`
+	LDAPBaseDN             *string `json:"ldap_base_dn,omitempty"`
+	LDAPFilter             *string `json:"ldap_filter,omitempty"`
+	LDAPUID                *string `json:"ldap_uid,omitempty"`
+	LDAPScope              *int64  `json:"ldap_scope,omitempty"`
+	LDAPTimeout            *int64  `json:"ldap_timeout,omitempty"`
+	LDAPVerifyCert         *bool   `json:"ldap_verify_cert,omitempty"`
+	LDAPGroupBaseDN        *string `json:"ldap_group_base_dn,omitempty"`
+	LDAPGroupSearchFilter  *string `json:"ldap_group_search_filter,omitempty"`
+	LDAPGroupAttributeName *string `json:"ldap_group_attribute_name,omitempty"`
+	LDAPGroupSearchScope   *int64  `json:"ldap_group_search_scope,omitempty"`
+	LDAPGroupAdminDN       *string `json:"ldap_group_admin_dn,omitempty"`
+
+	OIDCName         *string `json:"oidc_name,omitempty"`
+	OIDCEndpoint     *string `json:"oidc_endpoint,omitempty"`
+	OIDCClientID     *string `json:"oidc_client_id,omitempty"`
+	OIDCClientSecret *string `json:"oidc_client_secret,omitempty"`
+	OIDCScope        *string `json:"oidc_scope,omitempty"`
+	OIDCGroupsClaim  *string `json:"oidc_groups_claim,omitempty"`
+	OIDCUserClaim    *string `json:"oidc_user_claim,omitempty"`
+	OIDCVerifyCert   *bool   `json:"oidc_verify_cert,omitempty"`
+	OIDCAutoOnboard  *bool   `json:"oidc_auto_onboard,omitempty"`
+
+	EmailHost     *string `json:"email_host,omitempty"`
+	EmailPort     *int64  `json:"email_port,omitempty"`
+	EmailUsername *string `json:"email_username,omitempty"`
+	EmailPassword *string `json:"email_password,omitempty"`
+	EmailFrom     *string `json:"email_from,omitempty"`
+	EmailIdentity *string `json:"email_identity,omitempty"`
+	EmailSSL      *bool   `json:"email_ssl,omitempty"`
+	EmailInsecure *bool   `json:"email_insecure,omitempty"`
+
+	ScanAllPolicy *ScanAllPolicy `json:"scan_all_policy,omitempty"`
+}
+
+// String, Int and Bool return a pointer to v, to fill the fields of Update.
+func String(v string) *string { return &v }
+func Int(v int64) *int64      { return &v }
+func Bool(v bool) *bool       { return &v }
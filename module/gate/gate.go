@@ -0,0 +1,141 @@
+// Package gate evaluates the scan report of an image against a policy, to
+// block the release of images with too many vulnerabilities.
+package gate
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// Violation is a severity whose vulnerabilities exceed the limit of the
+// policy.
+type Violation struct {
+	Severity repositories.Severity
+	Max      int
+	Count    int
+}
+
+// Verdict is the result of the evaluation of a policy.
+type Verdict struct {
+	Pass       bool
+	Violations []Violation
+	// Vulnerabilities of the severities in violation
+	Offenders []repositories.VulnerabilityItem
+	// Vulnerabilities accepted by the allowlist
+	Exempted []repositories.VulnerabilityItem
+	// Vulnerabilities of ignored packages, or without fix when the policy
+	// only counts fixable ones
+	Ignored []repositories.VulnerabilityItem
+	// Exemptions that matched vulnerabilities but have expired
+	Expired []Exemption
+}
+
+// Evaluate evaluates the vulnerabilities of items against the policy.
+func (p Policy) Evaluate(items []repositories.VulnerabilityItem) Verdict {
+	return p.EvaluateAt(items, time.Now())
+}
+
+// EvaluateReport evaluates the vulnerabilities of a scan report against the
+// policy.
+func (p Policy) EvaluateReport(r repositories.ScanReport) Verdict {
+	return p.Evaluate(r.Vulnerabilities)
+}
+
+// EvaluateAt is like Evaluate, the exemptions expiring relative to now.
+func (p Policy) EvaluateAt(items []repositories.VulnerabilityItem, now time.Time) Verdict {
+	var (
+		v       Verdict
+		counted = map[repositories.Severity][]repositories.VulnerabilityItem{}
+		expired = map[string]bool{}
+	)
+	for _, item := range items {
+		if p.ignores(item.Pkg) || (p.OnlyFixable && item.Fixed == "") {
+			v.Ignored = append(v.Ignored, item)
+			continue
+		}
+		if e, ok := p.exemption(item.ID); ok {
+			if !e.Expired(now) {
+				v.Exempted = append(v.Exempted, item)
+				continue
+			}
+			if !expired[e.ID] {
+				expired[e.ID] = true
+				v.Expired = append(v.Expired, e)
+			}
+		}
+		counted[item.Severity] = append(counted[item.Severity], item)
+	}
+
+	for _, sev := range repositories.Severities() {
+		max, ok := p.MaxCounts[sev]
+		if !ok || len(counted[sev]) <= max {
+			continue
+		}
+		v.Violations = append(v.Violations, Violation{Severity: sev, Max: max, Count: len(counted[sev])})
+		v.Offenders = append(v.Offenders, counted[sev]...)
+	}
+	v.Pass = len(v.Violations) == 0
+	sortItems(v.Offenders)
+	sortItems(v.Exempted)
+	sortItems(v.Ignored)
+	return v
+}
+
+// sortItems sorts vulnerabilities from the most severe, then by ID and
+// package, so that verdicts are stable.
+func sortItems(items []repositories.VulnerabilityItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Pkg < b.Pkg
+	})
+}
+
+// ExitCode returns the exit code of a command reporting the verdict: 0 when
+// it passes, 1 otherwise.
+func (v Verdict) ExitCode() int {
+	if v.Pass {
+		return 0
+	}
+	return 1
+}
+
+// Write prints the verdict for humans, e.g. in the log of a pipeline.
+func (v Verdict) Write(w io.Writer) error {
+	status := "PASS"
+	if !v.Pass {
+		status = "FAIL"
+	}
+	if _, err := fmt.Fprintf(w, "%s: %d offending, %d exempted, %d ignored vulnerabilities\n", status, len(v.Offenders), len(v.Exempted), len(v.Ignored)); err != nil {
+		return err
+	}
+	for _, violation := range v.Violations {
+		if _, err := fmt.Fprintf(w, "  %s: %d found, %d allowed\n", violation.Severity, violation.Count, violation.Max); err != nil {
+			return err
+		}
+	}
+	for _, item := range v.Offenders {
+		fix := "no fix"
+		if item.Fixed != "" {
+			fix = "fixed in " + item.Fixed
+		}
+		if _, err := fmt.Fprintf(w, "  - %s %s %s@%s (%s)\n", item.Severity, item.ID, item.Pkg, item.Version, fix); err != nil {
+			return err
+		}
+	}
+	for _, e := range v.Expired {
+		if _, err := fmt.Fprintf(w, "  exemption of %s expired on %s\n", e.ID, e.Expires.Format("2006-01-02")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package gate
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+var items = []repositories.VulnerabilityItem{
+	{ID: "CVE-2020-0005", Severity: repositories.SeverityHigh, Pkg: "zlib", Version: "1.2.11"},
+	{ID: "CVE-2020-0001", Severity: repositories.SeverityCritical, Pkg: "openssl", Version: "1.1.1d", Fixed: "1.1.1g"},
+	{ID: "CVE-2020-0002", Severity: repositories.SeverityCritical, Pkg: "openssl", Version: "1.1.1d", Fixed: "1.1.1g"},
+	{ID: "CVE-2020-0003", Severity: repositories.SeverityHigh, Pkg: "curl", Version: "7.64.0", Fixed: "7.68.0"},
+	{ID: "CVE-2020-0004", Severity: repositories.SeverityMedium, Pkg: "linux-libc-dev", Version: "4.19.98"},
+	{ID: "CVE-2020-0006", Severity: repositories.SeverityLow, Pkg: "bash", Version: "5.0"},
+}
+
+func ids(items []repositories.VulnerabilityItem) []string {
+	var ids []string
+	for _, item := range items {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC)
+	p := Policy{
+		MaxCounts: map[repositories.Severity]int{repositories.SeverityCritical: 0, repositories.SeverityHigh: 1},
+		Allowlist: []Exemption{
+			{ID: "cve-2020-0002", Expires: Date{time.Date(2021, 1, 31, 23, 59, 59, 0, time.UTC)}},
+			{ID: "CVE-2020-0003", Expires: Date{time.Date(2021, 1, 1, 23, 59, 59, 0, time.UTC)}},
+		},
+		IgnorePackages: []string{"linux-*"},
+	}
+	v := p.EvaluateAt(items, now)
+	want := []Violation{
+		{Severity: repositories.SeverityHigh, Max: 1, Count: 2},
+		{Severity: repositories.SeverityCritical, Max: 0, Count: 1},
+	}
+	if v.Pass || v.ExitCode() != 1 || !reflect.DeepEqual(v.Violations, want) {
+		t.Errorf("violations %+v", v.Violations)
+	}
+	// Offenders are sorted from the most severe
+	if got, want := ids(v.Offenders), []string{"CVE-2020-0001", "CVE-2020-0003", "CVE-2020-0005"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("offenders %v, want %v", got, want)
+	}
+	if got := ids(v.Exempted); !reflect.DeepEqual(got, []string{"CVE-2020-0002"}) {
+		t.Errorf("exempted %v", got)
+	}
+	if got := ids(v.Ignored); !reflect.DeepEqual(got, []string{"CVE-2020-0004"}) {
+		t.Errorf("ignored %v", got)
+	}
+	if len(v.Expired) != 1 || v.Expired[0].ID != "CVE-2020-0003" {
+		t.Errorf("expired %+v", v.Expired)
+	}
+
+	// Only the fixable vulnerabilities count
+	p.OnlyFixable = true
+	v = p.EvaluateAt(items, now)
+	if got := ids(v.Ignored); !reflect.DeepEqual(got, []string{"CVE-2020-0005", "CVE-2020-0004", "CVE-2020-0006"}) {
+		t.Errorf("ignored %v", got)
+	}
+	if len(v.Violations) != 1 || v.Violations[0].Severity != repositories.SeverityCritical {
+		t.Errorf("violations %+v", v.Violations)
+	}
+
+	v = Policy{}.Evaluate(items)
+	if !v.Pass || v.ExitCode() != 0 || len(v.Offenders) != 0 {
+		t.Errorf("empty policy: %+v", v)
+	}
+	if v := p.EvaluateReport(repositories.ScanReport{}); !v.Pass {
+		t.Errorf("report without vulnerabilities: %+v", v)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	p := Policy{
+		MaxCounts: map[repositories.Severity]int{repositories.SeverityCritical: 0},
+		Allowlist: []Exemption{{ID: "CVE-2020-0002", Expires: Date{time.Date(2021, 1, 1, 23, 59, 59, 0, time.UTC)}}},
+	}
+	var buf bytes.Buffer
+	if err := p.EvaluateAt(items, time.Date(2021, 1, 15, 0, 0, 0, 0, time.UTC)).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `FAIL: 2 offending, 0 exempted, 0 ignored vulnerabilities
+  Critical: 2 found, 0 allowed
+  - Critical CVE-2020-0001 openssl@1.1.1d (fixed in 1.1.1g)
+  - Critical CVE-2020-0002 openssl@1.1.1d (fixed in 1.1.1g)
+  exemption of CVE-2020-0002 expired on 2021-01-01
+`
+	if buf.String() != want {
+		t.Errorf("Write =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := (Policy{}).Evaluate(items[:1]).Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if want := "PASS: 0 offending, 0 exempted, 0 ignored vulnerabilities\n"; buf.String() != want {
+		t.Errorf("Write = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,158 @@
+package gate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+	"gopkg.in/yaml.v2"
+)
+
+// Policy is the declarative policy a scan report is evaluated against.
+type Policy struct {
+	// Maximum number of vulnerabilities allowed per severity, e.g.
+	// {Critical: 0, High: 5}. Severities not listed are not limited.
+	MaxCounts map[repositories.Severity]int `json:"max_counts,omitempty" yaml:"max_counts,omitempty"`
+	// Only count the vulnerabilities having a fixed version
+	OnlyFixable bool `json:"only_fixable,omitempty" yaml:"only_fixable,omitempty"`
+	// Vulnerabilities accepted until their expiry
+	Allowlist []Exemption `json:"allowlist,omitempty" yaml:"allowlist,omitempty"`
+	// Patterns of the packages whose vulnerabilities are ignored, in the
+	// syntax of path.Match, e.g. linux-*
+	IgnorePackages []string `json:"ignore_packages,omitempty" yaml:"ignore_packages,omitempty"`
+}
+
+// Exemption accepts a vulnerability, identified by its CVE, until it
+// expires.
+type Exemption struct {
+	ID string `json:"id" yaml:"id"`
+	// Day after which the vulnerability counts again, never when zero
+	Expires Date   `json:"expires,omitempty" yaml:"expires,omitempty"`
+	Reason  string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Expired reports whether the exemption no longer applies at t.
+func (e Exemption) Expired(t time.Time) bool {
+	return !e.Expires.IsZero() && t.After(e.Expires.Time)
+}
+
+// Date is a day written as 2006-01-02, or a time in RFC 3339. A day lasts
+// until its end in UTC.
+type Date struct {
+	time.Time
+}
+
+// MarshalText encodes the date as a day, or in RFC 3339 when it is not the
+// end of a day.
+func (d Date) MarshalText() ([]byte, error) {
+	if d.IsZero() {
+		return []byte{}, nil
+	}
+	if d.Equal(endOfDay(d.Time)) {
+		return []byte(d.Format("2006-01-02")), nil
+	}
+	return []byte(d.Format(time.RFC3339)), nil
+}
+
+// UnmarshalText decodes a day or a time in RFC 3339.
+func (d *Date) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if s == "" {
+		*d = Date{}
+		return nil
+	}
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		d.Time = endOfDay(t)
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return fmt.Errorf("gate: invalid date %q, expected 2006-01-02", s)
+	}
+	d.Time = t
+	return nil
+}
+
+// MarshalJSON encodes the date as MarshalText does, rather than as the
+// embedded time.Time.
+func (d Date) MarshalJSON() ([]byte, error) {
+	text, err := d.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(text))
+}
+
+// UnmarshalJSON decodes a day or a time in RFC 3339.
+func (d *Date) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("gate: invalid date %s, expected 2006-01-02", data)
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+func endOfDay(t time.Time) time.Time {
+	y, m, day := t.UTC().Date()
+	return time.Date(y, m, day, 23, 59, 59, 0, time.UTC)
+}
+
+// ParsePolicy decodes a policy written in YAML or JSON.
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return p, fmt.Errorf("gate: invalid policy: %v", err)
+	}
+	return p, p.Validate()
+}
+
+// LoadPolicy reads the policy of the YAML or JSON file at filename.
+func LoadPolicy(filename string) (Policy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Policy{}, err
+	}
+	return ParsePolicy(data)
+}
+
+// Validate checks the limits and patterns of the policy.
+func (p Policy) Validate() error {
+	for sev, max := range p.MaxCounts {
+		if max < 0 {
+			return fmt.Errorf("gate: negative limit for %s", sev)
+		}
+	}
+	for _, pattern := range p.IgnorePackages {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("gate: invalid package pattern %q: %v", pattern, err)
+		}
+	}
+	for _, e := range p.Allowlist {
+		if e.ID == "" {
+			return fmt.Errorf("gate: allowlist entry without id")
+		}
+	}
+	return nil
+}
+
+func (p Policy) ignores(pkg string) bool {
+	for _, pattern := range p.IgnorePackages {
+		if ok, _ := path.Match(pattern, pkg); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (p Policy) exemption(id string) (Exemption, bool) {
+	for _, e := range p.Allowlist {
+		if strings.EqualFold(e.ID, id) {
+			return e, true
+		}
+	}
+	return Exemption{}, false
+}
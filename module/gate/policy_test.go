@@ -0,0 +1,138 @@
+package gate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// tempDir returns a directory removed at the end of the test.
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "harbor-gate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestParsePolicy(t *testing.T) {
+	want := Policy{
+		MaxCounts:   map[repositories.Severity]int{repositories.SeverityCritical: 0, repositories.SeverityHigh: 5},
+		OnlyFixable: true,
+		Allowlist: []Exemption{
+			{ID: "CVE-2020-0001", Expires: Date{time.Date(2021, 1, 31, 23, 59, 59, 0, time.UTC)}, Reason: "not exploitable"},
+			{ID: "CVE-2020-0002", Expires: Date{time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC)}},
+			{ID: "CVE-2020-0003"},
+		},
+		IgnorePackages: []string{"linux-*"},
+	}
+	for name, data := range map[string]string{
+		"yaml": `
+max_counts:
+  Critical: 0
+  high: 5
+only_fixable: true
+allowlist:
+- id: CVE-2020-0001
+  expires: 2021-01-31
+  reason: not exploitable
+- id: CVE-2020-0002
+  expires: 2021-01-31T12:00:00Z
+- id: CVE-2020-0003
+ignore_packages:
+- linux-*
+`,
+		"json": `{
+			"max_counts": {"Critical": 0, "High": 5},
+			"only_fixable": true,
+			"allowlist": [
+				{"id": "CVE-2020-0001", "expires": "2021-01-31", "reason": "not exploitable"},
+				{"id": "CVE-2020-0002", "expires": "2021-01-31T12:00:00Z"},
+				{"id": "CVE-2020-0003"}
+			],
+			"ignore_packages": ["linux-*"]
+		}`,
+	} {
+		p, err := ParsePolicy([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(p, want) {
+			t.Errorf("%s: ParsePolicy =\n%+v\nwant\n%+v", name, p, want)
+		}
+	}
+}
+
+func TestParsePolicyErrors(t *testing.T) {
+	for data, want := range map[string]string{
+		"max_count: {High: 1}":                        "field max_count not found",
+		"max_counts: {Severe: 1}":                     "unknown severity",
+		"max_counts: {High: -1}":                      "negative limit for High",
+		"ignore_packages: ['linux-[']":                "invalid package pattern",
+		"allowlist: [{reason: accepted}]":             "allowlist entry without id",
+		"allowlist: [{id: CVE-1, expires: tomorrow}]": "invalid date",
+	} {
+		if _, err := ParsePolicy([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParsePolicy(%q): %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	filename := filepath.Join(tempDir(t), "policy.yaml")
+	if err := ioutil.WriteFile(filename, []byte("max_counts: {Critical: 0}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadPolicy(filename)
+	if err != nil || !reflect.DeepEqual(p.MaxCounts, map[repositories.Severity]int{repositories.SeverityCritical: 0}) {
+		t.Errorf("LoadPolicy = %+v, %v", p, err)
+	}
+	if _, err := LoadPolicy(filepath.Join(tempDir(t), "missing.yaml")); err == nil {
+		t.Error("LoadPolicy of a missing file succeeded")
+	}
+}
+
+func TestDate(t *testing.T) {
+	var d Date
+	if err := d.UnmarshalText([]byte("2021-01-31")); err != nil {
+		t.Fatal(err)
+	}
+	e := Exemption{ID: "CVE-2020-0001", Expires: d}
+	for at, want := range map[string]bool{
+		"2021-01-31T00:00:00Z": false,
+		"2021-01-31T23:59:59Z": false,
+		"2021-02-01T00:00:00Z": true,
+		// Days end in UTC
+		"2021-02-01T07:59:59+08:00": false,
+		"2021-01-31T20:00:00-05:00": true,
+	} {
+		now, _ := time.Parse(time.RFC3339, at)
+		if got := e.Expired(now); got != want {
+			t.Errorf("Expired at %s = %v, want %v", at, got, want)
+		}
+	}
+	if (Exemption{ID: "CVE-2020-0001"}).Expired(time.Now()) {
+		t.Error("exemption without expiry expired")
+	}
+
+	data, err := json.Marshal([]Date{d, {time.Date(2021, 1, 31, 12, 0, 0, 0, time.UTC)}, {}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["2021-01-31","2021-01-31T12:00:00Z",""]`; string(data) != want {
+		t.Errorf("encoded %s, want %s", data, want)
+	}
+	var decoded []Date
+	if err := json.Unmarshal(data, &decoded); err != nil || !decoded[0].Equal(d.Time) || !decoded[2].IsZero() {
+		t.Errorf("decoded %v, %v", decoded, err)
+	}
+}
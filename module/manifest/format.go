@@ -0,0 +1,137 @@
+package manifest
+
+import (
+	"strings"
+	"time"
+)
+
+// Media types of the manifests
+const (
+	MediaTypeDockerSchema1       = "application/vnd.docker.distribution.manifest.v1+json"
+	MediaTypeDockerSchema1Signed = "application/vnd.docker.distribution.manifest.v1+prettyjws"
+	MediaTypeDockerSchema2       = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList  = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest         = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex            = "application/vnd.oci.image.index.v1+json"
+)
+
+// Media types of the blobs referenced by the manifests
+const (
+	MediaTypeDockerConfig = "application/vnd.docker.container.image.v1+json"
+	MediaTypeDockerLayer  = "application/vnd.docker.image.rootfs.diff.tar.gzip"
+	MediaTypeOCIConfig    = "application/vnd.oci.image.config.v1+json"
+	MediaTypeOCILayer     = "application/vnd.oci.image.layer.v1.tar+gzip"
+)
+
+// Descriptor references a blob or a manifest by its digest.
+type Descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Size        int64             `json:"size"`
+	Digest      string            `json:"digest"`
+	URLs        []string          `json:"urls,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Platform is only set on the manifests of an index
+	Platform *Platform `json:"platform,omitempty"`
+}
+
+// Platform describes the platform an image runs on.
+type Platform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+}
+
+// String returns the platform as os/architecture[/variant], e.g.
+// linux/arm64/v8.
+func (p Platform) String() string {
+	parts := []string{p.OS, p.Architecture}
+	if p.Variant != "" {
+		parts = append(parts, p.Variant)
+	}
+	return strings.Join(parts, "/")
+}
+
+// Schema1 is a Docker image manifest, version 2 schema 1.
+type Schema1 struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	MediaType     string `json:"mediaType,omitempty"`
+	Name          string `json:"name"`
+	Tag           string `json:"tag"`
+	Architecture  string `json:"architecture"`
+	// FSLayers lists the layers from the top one
+	FSLayers []FSLayer `json:"fsLayers"`
+	// History has an entry per layer, in the order of FSLayers
+	History []Schema1History `json:"history"`
+}
+
+// FSLayer is a layer of a Schema1 manifest.
+type FSLayer struct {
+	BlobSum string `json:"blobSum"`
+}
+
+// Schema1History holds the v1 JSON config of a layer of a Schema1 manifest.
+type Schema1History struct {
+	V1Compatibility string `json:"v1Compatibility"`
+}
+
+// Image is a Docker image manifest, version 2 schema 2, or an OCI image
+// manifest.
+type Image struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Config        Descriptor        `json:"config"`
+	Layers        []Descriptor      `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Index is a Docker manifest list or an OCI image index, listing the
+// manifests of an image for several platforms.
+type Index struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType,omitempty"`
+	Manifests     []Descriptor      `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// ImageConfig is the configuration of an image, referenced by Image.Config.
+type ImageConfig struct {
+	Architecture string          `json:"architecture"`
+	OS           string          `json:"os"`
+	OSVersion    string          `json:"os.version,omitempty"`
+	Variant      string          `json:"variant,omitempty"`
+	Created      *time.Time      `json:"created,omitempty"`
+	Author       string          `json:"author,omitempty"`
+	Config       ContainerConfig `json:"config"`
+	RootFS       RootFS          `json:"rootfs"`
+	History      []History       `json:"history,omitempty"`
+}
+
+// ContainerConfig holds the defaults of the containers run from an image.
+type ContainerConfig struct {
+	User         string              `json:"User,omitempty"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts,omitempty"`
+	Env          []string            `json:"Env,omitempty"`
+	Entrypoint   []string            `json:"Entrypoint,omitempty"`
+	Cmd          []string            `json:"Cmd,omitempty"`
+	Volumes      map[string]struct{} `json:"Volumes,omitempty"`
+	WorkingDir   string              `json:"WorkingDir,omitempty"`
+	Labels       map[string]string   `json:"Labels,omitempty"`
+	StopSignal   string              `json:"StopSignal,omitempty"`
+}
+
+// RootFS lists the uncompressed layers of an image.
+type RootFS struct {
+	Type    string   `json:"type"`
+	DiffIDs []string `json:"diff_ids"`
+}
+
+// History is a step of the build of an image.
+type History struct {
+	Created    *time.Time `json:"created,omitempty"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	Author     string     `json:"author,omitempty"`
+	Comment    string     `json:"comment,omitempty"`
+	EmptyLayer bool       `json:"empty_layer,omitempty"`
+}
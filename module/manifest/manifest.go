@@ -0,0 +1,205 @@
+// Package manifest parses the manifests of the images stored in Harbor:
+// Docker schema 1 and schema 2 manifests, Docker manifest lists, OCI image
+// manifests and OCI image indexes, along with the configuration of the
+// images.
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Manifest is a parsed manifest. Exactly one of Schema1, Image and Index is
+// set, depending on MediaType.
+type Manifest struct {
+	MediaType string
+	Schema1   *Schema1
+	Image     *Image
+	Index     *Index
+}
+
+// Parse parses a manifest, selected by its mediaType field. Manifests
+// without one are recognized by their schema version and fields, as OCI
+// allows.
+func Parse(data []byte) (*Manifest, error) {
+	var head struct {
+		SchemaVersion int             `json:"schemaVersion"`
+		MediaType     string          `json:"mediaType"`
+		Manifests     json.RawMessage `json:"manifests"`
+	}
+	if err := json.Unmarshal(data, &head); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	mediaType := head.MediaType
+	if mediaType == "" {
+		switch {
+		case head.SchemaVersion == 1:
+			mediaType = MediaTypeDockerSchema1
+		case head.Manifests != nil:
+			mediaType = MediaTypeOCIIndex
+		default:
+			mediaType = MediaTypeOCIManifest
+		}
+	}
+	return ParseMediaType(mediaType, data)
+}
+
+// ParseMediaType parses a manifest of the given media type, e.g. the
+// Content-Type returned by the registry.
+func ParseMediaType(mediaType string, data []byte) (*Manifest, error) {
+	// Drop the parameters of a Content-Type
+	if i := strings.Index(mediaType, ";"); i >= 0 {
+		mediaType = strings.TrimSpace(mediaType[:i])
+	}
+	m := &Manifest{MediaType: mediaType}
+	var v interface{}
+	switch mediaType {
+	case MediaTypeDockerSchema1, MediaTypeDockerSchema1Signed:
+		m.Schema1 = new(Schema1)
+		v = m.Schema1
+	case MediaTypeDockerSchema2, MediaTypeOCIManifest:
+		m.Image = new(Image)
+		v = m.Image
+	case MediaTypeDockerManifestList, MediaTypeOCIIndex:
+		m.Index = new(Index)
+		v = m.Index
+	default:
+		return nil, fmt.Errorf("manifest: unsupported media type %q", mediaType)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("manifest: %w", err)
+	}
+	return m, nil
+}
+
+// IsIndex reports whether m lists the manifests of several platforms.
+func (m *Manifest) IsIndex() bool {
+	return m.Index != nil
+}
+
+// Config returns the descriptor of the image configuration, nil for
+// schema 1 manifests and indexes.
+func (m *Manifest) Config() *Descriptor {
+	if m.Image == nil {
+		return nil
+	}
+	return &m.Image.Config
+}
+
+// Layers returns the layers of the image, from the base one. Schema 1
+// manifests do not carry the size of the layers; indexes have no layers.
+func (m *Manifest) Layers() []Descriptor {
+	switch {
+	case m.Image != nil:
+		return m.Image.Layers
+	case m.Schema1 != nil:
+		layers := make([]Descriptor, 0, len(m.Schema1.FSLayers))
+		for i := len(m.Schema1.FSLayers) - 1; i >= 0; i-- {
+			layers = append(layers, Descriptor{MediaType: MediaTypeDockerLayer, Digest: m.Schema1.FSLayers[i].BlobSum})
+		}
+		return layers
+	}
+	return nil
+}
+
+// TotalSize returns the compressed size of the image: its configuration and
+// layers. For an index, it returns the size of the manifests it lists, the
+// layers being only known from these manifests.
+func (m *Manifest) TotalSize() int64 {
+	var size int64
+	switch {
+	case m.Image != nil:
+		size = m.Image.Config.Size
+		for _, layer := range m.Image.Layers {
+			size += layer.Size
+		}
+	case m.Index != nil:
+		for _, manifest := range m.Index.Manifests {
+			size += manifest.Size
+		}
+	}
+	return size
+}
+
+// Platforms returns the platforms of the manifests of an index, or the
+// platform recorded by a schema 1 manifest. The platform of a schema 2 or
+// OCI image is only known from its configuration, see ImageConfig.Platform.
+func (m *Manifest) Platforms() []Platform {
+	switch {
+	case m.Index != nil:
+		var platforms []Platform
+		for _, manifest := range m.Index.Manifests {
+			if manifest.Platform != nil {
+				platforms = append(platforms, *manifest.Platform)
+			}
+		}
+		return platforms
+	case m.Schema1 != nil:
+		p := Platform{Architecture: m.Schema1.Architecture}
+		if len(m.Schema1.History) != 0 {
+			var top struct {
+				OS           string `json:"os"`
+				Architecture string `json:"architecture"`
+			}
+			if json.Unmarshal([]byte(m.Schema1.History[0].V1Compatibility), &top) == nil {
+				p.OS = top.OS
+				if p.Architecture == "" {
+					p.Architecture = top.Architecture
+				}
+			}
+		}
+		return []Platform{p}
+	}
+	return nil
+}
+
+// ForPlatform returns the descriptor of the manifest of an index matching p;
+// an empty variant or OS version of p matches any.
+func (m *Manifest) ForPlatform(p Platform) (Descriptor, bool) {
+	if m.Index == nil {
+		return Descriptor{}, false
+	}
+	for _, manifest := range m.Index.Manifests {
+		mp := manifest.Platform
+		if mp == nil || mp.OS != p.OS || mp.Architecture != p.Architecture {
+			continue
+		}
+		if p.Variant != "" && mp.Variant != p.Variant || p.OSVersion != "" && mp.OSVersion != p.OSVersion {
+			continue
+		}
+		return manifest, true
+	}
+	return Descriptor{}, false
+}
+
+// ParseConfig parses the configuration of an image.
+func ParseConfig(data []byte) (*ImageConfig, error) {
+	c := new(ImageConfig)
+	if err := json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("manifest: image config: %w", err)
+	}
+	return c, nil
+}
+
+// Platform returns the platform the image runs on.
+func (c *ImageConfig) Platform() Platform {
+	return Platform{Architecture: c.Architecture, OS: c.OS, OSVersion: c.OSVersion, Variant: c.Variant}
+}
+
+// Getenv returns the default value of the environment variable key in the
+// containers run from the image.
+func (c *ImageConfig) Getenv(key string) (string, bool) {
+	for _, kv := range c.Config.Env {
+		if strings.HasPrefix(kv, key+"=") {
+			return kv[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// Command returns the command run by default, the entrypoint followed by
+// the arguments.
+func (c *ImageConfig) Command() []string {
+	return append(append([]string(nil), c.Config.Entrypoint...), c.Config.Cmd...)
+}
@@ -0,0 +1,186 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const schema2 = `{
+	"schemaVersion": 2,
+	"mediaType": "application/vnd.docker.distribution.manifest.v2+json",
+	"config": {"mediaType": "application/vnd.docker.container.image.v1+json", "size": 1500, "digest": "sha256:c"},
+	"layers": [
+		{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "size": 27000000, "digest": "sha256:l1"},
+		{"mediaType": "application/vnd.docker.image.rootfs.diff.tar.gzip", "size": 500, "digest": "sha256:l2"}
+	]
+}`
+
+// An OCI index without media type, as OCI allows
+const ociIndex = `{
+	"schemaVersion": 2,
+	"manifests": [
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "size": 500, "digest": "sha256:amd64", "platform": {"architecture": "amd64", "os": "linux"}},
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "size": 510, "digest": "sha256:arm64", "platform": {"architecture": "arm64", "os": "linux", "variant": "v8"}},
+		{"mediaType": "application/vnd.oci.image.manifest.v1+json", "size": 520, "digest": "sha256:windows", "platform": {"architecture": "amd64", "os": "windows", "os.version": "10.0.17763.1457"}}
+	]
+}`
+
+const schema1 = `{
+	"schemaVersion": 1,
+	"name": "library/busybox",
+	"tag": "1.0",
+	"architecture": "amd64",
+	"fsLayers": [{"blobSum": "sha256:top"}, {"blobSum": "sha256:base"}],
+	"history": [
+		{"v1Compatibility": "{\"os\":\"linux\",\"architecture\":\"amd64\"}"},
+		{"v1Compatibility": "{}"}
+	]
+}`
+
+func TestParse(t *testing.T) {
+	m, err := Parse([]byte(schema2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != MediaTypeDockerSchema2 || m.Image == nil || m.IsIndex() {
+		t.Fatalf("Parse = %+v", m)
+	}
+	if c := m.Config(); c == nil || c.Digest != "sha256:c" {
+		t.Errorf("Config = %+v", c)
+	}
+	if layers := m.Layers(); len(layers) != 2 || layers[0].Digest != "sha256:l1" {
+		t.Errorf("Layers = %+v", layers)
+	}
+	if size := m.TotalSize(); size != 27002000 {
+		t.Errorf("TotalSize = %d", size)
+	}
+	if platforms := m.Platforms(); platforms != nil {
+		t.Errorf("Platforms of an image = %v", platforms)
+	}
+
+	m, err = Parse([]byte(schema1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != MediaTypeDockerSchema1 || m.Schema1 == nil || m.Config() != nil {
+		t.Fatalf("Parse = %+v", m)
+	}
+	// Layers are listed from the base one
+	want := []Descriptor{{MediaType: MediaTypeDockerLayer, Digest: "sha256:base"}, {MediaType: MediaTypeDockerLayer, Digest: "sha256:top"}}
+	if layers := m.Layers(); !reflect.DeepEqual(layers, want) {
+		t.Errorf("Layers = %+v", layers)
+	}
+	if platforms := m.Platforms(); !reflect.DeepEqual(platforms, []Platform{{Architecture: "amd64", OS: "linux"}}) {
+		t.Errorf("Platforms = %+v", platforms)
+	}
+
+	// An OCI manifest without media type
+	m, err = Parse([]byte(`{"schemaVersion": 2, "config": {"digest": "sha256:c"}, "layers": []}`))
+	if err != nil || m.MediaType != MediaTypeOCIManifest || m.Image == nil {
+		t.Errorf("Parse = %+v, %v", m, err)
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	m, err := Parse([]byte(ociIndex))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != MediaTypeOCIIndex || !m.IsIndex() || m.Config() != nil || m.Layers() != nil {
+		t.Fatalf("Parse = %+v", m)
+	}
+	if size := m.TotalSize(); size != 1530 {
+		t.Errorf("TotalSize = %d", size)
+	}
+	var platforms []string
+	for _, p := range m.Platforms() {
+		platforms = append(platforms, p.String())
+	}
+	if want := []string{"linux/amd64", "linux/arm64/v8", "windows/amd64"}; !reflect.DeepEqual(platforms, want) {
+		t.Errorf("Platforms = %v, want %v", platforms, want)
+	}
+
+	for _, tt := range []struct {
+		platform Platform
+		want     string
+	}{
+		{Platform{OS: "linux", Architecture: "amd64"}, "sha256:amd64"},
+		{Platform{OS: "linux", Architecture: "arm64"}, "sha256:arm64"},
+		{Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}, "sha256:arm64"},
+		{Platform{OS: "linux", Architecture: "arm64", Variant: "v7"}, ""},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.17763.1457"}, "sha256:windows"},
+		{Platform{OS: "windows", Architecture: "amd64", OSVersion: "10.0.14393.3930"}, ""},
+		{Platform{OS: "linux", Architecture: "s390x"}, ""},
+	} {
+		d, ok := m.ForPlatform(tt.platform)
+		if d.Digest != tt.want || ok != (tt.want != "") {
+			t.Errorf("ForPlatform(%v) = %q, %v, want %q", tt.platform, d.Digest, ok, tt.want)
+		}
+	}
+	image, _ := Parse([]byte(schema2))
+	if _, ok := image.ForPlatform(Platform{OS: "linux", Architecture: "amd64"}); ok {
+		t.Error("ForPlatform of an image succeeded")
+	}
+}
+
+func TestParseMediaType(t *testing.T) {
+	m, err := ParseMediaType(MediaTypeDockerManifestList+"; charset=utf-8", []byte(ociIndex))
+	if err != nil || m.MediaType != MediaTypeDockerManifestList || m.Index == nil {
+		t.Errorf("ParseMediaType = %+v, %v", m, err)
+	}
+	// The media type given wins over the one of the manifest
+	m, err = ParseMediaType(MediaTypeOCIManifest, []byte(schema2))
+	if err != nil || m.MediaType != MediaTypeOCIManifest || m.Image == nil {
+		t.Errorf("ParseMediaType = %+v, %v", m, err)
+	}
+
+	for mediaType, data := range map[string]string{
+		"application/vnd.cncf.helm.config.v1+json": `{}`,
+		MediaTypeDockerSchema2:                     `{"layers": {}}`,
+	} {
+		if _, err := ParseMediaType(mediaType, []byte(data)); err == nil || !strings.HasPrefix(err.Error(), "manifest: ") {
+			t.Errorf("ParseMediaType(%s, %s): %v", mediaType, data, err)
+		}
+	}
+	if _, err := Parse([]byte(`[]`)); err == nil {
+		t.Error("Parse of an array succeeded")
+	}
+}
+
+func TestParseConfig(t *testing.T) {
+	c, err := ParseConfig([]byte(`{
+		"architecture": "arm64",
+		"os": "linux",
+		"variant": "v8",
+		"created": "2020-06-01T00:00:00Z",
+		"config": {
+			"Env": ["PATH=/usr/local/bin:/usr/bin", "NGINX_VERSION=1.19.0", "EMPTY="],
+			"Entrypoint": ["/docker-entrypoint.sh"],
+			"Cmd": ["nginx", "-g", "daemon off;"]
+		},
+		"rootfs": {"type": "layers", "diff_ids": ["sha256:d1"]},
+		"history": [{"created_by": "/bin/sh -c #(nop) CMD [\"nginx\"]", "empty_layer": true}]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Platform(); p.String() != "linux/arm64/v8" {
+		t.Errorf("Platform = %v", p)
+	}
+	if c.Created == nil || c.Created.Year() != 2020 || len(c.History) != 1 || !c.History[0].EmptyLayer {
+		t.Errorf("ParseConfig = %+v", c)
+	}
+	for key, want := range map[string]string{"NGINX_VERSION": "1.19.0", "EMPTY": "", "NGINX": ""} {
+		value, ok := c.Getenv(key)
+		if value != want || ok != (key != "NGINX") {
+			t.Errorf("Getenv(%s) = %q, %v", key, value, ok)
+		}
+	}
+	if cmd := c.Command(); !reflect.DeepEqual(cmd, []string{"/docker-entrypoint.sh", "nginx", "-g", "daemon off;"}) {
+		t.Errorf("Command = %q", cmd)
+	}
+	if _, err := ParseConfig([]byte(`{"rootfs": []}`)); err == nil {
+		t.Error("ParseConfig of an invalid config succeeded")
+	}
+}
@@ -1,6 +1,7 @@
 package projects
 
 import (
+	"encoding/json"
 	"github.com/codingXiang/go-harbor-client/client"
 	"time"
 )
@@ -41,6 +42,10 @@ type AccessLog struct {
 	GUID      string    `json:"guid"`
 	Operation string    `json:"operation"`
 	OpTime    time.Time `json:"op_time"`
+	// Harbor 2.x reports the resource instead of the repository and tag
+	ID           int64  `json:"id"`
+	Resource     string `json:"resource"`
+	ResourceType string `json:"resource_type"`
 }
 
 // ProjectRequest holds informations that need for creating project API
@@ -72,4 +77,62 @@ type ListLogOptions struct {
 type MemberRequest struct {
 	UserName string `json:"username"`
 	Roles    []int  `json:"roles"`
-}
\ No newline at end of file
+}
+
+// Member is a member of a project. Harbor 1.5 and later give the members an
+// ID of their own; the users listed by Harbor 1.4 are decoded with the ID of
+// the user as member ID, which is the one its member API expects.
+type Member struct {
+	ID         int64  `json:"id"`
+	ProjectID  int64  `json:"project_id"`
+	EntityName string `json:"entity_name"`
+	EntityType string `json:"entity_type"`
+	EntityID   int64  `json:"entity_id"`
+	RoleID     int    `json:"role_id"`
+	RoleName   string `json:"role_name"`
+}
+
+// Entity types of the members
+const (
+	EntityUser  = "u"
+	EntityGroup = "g"
+)
+
+// UnmarshalJSON decodes a member of Harbor 1.5 and later, or a user of
+// Harbor 1.4.
+func (m *Member) UnmarshalJSON(data []byte) error {
+	type member Member
+	var v struct {
+		member
+		UserID   int64  `json:"user_id"`
+		Username string `json:"username"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*m = Member(v.member)
+	if m.EntityName == "" && v.Username != "" {
+		m.ID, m.EntityID, m.EntityName, m.EntityType = v.UserID, v.UserID, v.Username, EntityUser
+	}
+	return nil
+}
+
+// ProjectMemberRequest holds the user and role of a new member.
+type ProjectMemberRequest struct {
+	RoleID     int         `json:"role_id"`
+	MemberUser *MemberUser `json:"member_user,omitempty"`
+}
+
+// MemberUser is the user of a new member, by ID or username.
+type MemberUser struct {
+	UserID   int    `json:"user_id,omitempty"`
+	Username string `json:"username,omitempty"`
+}
+
+// memberRequestV1 adds the fields of Harbor 1.4 to a member request. The
+// 1.x API does not tell 1.4 from the later versions, which ignore them.
+type memberRequestV1 struct {
+	ProjectMemberRequest
+	Username string `json:"username,omitempty"`
+	Roles    []int  `json:"roles"`
+}
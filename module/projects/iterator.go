@@ -0,0 +1,177 @@
+package projects
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+// ProjectIterator walks the projects matching a ListProjectsOptions, fetching
+// the pages on demand.
+type ProjectIterator struct {
+	pager *client2.Pager
+	page  []Project
+	cur   Project
+}
+
+// Next advances to the next project, fetching the next page when needed.
+func (it *ProjectIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.pager.Next() {
+			return false
+		}
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Project returns the current project.
+func (it *ProjectIterator) Project() Project { return it.cur }
+
+// Total returns the total number of projects reported by Harbor, or -1 when
+// no page has been fetched yet.
+func (it *ProjectIterator) Total() int { return it.pager.Total() }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *ProjectIterator) Err() error { return it.pager.Err() }
+
+// Stop ends the iteration early.
+func (it *ProjectIterator) Stop() {
+	it.page = nil
+	it.pager.Stop()
+}
+
+// AccessLogIterator walks the access logs of a project, fetching the pages
+// on demand.
+type AccessLogIterator struct {
+	pager *client2.Pager
+	page  []AccessLog
+	cur   AccessLog
+}
+
+// Next advances to the next access log, fetching the next page when needed.
+func (it *AccessLogIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.pager.Next() {
+			return false
+		}
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// AccessLog returns the current access log.
+func (it *AccessLogIterator) AccessLog() AccessLog { return it.cur }
+
+// Total returns the total number of access logs reported by Harbor, or -1
+// when no page has been fetched yet.
+func (it *AccessLogIterator) Total() int { return it.pager.Total() }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *AccessLogIterator) Err() error { return it.pager.Err() }
+
+// Stop ends the iteration early.
+func (it *AccessLogIterator) Stop() {
+	it.page = nil
+	it.pager.Stop()
+}
+
+// Iterate returns an iterator over every project matching opt, following
+// the pages returned by Harbor.
+func (s *ProjectsService) Iterate(ctx context.Context, opt *ListProjectsOptions) *ProjectIterator {
+	o := ListProjectsOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	it := &ProjectIterator{}
+	it.pager = client2.NewPager(ctx, o.ListOptions, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		o.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, &o)
+		it.page = v
+		return len(v), resp, errs
+	})
+	return it
+}
+
+// ListAll returns every project matching opt. Up to concurrency pages are
+// fetched at the same time once the total count is known.
+func (s *ProjectsService) ListAll(ctx context.Context, opt *ListProjectsOptions, concurrency int) ([]Project, []error) {
+	o := ListProjectsOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	var (
+		mu    sync.Mutex
+		pages = map[int][]Project{}
+	)
+	errs := client2.FetchAll(ctx, o.ListOptions, concurrency, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		po := o
+		po.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, &po)
+		mu.Lock()
+		pages[page.Page] = v
+		mu.Unlock()
+		return len(v), resp, errs
+	})
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	keys := make([]int, 0, len(pages))
+	for page := range pages {
+		keys = append(keys, page)
+	}
+	sort.Ints(keys)
+	var all []Project
+	for _, page := range keys {
+		all = append(all, pages[page]...)
+	}
+	return all, nil
+}
+
+// IterateLogs returns an iterator over the access logs of project pid
+// matching opt.
+func (s *ProjectsService) IterateLogs(ctx context.Context, pid int64, opt ListLogOptions) *AccessLogIterator {
+	it := &AccessLogIterator{}
+	it.pager = client2.NewPager(ctx, opt.ListOptions, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		opt.ListOptions = page
+		v, resp, errs := s.GetLogContext(ctx, pid, opt)
+		it.page = v
+		return len(v), resp, errs
+	})
+	return it
+}
+
+// GetAllLogs returns every access log of project pid matching opt. Up to
+// concurrency pages are fetched at the same time once the total count is
+// known.
+func (s *ProjectsService) GetAllLogs(ctx context.Context, pid int64, opt ListLogOptions, concurrency int) ([]AccessLog, []error) {
+	var (
+		mu    sync.Mutex
+		pages = map[int][]AccessLog{}
+	)
+	errs := client2.FetchAll(ctx, opt.ListOptions, concurrency, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		po := opt
+		po.ListOptions = page
+		v, resp, errs := s.GetLogContext(ctx, pid, po)
+		mu.Lock()
+		pages[page.Page] = v
+		mu.Unlock()
+		return len(v), resp, errs
+	})
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	keys := make([]int, 0, len(pages))
+	for page := range pages {
+		keys = append(keys, page)
+	}
+	sort.Ints(keys)
+	var all []AccessLog
+	for _, page := range keys {
+		all = append(all, pages[page]...)
+	}
+	return all, nil
+}
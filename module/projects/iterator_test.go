@@ -0,0 +1,80 @@
+package projects_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+)
+
+func TestIterate(t *testing.T) {
+	srv := harbortest.Start(t)
+	for i := 0; i < 25; i++ {
+		srv.AddProject(fmt.Sprintf("project-%02d", i), true)
+	}
+	s := projects.NewProjectService(srv.Client())
+
+	it := s.Iterate(context.Background(), &projects.ListProjectsOptions{ListOptions: client.ListOptions{PageSize: 10}})
+	var names []string
+	for it.Next() {
+		names = append(names, it.Project().Name)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 25 || it.Total() != 25 {
+		t.Errorf("Iterate returned %d projects of %d: %q", len(names), it.Total(), names)
+	}
+
+	it = s.Iterate(context.Background(), &projects.ListProjectsOptions{ListOptions: client.ListOptions{PageSize: 10}})
+	for i := 0; i < 3 && it.Next(); i++ {
+	}
+	it.Stop()
+	if it.Next() {
+		t.Error("Next after Stop")
+	}
+
+	all, errs := s.ListAll(context.Background(), &projects.ListProjectsOptions{ListOptions: client.ListOptions{PageSize: 7}}, 4)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	for i, p := range all {
+		if p.Name != names[i] {
+			t.Fatalf("ListAll[%d] = %s, want %s in the order of the pages", i, p.Name, names[i])
+		}
+	}
+	if len(all) != 25 {
+		t.Errorf("ListAll returned %d projects, want 25", len(all))
+	}
+}
+
+func TestIterateLogs(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", true)
+	for i := 0; i < 12; i++ {
+		if _, err := srv.PushImage("library/nginx", fmt.Sprint(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := projects.NewProjectService(srv.Client())
+
+	opt := projects.ListLogOptions{ListOptions: client.ListOptions{PageSize: 5}, Operations: []string{"push"}}
+	it := s.IterateLogs(context.Background(), p.ProjectID, opt)
+	n := 0
+	for it.Next() {
+		if it.AccessLog().Operation != "push" {
+			t.Errorf("log %+v", it.AccessLog())
+		}
+		n++
+	}
+	if err := it.Err(); err != nil || n != 12 {
+		t.Errorf("IterateLogs returned %d logs, %v", n, err)
+	}
+	logs, errs := s.GetAllLogs(context.Background(), p.ProjectID, opt, 3)
+	if len(errs) != 0 || len(logs) != 12 {
+		t.Errorf("GetAllLogs returned %d logs, %v", len(logs), errs)
+	}
+}
@@ -1,9 +1,13 @@
 package projects
 
 import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+
 	client2 "github.com/codingXiang/go-harbor-client/client"
 	"github.com/codingXiang/go-harbor-client/module/user"
-	"fmt"
 	"github.com/parnurzeal/gorequest"
 )
 
@@ -13,6 +17,7 @@ const (
 	metadatasRoot = "api.projects.metadatas.root"
 	metadatasBase = "api.projects.metadatas.base"
 	logsRoot      = "api.projects.logs.root"
+	logsRootV2    = "api.v2.projects.logs.root"
 	membersRoot   = "api.projects.members.root"
 	membersBase   = "api.projects.members.base"
 )
@@ -24,38 +29,72 @@ const (
 type Service interface {
 	//列出所有專案
 	List(opt *ListProjectsOptions) ([]Project, *gorequest.Response, []error)
+	ListContext(ctx context.Context, opt *ListProjectsOptions) ([]Project, *gorequest.Response, []error)
 	//取得特定專案
 	Get(id int64) (Project, *gorequest.Response, []error)
+	GetContext(ctx context.Context, id int64) (Project, *gorequest.Response, []error)
 	//建立專案
 	Create(p *ProjectRequest) (*gorequest.Response, []error)
+	CreateContext(ctx context.Context, p *ProjectRequest) (*gorequest.Response, []error)
 	//更新專案
 	Update(id int64, p Project) (*gorequest.Response, []error)
+	UpdateContext(ctx context.Context, id int64, p Project) (*gorequest.Response, []error)
 	//刪除專案
 	Delete(id int64) (*gorequest.Response, []error)
+	DeleteContext(ctx context.Context, id int64) (*gorequest.Response, []error)
 	//檢查專案
 	Check(name string) (*gorequest.Response, []error)
+	CheckContext(ctx context.Context, name string) (*gorequest.Response, []error)
 	//取得 log
 	GetLog(id int64, options ListLogOptions) ([]AccessLog, *gorequest.Response, []error)
+	GetLogContext(ctx context.Context, id int64, options ListLogOptions) ([]AccessLog, *gorequest.Response, []error)
 	//透過 id 取得 metadata
 	GetMetadataById(id int64) (map[string]string, *gorequest.Response, []error)
+	GetMetadataByIdContext(ctx context.Context, id int64) (map[string]string, *gorequest.Response, []error)
 	//加入 metadata
 	AddMetadata(id int64, medadata map[string]string) (*gorequest.Response, []error)
+	AddMetadataContext(ctx context.Context, id int64, medadata map[string]string) (*gorequest.Response, []error)
 	//透過名稱取得 metadata
 	GetMetadata(id int64, name string) (map[string]string, *gorequest.Response, []error)
+	GetMetadataContext(ctx context.Context, id int64, name string) (map[string]string, *gorequest.Response, []error)
 	//更新 metadata
 	UpdateMetadata(id int64, name string) (*gorequest.Response, []error)
+	UpdateMetadataContext(ctx context.Context, id int64, name string) (*gorequest.Response, []error)
 	//刪除 metadata
 	DeleteMetadata(id int64, name string) (*gorequest.Response, []error)
+	DeleteMetadataContext(ctx context.Context, id int64, name string) (*gorequest.Response, []error)
 	//取得成員
 	GetMembers(id int64) ([]user.User, *gorequest.Response, []error)
+	GetMembersContext(ctx context.Context, id int64) ([]user.User, *gorequest.Response, []error)
 	//加入成員
 	AddMember(id int64, member MemberRequest) (*gorequest.Response, []error)
+	AddMemberContext(ctx context.Context, id int64, member MemberRequest) (*gorequest.Response, []error)
 	//更新成員角色
 	UpdateMemberRole(id int, uid int, role MemberRequest) (*gorequest.Response, []error)
+	UpdateMemberRoleContext(ctx context.Context, id int, uid int, role MemberRequest) (*gorequest.Response, []error)
 	//取得成員角色
 	GetMemberRole(id int, uid int) (Role, *gorequest.Response, []error)
+	GetMemberRoleContext(ctx context.Context, id int, uid int) (Role, *gorequest.Response, []error)
 	//刪除成員
 	DeleteMember(id int, uid int) (*gorequest.Response, []error)
+	DeleteMemberContext(ctx context.Context, id int, uid int) (*gorequest.Response, []error)
+	//列出成員
+	ListMembers(id int64) ([]Member, *gorequest.Response, []error)
+	ListMembersContext(ctx context.Context, id int64) ([]Member, *gorequest.Response, []error)
+	//新增成員
+	CreateMember(id int64, member ProjectMemberRequest) (*gorequest.Response, []error)
+	CreateMemberContext(ctx context.Context, id int64, member ProjectMemberRequest) (*gorequest.Response, []error)
+	//更新成員
+	UpdateMember(id int64, mid int64, roleID int) (*gorequest.Response, []error)
+	UpdateMemberContext(ctx context.Context, id int64, mid int64, roleID int) (*gorequest.Response, []error)
+	//逐頁列出所有專案
+	Iterate(ctx context.Context, opt *ListProjectsOptions) *ProjectIterator
+	//取得所有分頁的專案
+	ListAll(ctx context.Context, opt *ListProjectsOptions, concurrency int) ([]Project, []error)
+	//逐頁取得 log
+	IterateLogs(ctx context.Context, id int64, options ListLogOptions) *AccessLogIterator
+	//取得所有分頁的 log
+	GetAllLogs(ctx context.Context, id int64, options ListLogOptions, concurrency int) ([]AccessLog, []error)
 }
 
 type ProjectsService struct {
@@ -77,12 +116,16 @@ func (s *ProjectsService) getConfigString(key string) string {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L46
 func (s *ProjectsService) List(opt *ListProjectsOptions) ([]Project, *gorequest.Response, []error) {
+	return s.ListContext(context.Background(), opt)
+}
+
+// ListContext is like List but carries ctx to the request.
+func (s *ProjectsService) ListContext(ctx context.Context, opt *ListProjectsOptions) ([]Project, *gorequest.Response, []error) {
 	var projects []Project
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, s.getConfigString(root)).
-		Query(*opt).
-		EndStruct(&projects)
-	return projects, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(root)).
+		Query(*opt)
+	resp, errs := s.client.Do(ctx, req, &projects)
+	return projects, resp, errs
 }
 
 // Check if the project name user provided already exists.
@@ -91,11 +134,14 @@ func (s *ProjectsService) List(opt *ListProjectsOptions) ([]Project, *gorequest.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L100
 func (s *ProjectsService) Check(projectName string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.HEAD, s.getConfigString(root)).
-		Query(fmt.Sprintf("project_name=%s", projectName)).
-		End()
-	return &resp, errs
+	return s.CheckContext(context.Background(), projectName)
+}
+
+// CheckContext is like Check but carries ctx to the request.
+func (s *ProjectsService) CheckContext(ctx context.Context, projectName string) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.HEAD, s.getConfigString(root)).
+		Query(fmt.Sprintf("project_name=%s", projectName))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Create a new project.
@@ -104,11 +150,14 @@ func (s *ProjectsService) Check(projectName string) (*gorequest.Response, []erro
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L122
 func (s *ProjectsService) Create(p *ProjectRequest) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, s.getConfigString(root)).
-		Send(*p).
-		End()
-	return &resp, errs
+	return s.CreateContext(context.Background(), p)
+}
+
+// CreateContext is like Create but carries ctx to the request.
+func (s *ProjectsService) CreateContext(ctx context.Context, p *ProjectRequest) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(root)).
+		Send(*p)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Return specific project detail information.
@@ -117,11 +166,15 @@ func (s *ProjectsService) Create(p *ProjectRequest) (*gorequest.Response, []erro
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L149
 func (s *ProjectsService) Get(pid int64) (Project, *gorequest.Response, []error) {
+	return s.GetContext(context.Background(), pid)
+}
+
+// GetContext is like Get but carries ctx to the request.
+func (s *ProjectsService) GetContext(ctx context.Context, pid int64) (Project, *gorequest.Response, []error) {
 	var project Project
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), pid)).
-		EndStruct(&project)
-	return project, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), pid))
+	resp, errs := s.client.Do(ctx, req, &project)
+	return project, resp, errs
 }
 
 // Update properties for a selected project.
@@ -130,11 +183,14 @@ func (s *ProjectsService) Get(pid int64) (Project, *gorequest.Response, []error)
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L171
 func (s *ProjectsService) Update(pid int64, p Project) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(base), pid)).
-		Send(p).
-		End()
-	return &resp, errs
+	return s.UpdateContext(context.Background(), pid, p)
+}
+
+// UpdateContext is like Update but carries ctx to the request.
+func (s *ProjectsService) UpdateContext(ctx context.Context, pid int64, p Project) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(base), pid)).
+		Send(p)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Delete project by projectID.
@@ -143,10 +199,13 @@ func (s *ProjectsService) Update(pid int64, p Project) (*gorequest.Response, []e
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L203
 func (s *ProjectsService) Delete(pid int64) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), pid)).
-		End()
-	return &resp, errs
+	return s.DeleteContext(context.Background(), pid)
+}
+
+// DeleteContext is like Delete but carries ctx to the request.
+func (s *ProjectsService) DeleteContext(ctx context.Context, pid int64) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), pid))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Get access logs accompany with a relevant project.
@@ -155,12 +214,60 @@ func (s *ProjectsService) Delete(pid int64) (*gorequest.Response, []error) {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L230
 func (s *ProjectsService) GetLog(pid int64, opt ListLogOptions) ([]AccessLog, *gorequest.Response, []error) {
+	return s.GetLogContext(context.Background(), pid, opt)
+}
+
+// GetLogContext is like GetLog but carries ctx to the request.
+func (s *ProjectsService) GetLogContext(ctx context.Context, pid int64, opt ListLogOptions) ([]AccessLog, *gorequest.Response, []error) {
 	var accessLog []AccessLog
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(logsRoot), pid)).
-		Query(opt).
-		EndStruct(&accessLog)
-	return accessLog, &resp, errs
+	path := fmt.Sprintf(s.getConfigString(logsRoot), pid)
+	version, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return nil, new(gorequest.Response), []error{err}
+	}
+	if version == client2.APIVersion2 {
+		// Harbor 2.x addresses the logs of a project by its name
+		project, resp, errs := s.GetContext(ctx, pid)
+		if len(errs) != 0 {
+			return nil, resp, errs
+		}
+		path = fmt.Sprintf(s.getConfigString(logsRootV2), project.Name)
+	}
+	req := s.client.NewRequest(gorequest.GET, path).
+		Query(opt.query().Encode())
+	resp, errs := s.client.Do(ctx, req, &accessLog)
+	return accessLog, resp, errs
+}
+
+// query returns the query parameters of the options. Harbor expects an
+// operation parameter per operation, and the times as Unix timestamps.
+func (opt ListLogOptions) query() url.Values {
+	query := url.Values{}
+	if opt.Page != 0 {
+		query.Set("page", strconv.Itoa(opt.Page))
+	}
+	if opt.PageSize != 0 {
+		query.Set("page_size", strconv.Itoa(opt.PageSize))
+	}
+	if opt.Username != "" {
+		query.Set("username", opt.Username)
+	}
+	if opt.Repository != "" {
+		query.Set("repository", opt.Repository)
+	}
+	if opt.Tag != "" {
+		query.Set("tag", opt.Tag)
+	}
+	for _, op := range opt.Operations {
+		query.Add("operation", op)
+	}
+	if opt.BeginTime != nil {
+		query.Set("begin_timestamp", strconv.FormatInt(opt.BeginTime.Unix(), 10))
+	}
+	if opt.EndTime != nil {
+		query.Set("end_timestamp", strconv.FormatInt(opt.EndTime.Unix(), 10))
+	}
+	return query
 }
 
 // Get project all metadata.
@@ -169,11 +276,15 @@ func (s *ProjectsService) GetLog(pid int64, opt ListLogOptions) ([]AccessLog, *g
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L307
 func (s *ProjectsService) GetMetadataById(pid int64) (map[string]string, *gorequest.Response, []error) {
+	return s.GetMetadataByIdContext(context.Background(), pid)
+}
+
+// GetMetadataByIdContext is like GetMetadataById but carries ctx to the request.
+func (s *ProjectsService) GetMetadataByIdContext(ctx context.Context, pid int64) (map[string]string, *gorequest.Response, []error) {
 	var metadata map[string]string
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), pid)).
-		EndStruct(&metadata)
-	return metadata, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(metadatasRoot), pid))
+	resp, errs := s.client.Do(ctx, req, &metadata)
+	return metadata, resp, errs
 }
 
 // Add metadata for the project.
@@ -182,11 +293,14 @@ func (s *ProjectsService) GetMetadataById(pid int64) (map[string]string, *gorequ
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L329
 func (s *ProjectsService) AddMetadata(pid int64, metadata map[string]string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(metadatasRoot), pid)).
-		Send(metadata).
-		End()
-	return &resp, errs
+	return s.AddMetadataContext(context.Background(), pid, metadata)
+}
+
+// AddMetadataContext is like AddMetadata but carries ctx to the request.
+func (s *ProjectsService) AddMetadataContext(ctx context.Context, pid int64, metadata map[string]string) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(metadatasRoot), pid)).
+		Send(metadata)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Get project metadata
@@ -195,11 +309,15 @@ func (s *ProjectsService) AddMetadata(pid int64, metadata map[string]string) (*g
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L364
 func (s *ProjectsService) GetMetadata(pid int64, specified string) (map[string]string, *gorequest.Response, []error) {
+	return s.GetMetadataContext(context.Background(), pid, specified)
+}
+
+// GetMetadataContext is like GetMetadata but carries ctx to the request.
+func (s *ProjectsService) GetMetadataContext(ctx context.Context, pid int64, specified string) (map[string]string, *gorequest.Response, []error) {
 	var metadata map[string]string
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersBase), pid, specified)).
-		EndStruct(&metadata)
-	return metadata, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(metadatasBase), pid, specified))
+	resp, errs := s.client.Do(ctx, req, &metadata)
+	return metadata, resp, errs
 }
 
 // Update metadata of a project.
@@ -208,10 +326,13 @@ func (s *ProjectsService) GetMetadata(pid int64, specified string) (map[string]s
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L391
 func (s *ProjectsService) UpdateMetadata(pid int64, metadataName string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(metadatasBase), pid, metadataName)).
-		End()
-	return &resp, errs
+	return s.UpdateMetadataContext(context.Background(), pid, metadataName)
+}
+
+// UpdateMetadataContext is like UpdateMetadata but carries ctx to the request.
+func (s *ProjectsService) UpdateMetadataContext(ctx context.Context, pid int64, metadataName string) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(metadatasBase), pid, metadataName))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Delete metadata of a project
@@ -220,10 +341,13 @@ func (s *ProjectsService) UpdateMetadata(pid int64, metadataName string) (*goreq
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L422
 func (s *ProjectsService) DeleteMetadata(pid int64, metadataName string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(metadatasBase), pid, metadataName)).
-		End()
-	return &resp, errs
+	return s.DeleteMetadataContext(context.Background(), pid, metadataName)
+}
+
+// DeleteMetadataContext is like DeleteMetadata but carries ctx to the request.
+func (s *ProjectsService) DeleteMetadataContext(ctx context.Context, pid int64, metadataName string) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(metadatasBase), pid, metadataName))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Return a project's relevant role members.
@@ -231,12 +355,19 @@ func (s *ProjectsService) DeleteMetadata(pid int64, metadataName string) (*goreq
 // This endpoint is for user to search a specified project’s relevant role members.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L452
+//
+// Deprecated: Harbor 1.5 and later list members instead of users, use
+// ListMembers.
 func (s *ProjectsService) GetMembers(pid int64) ([]user.User, *gorequest.Response, []error) {
+	return s.GetMembersContext(context.Background(), pid)
+}
+
+// GetMembersContext is like GetMembers but carries ctx to the request.
+func (s *ProjectsService) GetMembersContext(ctx context.Context, pid int64) ([]user.User, *gorequest.Response, []error) {
 	var users []user.User
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersRoot), pid)).
-		EndStruct(&users)
-	return users, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersRoot), pid))
+	resp, errs := s.client.Do(ctx, req, &users)
+	return users, resp, errs
 }
 
 // Add project role member accompany with relevant project and user.
@@ -244,12 +375,18 @@ func (s *ProjectsService) GetMembers(pid int64) ([]user.User, *gorequest.Respons
 // This endpoint is for user to add project role member accompany with relevant project and user.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L483
+//
+// Deprecated: Harbor 1.5 and later expect a role_id and member_user, use
+// CreateMember.
 func (s *ProjectsService) AddMember(pid int64, member MemberRequest) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(membersRoot), pid)).
-		Send(member).
-		End()
-	return &resp, errs
+	return s.AddMemberContext(context.Background(), pid, member)
+}
+
+// AddMemberContext is like AddMember but carries ctx to the request.
+func (s *ProjectsService) AddMemberContext(ctx context.Context, pid int64, member MemberRequest) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(membersRoot), pid)).
+		Send(member)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Role holds the details of a role.
@@ -266,11 +403,15 @@ type Role struct {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L522
 func (s *ProjectsService) GetMemberRole(pid, uid int) (Role, *gorequest.Response, []error) {
+	return s.GetMemberRoleContext(context.Background(), pid, uid)
+}
+
+// GetMemberRoleContext is like GetMemberRole but carries ctx to the request.
+func (s *ProjectsService) GetMemberRoleContext(ctx context.Context, pid, uid int) (Role, *gorequest.Response, []error) {
 	var role Role
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersBase), pid, uid)).
-		EndStruct(&role)
-	return role, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersBase), pid, uid))
+	resp, errs := s.client.Do(ctx, req, &role)
+	return role, resp, errs
 }
 
 // Update project role members accompany with relevant project and user.
@@ -278,22 +419,100 @@ func (s *ProjectsService) GetMemberRole(pid, uid int) (Role, *gorequest.Response
 // This endpoint is for user to update current project role members accompany with relevant project and user.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L559
+//
+// Deprecated: Harbor 1.5 and later expect a role_id, use UpdateMember.
 func (s *ProjectsService) UpdateMemberRole(pid, uid int, role MemberRequest) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(membersBase), pid, uid)).
-		Send(role).
-		End()
-	return &resp, errs
+	return s.UpdateMemberRoleContext(context.Background(), pid, uid, role)
+}
+
+// UpdateMemberRoleContext is like UpdateMemberRole but carries ctx to the request.
+func (s *ProjectsService) UpdateMemberRoleContext(ctx context.Context, pid, uid int, role MemberRequest) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(membersBase), pid, uid)).
+		Send(role)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Delete project role members accompany with relevant project and user.
 //
 // This endpoint is aimed to remove project role members already added to the relevant project and user.
+// The ID of the member is Member.ID, the one of the user on Harbor 1.4.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L597
 func (s *ProjectsService) DeleteMember(pid, uid int) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(membersBase), pid, uid)).
-		End()
-	return &resp, errs
+	return s.DeleteMemberContext(context.Background(), pid, uid)
+}
+
+// DeleteMemberContext is like DeleteMember but carries ctx to the request.
+func (s *ProjectsService) DeleteMemberContext(ctx context.Context, pid, uid int) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(membersBase), pid, uid))
+	return s.client.Do(ctx, req, nil)
+}
+
+// List the members of a project.
+//
+// This endpoint lists the members of a project with their role, decoded
+// from the users of Harbor 1.4 as well as the members of the later versions.
+func (s *ProjectsService) ListMembers(pid int64) ([]Member, *gorequest.Response, []error) {
+	return s.ListMembersContext(context.Background(), pid)
+}
+
+// ListMembersContext is like ListMembers but carries ctx to the request.
+func (s *ProjectsService) ListMembersContext(ctx context.Context, pid int64) ([]Member, *gorequest.Response, []error) {
+	var members []Member
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(membersRoot), pid))
+	resp, errs := s.client.Do(ctx, req, &members)
+	return members, resp, errs
+}
+
+// Add a user to a project.
+//
+// This endpoint adds the user of the request to a project with its role.
+func (s *ProjectsService) CreateMember(pid int64, member ProjectMemberRequest) (*gorequest.Response, []error) {
+	return s.CreateMemberContext(context.Background(), pid, member)
+}
+
+// CreateMemberContext is like CreateMember but carries ctx to the request.
+func (s *ProjectsService) CreateMemberContext(ctx context.Context, pid int64, member ProjectMemberRequest) (*gorequest.Response, []error) {
+	body, err := s.memberBody(ctx, member)
+	if err != nil {
+		return new(gorequest.Response), []error{err}
+	}
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(membersRoot), pid)).
+		Send(body)
+	return s.client.Do(ctx, req, nil)
+}
+
+// Update the role of a member of a project.
+//
+// This endpoint changes the role of the member mid, Member.ID.
+func (s *ProjectsService) UpdateMember(pid, mid int64, roleID int) (*gorequest.Response, []error) {
+	return s.UpdateMemberContext(context.Background(), pid, mid, roleID)
+}
+
+// UpdateMemberContext is like UpdateMember but carries ctx to the request.
+func (s *ProjectsService) UpdateMemberContext(ctx context.Context, pid, mid int64, roleID int) (*gorequest.Response, []error) {
+	body, err := s.memberBody(ctx, ProjectMemberRequest{RoleID: roleID})
+	if err != nil {
+		return new(gorequest.Response), []error{err}
+	}
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(membersBase), pid, mid)).
+		Send(body)
+	return s.client.Do(ctx, req, nil)
+}
+
+// memberBody returns the body of a member request for the API version of
+// the server.
+func (s *ProjectsService) memberBody(ctx context.Context, member ProjectMemberRequest) (interface{}, error) {
+	version, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if version == client2.APIVersion2 {
+		return member, nil
+	}
+	v1 := memberRequestV1{ProjectMemberRequest: member, Roles: []int{member.RoleID}}
+	if member.MemberUser != nil {
+		v1.Username = member.MemberUser.Username
+	}
+	return v1, nil
 }
@@ -0,0 +1,93 @@
+package projects
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+)
+
+func TestListLogOptionsQuery(t *testing.T) {
+	begin := time.Unix(1590969600, 0)
+	end := time.Unix(1593561600, 0)
+	for _, tt := range []struct {
+		opt  ListLogOptions
+		want string
+	}{
+		{ListLogOptions{}, ""},
+		{
+			ListLogOptions{
+				ListOptions: client.ListOptions{Page: 2, PageSize: 50},
+				Username:    "admin",
+				Repository:  "library/nginx",
+				Tag:         "1.19",
+				Operations:  []string{"push", "delete"},
+				BeginTime:   &begin,
+				EndTime:     &end,
+			},
+			"begin_timestamp=1590969600&end_timestamp=1593561600&operation=push&operation=delete&page=2&page_size=50&repository=library%2Fnginx&tag=1.19&username=admin",
+		},
+	} {
+		if got := tt.opt.query().Encode(); got != tt.want {
+			t.Errorf("query() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestMembersV2(t *testing.T) {
+	f, c := fakeharbor.Start(t, client.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/projects/1/members": {Body: `[
+			{"id":11,"project_id":1,"entity_name":"admin","role_name":"projectAdmin","role_id":1,"entity_id":1,"entity_type":"u"},
+			{"id":12,"project_id":1,"entity_name":"dev","role_name":"developer","role_id":2,"entity_id":3,"entity_type":"u"}
+		]`},
+		"POST /api/v2.0/projects/1/members":   {Status: http.StatusCreated, Location: "/api/v2.0/projects/1/members/13"},
+		"PUT /api/v2.0/projects/1/members/12": {},
+	})
+	s := NewProjectService(c)
+	members, _, errs := s.ListMembers(1)
+	if want := (Member{ID: 12, ProjectID: 1, EntityName: "dev", EntityType: EntityUser, EntityID: 3, RoleID: 2, RoleName: "developer"}); len(errs) != 0 || len(members) != 2 || members[1] != want {
+		t.Fatalf("ListMembers = %+v, %v", members, errs)
+	}
+	if _, errs := s.CreateMember(1, ProjectMemberRequest{RoleID: 3, MemberUser: &MemberUser{Username: "ops"}}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("POST /api/v2.0/projects/1/members"); r.Body != `{"member_user":{"username":"ops"},"role_id":3}` {
+		t.Errorf("CreateMember body %s", r.Body)
+	}
+	if _, errs := s.UpdateMember(1, 12, 4); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("PUT /api/v2.0/projects/1/members/12"); r.Body != `{"role_id":4}` {
+		t.Errorf("UpdateMember body %s", r.Body)
+	}
+}
+
+func TestMembersV1(t *testing.T) {
+	f, c := fakeharbor.Start(t, client.APIVersion1, map[string]fakeharbor.Response{
+		// Harbor 1.4 lists the users of the project
+		"GET /api/projects/1/members":   {Body: `[{"user_id":3,"username":"dev","role_name":"developer","role_id":2}]`},
+		"POST /api/projects/1/members":  {Status: http.StatusCreated},
+		"PUT /api/projects/1/members/3": {},
+	})
+	s := NewProjectService(c)
+	members, _, errs := s.ListMembers(1)
+	if want := []Member{{ID: 3, EntityName: "dev", EntityType: EntityUser, EntityID: 3, RoleID: 2, RoleName: "developer"}}; len(errs) != 0 || !reflect.DeepEqual(members, want) {
+		t.Fatalf("ListMembers = %+v, %v", members, errs)
+	}
+	// The 1.x API does not tell 1.4 from the later versions, which get both
+	if _, errs := s.CreateMember(1, ProjectMemberRequest{RoleID: 3, MemberUser: &MemberUser{Username: "ops"}}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("POST /api/projects/1/members"); r.Body != `{"member_user":{"username":"ops"},"role_id":3,"roles":[3],"username":"ops"}` {
+		t.Errorf("CreateMember body %s", r.Body)
+	}
+	if _, errs := s.UpdateMember(1, 3, 4); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("PUT /api/projects/1/members/3"); r.Body != `{"role_id":4,"roles":[4]}` {
+		t.Errorf("UpdateMember body %s", r.Body)
+	}
+}
@@ -0,0 +1,345 @@
+// Package reconcile keeps Harbor projects, their metadata and members in
+// line with a desired state declared in YAML or JSON. A plan lists the
+// changes as a diff, so that it can be reviewed before being applied;
+// applying it again once in sync changes nothing.
+package reconcile
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+)
+
+// Action is the kind of a change.
+type Action string
+
+// Actions of the changes of a plan
+const (
+	CreateProject Action = "create-project"
+	DeleteProject Action = "delete-project"
+	SetMetadata   Action = "set-metadata"
+	AddMember     Action = "add-member"
+	UpdateMember  Action = "update-member"
+	RemoveMember  Action = "remove-member"
+)
+
+// Change is a change of a plan.
+type Change struct {
+	Action  Action
+	Project string
+	// Zero when the project is created by the plan
+	ProjectID int64
+	// Metadata of the created project
+	Metadata Metadata
+	// Metadata key of SetMetadata, username of the member changes
+	Name string
+	// Current and desired value of the metadata
+	Old, New string
+	// ID of the member of UpdateMember and RemoveMember
+	MemberID int64
+	// Current and desired role of the member
+	OldRole, NewRole Role
+}
+
+func (c Change) String() string {
+	switch c.Action {
+	case CreateProject:
+		return fmt.Sprintf("+ project %s%s", c.Project, formatMetadata(c.Metadata))
+	case DeleteProject:
+		return fmt.Sprintf("- project %s", c.Project)
+	case SetMetadata:
+		if c.Old == "" {
+			return fmt.Sprintf("~ project %s: metadata %s = %q", c.Project, c.Name, c.New)
+		}
+		return fmt.Sprintf("~ project %s: metadata %s %q -> %q", c.Project, c.Name, c.Old, c.New)
+	case AddMember:
+		return fmt.Sprintf("+ project %s: member %s as %s", c.Project, c.Name, c.NewRole)
+	case UpdateMember:
+		return fmt.Sprintf("~ project %s: member %s %s -> %s", c.Project, c.Name, c.OldRole, c.NewRole)
+	case RemoveMember:
+		return fmt.Sprintf("- project %s: member %s (%s)", c.Project, c.Name, c.OldRole)
+	}
+	return string(c.Action)
+}
+
+func formatMetadata(m Metadata) string {
+	if len(m) == 0 {
+		return ""
+	}
+	s := ""
+	for _, k := range sortedKeys(m) {
+		if s != "" {
+			s += ", "
+		}
+		s += k + "=" + m[k]
+	}
+	return " (" + s + ")"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// firstError returns the first of errs, if any.
+func firstError(errs []error) error {
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+// Plan is the list of changes bringing the projects to the desired state.
+type Plan struct {
+	Changes []Change
+}
+
+// NewPlan compares the desired state to the projects of s. With prune, the
+// projects not declared and the members not declared in their project are
+// removed; the owner of a project is never removed. Nothing is changed
+// until Apply.
+func NewPlan(ctx context.Context, s projects.Service, desired State, prune bool) (Plan, error) {
+	var plan Plan
+	if err := desired.Validate(); err != nil {
+		return plan, err
+	}
+	list, errs := s.ListAll(ctx, nil, 4)
+	if err := firstError(errs); err != nil {
+		return plan, fmt.Errorf("reconcile: list projects: %w", err)
+	}
+	current := make(map[string]projects.Project, len(list))
+	for _, p := range list {
+		current[p.Name] = p
+	}
+	for _, want := range desired.Projects {
+		p, ok := current[want.Name]
+		if !ok {
+			plan.Changes = append(plan.Changes, Change{Action: CreateProject, Project: want.Name, Metadata: want.Metadata})
+			for _, m := range want.Members {
+				plan.Changes = append(plan.Changes, Change{Action: AddMember, Project: want.Name, Name: m.Username, NewRole: m.Role})
+			}
+			continue
+		}
+		changes, err := diffProject(ctx, s, p, want, prune)
+		if err != nil {
+			return plan, err
+		}
+		plan.Changes = append(plan.Changes, changes...)
+	}
+	if prune {
+		declared := map[string]bool{}
+		for _, p := range desired.Projects {
+			declared[p.Name] = true
+		}
+		var deleted []Change
+		for _, p := range list {
+			if !declared[p.Name] {
+				deleted = append(deleted, Change{Action: DeleteProject, Project: p.Name, ProjectID: p.ProjectID})
+			}
+		}
+		sort.Slice(deleted, func(i, j int) bool { return deleted[i].Project < deleted[j].Project })
+		plan.Changes = append(plan.Changes, deleted...)
+	}
+	return plan, nil
+}
+
+// diffProject returns the changes of the metadata and members of an existing
+// project.
+func diffProject(ctx context.Context, s projects.Service, p projects.Project, want Project, prune bool) ([]Change, error) {
+	var changes []Change
+	metadata, _, errs := s.GetMetadataByIdContext(ctx, p.ProjectID)
+	if err := firstError(errs); err != nil {
+		return nil, fmt.Errorf("reconcile: get metadata of project %s: %w", p.Name, err)
+	}
+	for _, k := range sortedKeys(want.Metadata) {
+		if v := want.Metadata[k]; metadata[k] != v {
+			changes = append(changes, Change{Action: SetMetadata, Project: p.Name, ProjectID: p.ProjectID, Name: k, Old: metadata[k], New: v})
+		}
+	}
+
+	members, _, errs := s.ListMembersContext(ctx, p.ProjectID)
+	if err := firstError(errs); err != nil {
+		return nil, fmt.Errorf("reconcile: get members of project %s: %w", p.Name, err)
+	}
+	declared := map[string]bool{}
+	for _, m := range want.Members {
+		declared[m.Username] = true
+		change := Change{Action: AddMember, Project: p.Name, ProjectID: p.ProjectID, Name: m.Username, NewRole: m.Role}
+		for _, u := range members {
+			if u.EntityType != projects.EntityUser || u.EntityName != m.Username {
+				continue
+			}
+			change.Action = UpdateMember
+			change.MemberID = u.ID
+			change.OldRole = Role(u.RoleID)
+			break
+		}
+		if change.Action == AddMember || change.OldRole != change.NewRole {
+			changes = append(changes, change)
+		}
+	}
+	if prune {
+		var removed []Change
+		for _, u := range members {
+			if u.EntityType == projects.EntityUser && !declared[u.EntityName] && u.EntityName != p.OwnerName {
+				removed = append(removed, Change{Action: RemoveMember, Project: p.Name, ProjectID: p.ProjectID, Name: u.EntityName, MemberID: u.ID, OldRole: Role(u.RoleID)})
+			}
+		}
+		sort.Slice(removed, func(i, j int) bool { return removed[i].Name < removed[j].Name })
+		changes = append(changes, removed...)
+	}
+	return changes, nil
+}
+
+// Empty tells whether the projects are already in the desired state.
+func (p Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Write writes the plan as a diff, + for additions, ~ for changes and - for
+// removals.
+func (p Plan) Write(w io.Writer) error {
+	if p.Empty() {
+		_, err := fmt.Fprintln(w, "no changes, the projects are in the desired state")
+		return err
+	}
+	var add, change, remove int
+	for _, c := range p.Changes {
+		switch c.Action {
+		case CreateProject, AddMember:
+			add++
+		case SetMetadata, UpdateMember:
+			change++
+		default:
+			remove++
+		}
+		if _, err := fmt.Fprintln(w, c); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d to add, %d to change, %d to remove\n", add, change, remove)
+	return err
+}
+
+// Failure is a change that could not be applied.
+type Failure struct {
+	Change Change
+	Err    error
+}
+
+// Report is the result of the application of a plan.
+type Report struct {
+	Applied []Change
+	Failed  []Failure
+}
+
+// Apply applies the changes of the plan to the projects of s, in order. The
+// changes of a project that could not be created fail as well.
+func (p Plan) Apply(ctx context.Context, s projects.Service) Report {
+	var (
+		report  Report
+		created = map[string]int64{}
+	)
+	for _, c := range p.Changes {
+		if c.ProjectID == 0 && c.Action != CreateProject {
+			id, ok := created[c.Project]
+			if !ok {
+				report.Failed = append(report.Failed, Failure{Change: c, Err: fmt.Errorf("reconcile: project %s not created", c.Project)})
+				continue
+			}
+			c.ProjectID = id
+		}
+		if err := ctx.Err(); err != nil {
+			report.Failed = append(report.Failed, Failure{Change: c, Err: err})
+			continue
+		}
+		id, err := apply(ctx, s, c)
+		if err != nil {
+			report.Failed = append(report.Failed, Failure{Change: c, Err: err})
+			continue
+		}
+		if c.Action == CreateProject {
+			created[c.Project] = id
+			c.ProjectID = id
+		}
+		report.Applied = append(report.Applied, c)
+	}
+	return report
+}
+
+// apply applies a change, returning the ID of the project it creates.
+func apply(ctx context.Context, s projects.Service, c Change) (int64, error) {
+	var errs []error
+	switch c.Action {
+	case CreateProject:
+		resp, errs := s.CreateContext(ctx, &projects.ProjectRequest{Name: c.Project, Metadata: c.Metadata})
+		if err := firstError(errs); err != nil {
+			return 0, err
+		}
+		if id, ok := client2.LocationID(resp); ok {
+			return id, nil
+		}
+		return lookup(ctx, s, c.Project)
+	case DeleteProject:
+		_, errs = s.DeleteContext(ctx, c.ProjectID)
+	case SetMetadata:
+		_, errs = s.UpdateContext(ctx, c.ProjectID, projects.Project{Metadata: map[string]string{c.Name: c.New}})
+	case AddMember:
+		_, errs = s.CreateMemberContext(ctx, c.ProjectID, projects.ProjectMemberRequest{RoleID: int(c.NewRole), MemberUser: &projects.MemberUser{Username: c.Name}})
+	case UpdateMember:
+		_, errs = s.UpdateMemberContext(ctx, c.ProjectID, c.MemberID, int(c.NewRole))
+	case RemoveMember:
+		_, errs = s.DeleteMemberContext(ctx, int(c.ProjectID), int(c.MemberID))
+	default:
+		return 0, fmt.Errorf("reconcile: unknown action %s", c.Action)
+	}
+	return c.ProjectID, firstError(errs)
+}
+
+// lookup returns the ID of the project name, for the Harbor versions that do
+// not report it on creation.
+func lookup(ctx context.Context, s projects.Service, name string) (int64, error) {
+	list, _, errs := s.ListContext(ctx, &projects.ListProjectsOptions{Name: name})
+	if err := firstError(errs); err != nil {
+		return 0, err
+	}
+	for _, p := range list {
+		if p.Name == name {
+			return p.ProjectID, nil
+		}
+	}
+	return 0, fmt.Errorf("reconcile: project %s not found once created", name)
+}
+
+// Err returns an error summing up the failures, if any.
+func (r Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	f := r.Failed[0]
+	return fmt.Errorf("reconcile: %d changes not applied, e.g. %s: %w", len(r.Failed), f.Change, f.Err)
+}
+
+// Write writes the report in a human readable form.
+func (r Report) Write(w io.Writer) error {
+	for _, c := range r.Applied {
+		if _, err := fmt.Fprintf(w, "applied %s\n", c); err != nil {
+			return err
+		}
+	}
+	for _, f := range r.Failed {
+		if _, err := fmt.Fprintf(w, "failed  %s: %v\n", f.Change, f.Err); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d changes applied, %d failed\n", len(r.Applied), len(r.Failed))
+	return err
+}
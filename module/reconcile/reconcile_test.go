@@ -0,0 +1,239 @@
+package reconcile_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/codingXiang/go-harbor-client/module/reconcile"
+)
+
+const desired = `
+projects:
+- name: library
+  metadata:
+    public: true
+    auto_scan: true
+  members:
+  - {username: dev, role: developer}
+- name: team
+  metadata: {severity: high}
+  members:
+  - {username: dev, role: maintainer}
+`
+
+func TestPlanAndApply(t *testing.T) {
+	srv := harbortest.Start(t)
+	library := srv.AddProject("library", false)
+	legacy := srv.AddProject("legacy", false)
+	dev := srv.AddUser("dev", "Passw0rd", false)
+	ops := srv.AddUser("ops", "Passw0rd", false)
+	for _, m := range []struct {
+		project int64
+		user    int
+	}{{library.ProjectID, dev.UserID}, {library.ProjectID, ops.UserID}, {legacy.ProjectID, dev.UserID}} {
+		if err := srv.AddMember(m.project, m.user, harbortest.RoleGuest); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err := reconcile.ParseState([]byte(desired))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := projects.NewProjectService(srv.Client())
+	ctx := context.Background()
+
+	// Without prune, nothing is removed
+	plan, err := reconcile.NewPlan(ctx, s, state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range plan.Changes {
+		if c.Action == reconcile.DeleteProject || c.Action == reconcile.RemoveMember {
+			t.Errorf("plan without prune removes: %s", c)
+		}
+	}
+
+	plan, err = reconcile.NewPlan(ctx, s, state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := plan.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	want := `~ project library: metadata auto_scan = "true"
+~ project library: metadata public "false" -> "true"
+~ project library: member dev guest -> developer
+- project library: member ops (guest)
++ project team (severity=high)
++ project team: member dev as maintainer
+- project legacy
+2 to add, 3 to change, 2 to remove
+`
+	if buf.String() != want {
+		t.Errorf("plan:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	report := plan.Apply(ctx, s)
+	if err := report.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Applied) != len(plan.Changes) {
+		t.Errorf("%d changes applied of %d", len(report.Applied), len(plan.Changes))
+	}
+	for _, c := range report.Applied {
+		if c.ProjectID == 0 {
+			t.Errorf("applied %s without project ID", c)
+		}
+	}
+
+	// Once applied, the projects are in sync
+	plan, err = reconcile.NewPlan(ctx, s, state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !plan.Empty() {
+		t.Errorf("plan once applied: %+v", plan.Changes)
+	}
+	team, _, errs := s.List(&projects.ListProjectsOptions{Name: "team"})
+	if len(errs) != 0 || len(team) != 1 || team[0].Metadata["severity"] != "high" {
+		t.Errorf("team project %+v, %v", team, errs)
+	}
+	buf.Reset()
+	plan.Write(&buf)
+	if buf.String() != "no changes, the projects are in the desired state\n" {
+		t.Errorf("empty plan: %q", buf.String())
+	}
+}
+
+// Harbor 2.x lists members with an ID of their own, which updates and
+// removals address.
+func TestPlanAndApplyV2(t *testing.T) {
+	f, c := fakeharbor.Start(t, client.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/projects":             {Body: `[{"project_id":2,"name":"team","owner_name":"admin"}]`},
+		"GET /api/v2.0/projects/2/metadatas": {Body: `{"severity":"high"}`},
+		"GET /api/v2.0/projects/2/members": {Body: `[
+			{"id":20,"project_id":2,"entity_name":"admin","role_id":1,"entity_id":1,"entity_type":"u"},
+			{"id":21,"project_id":2,"entity_name":"dev","role_id":3,"entity_id":3,"entity_type":"u"},
+			{"id":22,"project_id":2,"entity_name":"ops","role_id":2,"entity_id":4,"entity_type":"u"},
+			{"id":23,"project_id":2,"entity_name":"devs","role_id":2,"entity_id":1,"entity_type":"g"}
+		]`},
+		"POST /api/v2.0/projects/2/members":      {Status: http.StatusCreated, Location: "/api/v2.0/projects/2/members/24"},
+		"PUT /api/v2.0/projects/2/members/21":    {},
+		"DELETE /api/v2.0/projects/2/members/22": {},
+	})
+	state, err := reconcile.ParseState([]byte(`
+projects:
+- name: team
+  metadata: {severity: high}
+  members:
+  - {username: dev, role: maintainer}
+  - {username: alice, role: developer}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := projects.NewProjectService(c)
+	plan, err := reconcile.NewPlan(context.Background(), s, state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := plan.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// Neither the owner nor the group is pruned
+	want := "~ project team: member dev guest -> maintainer\n" +
+		"+ project team: member alice as developer\n" +
+		"- project team: member ops (developer)\n" +
+		"1 to add, 1 to change, 1 to remove\n"
+	if buf.String() != want {
+		t.Errorf("plan:\n%s\nwant:\n%s", buf.String(), want)
+	}
+
+	if report := plan.Apply(context.Background(), s); report.Err() != nil {
+		t.Fatal(report.Err())
+	}
+	if r, _ := f.Request("PUT /api/v2.0/projects/2/members/21"); r.Body != `{"role_id":4}` {
+		t.Errorf("update of dev sent %s", r.Body)
+	}
+	if r, _ := f.Request("POST /api/v2.0/projects/2/members"); r.Body != `{"member_user":{"username":"alice"},"role_id":2}` {
+		t.Errorf("addition of alice sent %s", r.Body)
+	}
+	if _, ok := f.Request("DELETE /api/v2.0/projects/2/members/22"); !ok {
+		t.Error("ops not removed by its member ID")
+	}
+}
+
+func TestApplyFailures(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", false)
+	s := projects.NewProjectService(srv.Client())
+	ctx := context.Background()
+
+	state, err := reconcile.ParseState([]byte(`
+projects:
+- name: library
+  members: [{username: nobody, role: guest}]
+- name: team
+  members: [{username: admin, role: developer}]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan, err := reconcile.NewPlan(ctx, s, state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The project is created by someone else in the meantime
+	srv.AddProject("team", false)
+	report := plan.Apply(ctx, s)
+	if len(report.Applied) != 0 || len(report.Failed) != 3 {
+		t.Fatalf("report %+v", report)
+	}
+	// The members of a project not created fail along with it
+	if err := report.Failed[1].Err; !client.IsConflict(err) {
+		t.Errorf("creation of an existing project: %v", err)
+	}
+	if err := report.Failed[2].Err; err == nil || !strings.Contains(err.Error(), "project team not created") {
+		t.Errorf("member of the project not created: %v", err)
+	}
+	if err := report.Err(); !client.IsNotFound(err) || !strings.HasPrefix(err.Error(), "reconcile: 3 changes not applied, e.g. + project library: member nobody as guest") {
+		t.Errorf("Err() = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := report.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(buf.String(), "0 changes applied, 3 failed\n") {
+		t.Errorf("report:\n%s", buf.String())
+	}
+
+	ctxCancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	report = plan.Apply(ctxCancelled, s)
+	if len(report.Applied) != 0 || !errors.Is(report.Err(), context.Canceled) {
+		t.Errorf("Apply with a cancelled context: %+v", report)
+	}
+}
+
+func TestNewPlanErrors(t *testing.T) {
+	srv := harbortest.Start(t)
+	s := projects.NewProjectService(srv.Client())
+	invalid := reconcile.State{Projects: []reconcile.Project{{Name: "library"}, {Name: "library"}}}
+	if _, err := reconcile.NewPlan(context.Background(), s, invalid, false); err == nil {
+		t.Error("NewPlan of an invalid state succeeded")
+	}
+	srv.Inject(harbortest.Fault{Method: http.MethodGet, Path: "/api/projects", Status: http.StatusUnauthorized})
+	if _, err := reconcile.NewPlan(context.Background(), s, reconcile.State{}, false); !client.IsUnauthorized(err) {
+		t.Errorf("NewPlan when listing fails: %v", err)
+	}
+}
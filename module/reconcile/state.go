@@ -0,0 +1,165 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// State is the desired state of the projects.
+type State struct {
+	Projects []Project `json:"projects" yaml:"projects"`
+}
+
+// Project is the desired state of a project.
+type Project struct {
+	Name string `json:"name" yaml:"name"`
+	// Metadata to set, e.g. public, auto_scan, severity and prevent_vul.
+	// Metadata of the project not listed here are left untouched.
+	Metadata Metadata `json:"metadata,omitempty" yaml:"metadata,omitempty"`
+	Members  []Member `json:"members,omitempty" yaml:"members,omitempty"`
+}
+
+// Metadata holds the metadata of a project. Values may be written as
+// booleans or numbers, e.g. public: true.
+type Metadata map[string]string
+
+// UnmarshalJSON decodes the scalar values of the metadata as strings.
+func (m *Metadata) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*m = Metadata{}
+	for k, v := range raw {
+		switch v := v.(type) {
+		case string:
+			(*m)[k] = v
+		case bool, float64:
+			(*m)[k] = fmt.Sprint(v)
+		default:
+			return fmt.Errorf("reconcile: metadata %s must be a string, a boolean or a number", k)
+		}
+	}
+	return nil
+}
+
+// Member is a member of a project with its role.
+type Member struct {
+	Username string `json:"username" yaml:"username"`
+	Role     Role   `json:"role" yaml:"role"`
+}
+
+// Role is the role of a member of a project.
+type Role int
+
+// Roles of the members of a project
+const (
+	RoleProjectAdmin Role = 1
+	RoleDeveloper    Role = 2
+	RoleGuest        Role = 3
+	RoleMaintainer   Role = 4
+	RoleLimitedGuest Role = 5
+)
+
+var roleNames = map[Role]string{
+	RoleProjectAdmin: "projectAdmin",
+	RoleDeveloper:    "developer",
+	RoleGuest:        "guest",
+	RoleMaintainer:   "maintainer",
+	RoleLimitedGuest: "limitedGuest",
+}
+
+func (r Role) String() string {
+	if name, ok := roleNames[r]; ok {
+		return name
+	}
+	return strconv.Itoa(int(r))
+}
+
+// MarshalText encodes the role by its name.
+func (r Role) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText decodes a role by its name, case insensitive, or its ID.
+// master is accepted for maintainer, its name before Harbor 1.9.
+func (r *Role) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	if id, err := strconv.Atoi(s); err == nil {
+		if _, ok := roleNames[Role(id)]; ok {
+			*r = Role(id)
+			return nil
+		}
+	}
+	if strings.EqualFold(s, "master") {
+		*r = RoleMaintainer
+		return nil
+	}
+	for role, name := range roleNames {
+		if strings.EqualFold(s, name) {
+			*r = role
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown role %q", s)
+}
+
+// UnmarshalJSON decodes a role written as a name or an ID.
+func (r *Role) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	return r.UnmarshalText([]byte(fmt.Sprint(v)))
+}
+
+// ParseState decodes a desired state written in YAML or JSON.
+func ParseState(data []byte) (State, error) {
+	var s State
+	if err := yaml.UnmarshalStrict(data, &s); err != nil {
+		return s, fmt.Errorf("reconcile: invalid state: %v", err)
+	}
+	return s, s.Validate()
+}
+
+// LoadState reads the desired state of the YAML or JSON file at filename.
+func LoadState(filename string) (State, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return State{}, err
+	}
+	return ParseState(data)
+}
+
+// Validate checks that projects and their members are declared once.
+func (s State) Validate() error {
+	names := map[string]bool{}
+	for _, p := range s.Projects {
+		if p.Name == "" {
+			return fmt.Errorf("reconcile: project without name")
+		}
+		if names[p.Name] {
+			return fmt.Errorf("reconcile: project %s declared twice", p.Name)
+		}
+		names[p.Name] = true
+		members := map[string]bool{}
+		for _, m := range p.Members {
+			if m.Username == "" {
+				return fmt.Errorf("reconcile: member of project %s without username", p.Name)
+			}
+			if members[m.Username] {
+				return fmt.Errorf("reconcile: member %s of project %s declared twice", m.Username, p.Name)
+			}
+			if _, ok := roleNames[m.Role]; !ok {
+				return fmt.Errorf("reconcile: member %s of project %s without role", m.Username, p.Name)
+			}
+			members[m.Username] = true
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package reconcile
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// tempDir returns a directory removed at the end of the test.
+func tempDir(t *testing.T) string {
+	t.Helper()
+	dir, err := ioutil.TempDir("", "harbor-reconcile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestParseState(t *testing.T) {
+	want := State{Projects: []Project{
+		{
+			Name:     "library",
+			Metadata: Metadata{"public": "true", "auto_scan": "true", "severity": "high", "retention_id": "7"},
+			Members: []Member{
+				{Username: "dev", Role: RoleDeveloper},
+				{Username: "ops", Role: RoleMaintainer},
+				{Username: "qa", Role: RoleGuest},
+			},
+		},
+		{Name: "team"},
+	}}
+	for name, data := range map[string]string{
+		"yaml": `
+projects:
+- name: library
+  metadata: {public: true, auto_scan: "true", severity: high, retention_id: 7}
+  members:
+  - {username: dev, role: Developer}
+  - {username: ops, role: master}
+  - {username: qa, role: 3}
+- name: team
+`,
+		"json": `{"projects": [
+			{
+				"name": "library",
+				"metadata": {"public": true, "auto_scan": "true", "severity": "high", "retention_id": 7},
+				"members": [
+					{"username": "dev", "role": "Developer"},
+					{"username": "ops", "role": "master"},
+					{"username": "qa", "role": 3}
+				]
+			},
+			{"name": "team"}
+		]}`,
+	} {
+		s, err := ParseState([]byte(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !reflect.DeepEqual(s, want) {
+			t.Errorf("%s: ParseState =\n%+v\nwant\n%+v", name, s, want)
+		}
+	}
+
+	// The JSON decoding of the metadata matches the YAML one
+	var m Metadata
+	if err := json.Unmarshal([]byte(`{"public": false, "retention_id": 7}`), &m); err != nil || !reflect.DeepEqual(m, Metadata{"public": "false", "retention_id": "7"}) {
+		t.Errorf("Metadata = %v, %v", m, err)
+	}
+	if err := json.Unmarshal([]byte(`{"public": [true]}`), &m); err == nil {
+		t.Error("decoding metadata of a list succeeded")
+	}
+}
+
+func TestParseStateErrors(t *testing.T) {
+	for data, want := range map[string]string{
+		"project: []":                                                                          "field project not found",
+		"projects: [{metadata: {public: true}}]":                                               "project without name",
+		"projects: [{name: a}, {name: a}]":                                                     "project a declared twice",
+		"projects: [{name: a, members: [{role: guest}]}]":                                      "member of project a without username",
+		"projects: [{name: a, members: [{username: dev}]}]":                                    "member dev of project a without role",
+		"projects: [{name: a, members: [{username: dev, role: owner}]}]":                       `unknown role "owner"`,
+		"projects: [{name: a, members: [{username: dev, role: 9}]}]":                           `unknown role "9"`,
+		"projects: [{name: a, members: [{username: dev, role: 1}, {username: dev, role: 2}]}]": "member dev of project a declared twice",
+	} {
+		if _, err := ParseState([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("ParseState(%q): %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestLoadState(t *testing.T) {
+	filename := filepath.Join(tempDir(t), "projects.yaml")
+	if err := ioutil.WriteFile(filename, []byte("projects: [{name: library}]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := LoadState(filename); err != nil || len(s.Projects) != 1 {
+		t.Errorf("LoadState = %+v, %v", s, err)
+	}
+	if _, err := LoadState(filepath.Join(tempDir(t), "missing.yaml")); err == nil {
+		t.Error("LoadState of a missing file succeeded")
+	}
+}
+
+func TestRole(t *testing.T) {
+	data, err := json.Marshal([]Role{RoleProjectAdmin, RoleLimitedGuest, Role(9)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `["projectAdmin","limitedGuest","9"]`; string(data) != want {
+		t.Errorf("encoded %s, want %s", data, want)
+	}
+	var roles []Role
+	if err := json.Unmarshal([]byte(`["PROJECTADMIN", " guest ", 4, "2"]`), &roles); err != nil {
+		t.Fatal(err)
+	}
+	if want := []Role{RoleProjectAdmin, RoleGuest, RoleMaintainer, RoleDeveloper}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("decoded %v, want %v", roles, want)
+	}
+}
@@ -0,0 +1,188 @@
+package replication
+
+import (
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+)
+
+// Kinds of the trigger of a replication policy
+const (
+	TriggerManual    = "Manual"
+	TriggerImmediate = "Immediate"
+	TriggerScheduled = "Scheduled"
+)
+
+// Kinds of the filter of a replication policy
+const (
+	FilterRepository = "repository"
+	FilterTag        = "tag"
+	FilterLabel      = "label"
+)
+
+// Statuses of a replication job
+const (
+	JobPending  = "pending"
+	JobRunning  = "running"
+	JobRetrying = "retrying"
+	JobError    = "error"
+	JobStopped  = "stopped"
+	JobFinished = "finished"
+	JobCanceled = "canceled"
+)
+
+// Target holds the details of a replication target, a remote registry.
+type Target struct {
+	ID           int64     `json:"id,omitempty"`
+	Name         string    `json:"name"`
+	Endpoint     string    `json:"endpoint"`
+	Username     string    `json:"username,omitempty"`
+	Password     string    `json:"password,omitempty"`
+	Type         int       `json:"type,omitempty"`
+	Insecure     bool      `json:"insecure"`
+	CreationTime time.Time `json:"creation_time,omitempty"`
+	UpdateTime   time.Time `json:"update_time,omitempty"`
+}
+
+// PingTarget holds the target to check the connection to, either by the ID
+// of an existing target or by its endpoint and credentials.
+type PingTarget struct {
+	ID       int64  `json:"id,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Insecure bool   `json:"insecure"`
+}
+
+// ScheduleParam specifies when a scheduled policy is triggered.
+type ScheduleParam struct {
+	// daily or weekly
+	Type string `json:"type"`
+	// Day of the week of a weekly schedule, 1 for Monday
+	Weekday int8 `json:"weekday,omitempty"`
+	// Seconds after midnight UTC
+	Offtime int64 `json:"offtime"`
+}
+
+// Trigger specifies how a policy is triggered.
+type Trigger struct {
+	Kind          string         `json:"kind"`
+	ScheduleParam *ScheduleParam `json:"schedule_param,omitempty"`
+	// Cron schedule of Harbor 2.x, e.g. "0 0 2 * * *", taking precedence
+	// over ScheduleParam there
+	Cron string `json:"-"`
+}
+
+// Filter restricts the images replicated by a policy.
+type Filter struct {
+	Kind    string      `json:"kind"`
+	Pattern string      `json:"pattern,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+}
+
+// Policy holds the details of a replication policy.
+type Policy struct {
+	ID                        int64              `json:"id,omitempty"`
+	Name                      string             `json:"name"`
+	Description               string             `json:"description,omitempty"`
+	Projects                  []projects.Project `json:"projects"`
+	Targets                   []Target           `json:"targets"`
+	Trigger                   *Trigger           `json:"trigger"`
+	Filters                   []Filter           `json:"filters,omitempty"`
+	ReplicateExistingImageNow bool               `json:"replicate_existing_image_now"`
+	ReplicateDeletion         bool               `json:"replicate_deletion"`
+	CreationTime              time.Time          `json:"creation_time,omitempty"`
+	UpdateTime                time.Time          `json:"update_time,omitempty"`
+	ErrorJobCount             int64              `json:"error_job_count,omitempty"`
+}
+
+// Job holds the details of a replication job. On Harbor 2.x, a job is a
+// task of an execution of the policy.
+type Job struct {
+	ID           int64     `json:"id"`
+	ExecutionID  int64     `json:"execution_id,omitempty"`
+	Status       string    `json:"status"`
+	Repository   string    `json:"repository"`
+	PolicyID     int64     `json:"policy_id"`
+	Operation    string    `json:"operation"`
+	Tags         []string  `json:"tags"`
+	CreationTime time.Time `json:"creation_time"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+// Statuses of an execution and of its tasks, Harbor 2.x only
+const (
+	ExecutionPending    = "Pending"
+	ExecutionInProgress = "InProgress"
+	ExecutionSucceed    = "Succeed"
+	ExecutionFailed     = "Failed"
+	ExecutionStopped    = "Stopped"
+)
+
+// Execution is a run of a replication policy, Harbor 2.x only.
+type Execution struct {
+	ID         int64     `json:"id"`
+	PolicyID   int64     `json:"policy_id"`
+	Status     string    `json:"status"`
+	StatusText string    `json:"status_text,omitempty"`
+	Trigger    string    `json:"trigger"`
+	Total      int       `json:"total"`
+	Failed     int       `json:"failed"`
+	Succeed    int       `json:"succeed"`
+	InProgress int       `json:"in_progress"`
+	Stopped    int       `json:"stopped"`
+	StartTime  time.Time `json:"start_time"`
+	EndTime    time.Time `json:"end_time,omitempty"`
+}
+
+// Task is the replication of one resource by an execution, Harbor 2.x only.
+type Task struct {
+	ID           int64     `json:"id"`
+	ExecutionID  int64     `json:"execution_id"`
+	ResourceType string    `json:"resource_type"`
+	SrcResource  string    `json:"src_resource"`
+	DstResource  string    `json:"dst_resource"`
+	Operation    string    `json:"operation"`
+	Status       string    `json:"status"`
+	JobID        string    `json:"job_id"`
+	StartTime    time.Time `json:"start_time"`
+	EndTime      time.Time `json:"end_time,omitempty"`
+}
+
+// ListTargetsOptions specifies the optional parameters of ListTargets.
+type ListTargetsOptions struct {
+	Name string `json:"name,omitempty"`
+}
+
+// ListPoliciesOptions specifies the optional parameters of ListPolicies.
+type ListPoliciesOptions struct {
+	client.ListOptions
+	Name      string `json:"name,omitempty"`
+	ProjectID int64  `json:"project_id,omitempty"`
+}
+
+// ListJobsOptions specifies the parameters of ListJobs, PolicyID being
+// required by Harbor. On Harbor 2.x, the page options select the executions
+// whose tasks are listed, and the other filters apply to the tasks.
+type ListJobsOptions struct {
+	client.ListOptions
+	PolicyID   int64  `json:"policy_id"`
+	Status     string `json:"status,omitempty"`
+	Repository string `json:"repository,omitempty"`
+	// Unix times bounding the creation of the jobs
+	StartTime int64 `json:"start_time,omitempty"`
+	EndTime   int64 `json:"end_time,omitempty"`
+	// Only return the num latest jobs
+	Num int `json:"num,omitempty"`
+}
+
+// ListExecutionsOptions specifies the optional parameters of
+// ListExecutions.
+type ListExecutionsOptions struct {
+	client.ListOptions
+	PolicyID int64  `json:"policy_id,omitempty"`
+	Status   string `json:"status,omitempty"`
+	// manual, event_based or scheduled
+	Trigger string `json:"trigger,omitempty"`
+}
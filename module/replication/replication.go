@@ -0,0 +1,613 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	targetsRoot     = "api.targets.root"
+	targetsBase     = "api.targets.base"
+	targetsPing     = "api.targets.ping"
+	targetsPolicies = "api.targets.policies"
+	policiesRoot    = "api.policies.root"
+	policiesBase    = "api.policies.base"
+	replicationRoot = "api.replications.root"
+	jobsRoot        = "api.jobs.root"
+	jobsLog         = "api.jobs.log.root"
+)
+
+// ReplicationService handles communication with the replication related
+// methods of the Harbor API: targets, policies and jobs. Harbor 1.8 replaced
+// these endpoints by registries, policies of its own and executions, which
+// the same methods use against Harbor 2.x: a target is a registry, the
+// projects of a policy become its name filter, and a job is a task of an
+// execution. The executions and their tasks are only served by Harbor 2.x.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+type Service interface {
+	//列出 replication target
+	ListTargets(opt *ListTargetsOptions) ([]Target, *gorequest.Response, []error)
+	ListTargetsContext(ctx context.Context, opt *ListTargetsOptions) ([]Target, *gorequest.Response, []error)
+	//取得特定 replication target
+	GetTarget(id int64) (Target, *gorequest.Response, []error)
+	GetTargetContext(ctx context.Context, id int64) (Target, *gorequest.Response, []error)
+	//建立 replication target，回傳新 target 的 id
+	CreateTarget(t Target) (int64, *gorequest.Response, []error)
+	CreateTargetContext(ctx context.Context, t Target) (int64, *gorequest.Response, []error)
+	//更新 replication target
+	UpdateTarget(id int64, t Target) (*gorequest.Response, []error)
+	UpdateTargetContext(ctx context.Context, id int64, t Target) (*gorequest.Response, []error)
+	//刪除 replication target
+	DeleteTarget(id int64) (*gorequest.Response, []error)
+	DeleteTargetContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+	//測試 replication target 的連線
+	PingTarget(t PingTarget) (*gorequest.Response, []error)
+	PingTargetContext(ctx context.Context, t PingTarget) (*gorequest.Response, []error)
+	//列出使用 replication target 的 policy
+	GetTargetPolicies(id int64) ([]Policy, *gorequest.Response, []error)
+	GetTargetPoliciesContext(ctx context.Context, id int64) ([]Policy, *gorequest.Response, []error)
+	//列出 replication policy
+	ListPolicies(opt *ListPoliciesOptions) ([]Policy, *gorequest.Response, []error)
+	ListPoliciesContext(ctx context.Context, opt *ListPoliciesOptions) ([]Policy, *gorequest.Response, []error)
+	//取得特定 replication policy
+	GetPolicy(id int64) (Policy, *gorequest.Response, []error)
+	GetPolicyContext(ctx context.Context, id int64) (Policy, *gorequest.Response, []error)
+	//建立 replication policy，回傳新 policy 的 id
+	CreatePolicy(p Policy) (int64, *gorequest.Response, []error)
+	CreatePolicyContext(ctx context.Context, p Policy) (int64, *gorequest.Response, []error)
+	//更新 replication policy
+	UpdatePolicy(id int64, p Policy) (*gorequest.Response, []error)
+	UpdatePolicyContext(ctx context.Context, id int64, p Policy) (*gorequest.Response, []error)
+	//刪除 replication policy
+	DeletePolicy(id int64) (*gorequest.Response, []error)
+	DeletePolicyContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+	//手動觸發 replication policy
+	Trigger(policyID int64) (*gorequest.Response, []error)
+	TriggerContext(ctx context.Context, policyID int64) (*gorequest.Response, []error)
+	//列出 replication job
+	ListJobs(opt *ListJobsOptions) ([]Job, *gorequest.Response, []error)
+	ListJobsContext(ctx context.Context, opt *ListJobsOptions) ([]Job, *gorequest.Response, []error)
+	//停止 replication policy 所有執行中的 job
+	StopJobs(policyID int64) (*gorequest.Response, []error)
+	StopJobsContext(ctx context.Context, policyID int64) (*gorequest.Response, []error)
+	//取得 replication job 的 log
+	GetJobLog(id int64) (string, *gorequest.Response, []error)
+	GetJobLogContext(ctx context.Context, id int64) (string, *gorequest.Response, []error)
+	//列出 replication execution（2.x）
+	ListExecutions(opt *ListExecutionsOptions) ([]Execution, *gorequest.Response, []error)
+	ListExecutionsContext(ctx context.Context, opt *ListExecutionsOptions) ([]Execution, *gorequest.Response, []error)
+	//取得特定 replication execution（2.x）
+	GetExecution(id int64) (Execution, *gorequest.Response, []error)
+	GetExecutionContext(ctx context.Context, id int64) (Execution, *gorequest.Response, []error)
+	//停止 replication execution（2.x）
+	StopExecution(id int64) (*gorequest.Response, []error)
+	StopExecutionContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+	//列出 replication execution 的 task（2.x）
+	ListTasks(executionID int64) ([]Task, *gorequest.Response, []error)
+	ListTasksContext(ctx context.Context, executionID int64) ([]Task, *gorequest.Response, []error)
+	//取得 replication task 的 log（2.x）
+	GetTaskLog(executionID, taskID int64) (string, *gorequest.Response, []error)
+	GetTaskLogContext(ctx context.Context, executionID, taskID int64) (string, *gorequest.Response, []error)
+}
+
+type ReplicationService struct {
+	client client2.ClientInterface
+}
+
+func NewReplicationService(client client2.ClientInterface) Service {
+	return &ReplicationService{client: client}
+}
+
+func (s *ReplicationService) getConfigString(key string) string {
+	return s.client.GetConfig().GetString(key)
+}
+
+// isV2 reports whether the server speaks the 2.x API, negotiating the API
+// version with ctx if need be.
+func (s *ReplicationService) isV2(ctx context.Context) (bool, []error) {
+	v, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return false, []error{err}
+	}
+	return v == client2.APIVersion2, nil
+}
+
+// supportedV2 reports whether the server serves the executions of Harbor
+// 2.x.
+func (s *ReplicationService) supportedV2(ctx context.Context) []error {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return errs
+	}
+	if !v2 {
+		return []error{client2.ErrNotSupported}
+	}
+	return nil
+}
+
+// List replication targets.
+//
+// This endpoint lists the replication targets, filtered by name.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) ListTargets(opt *ListTargetsOptions) ([]Target, *gorequest.Response, []error) {
+	return s.ListTargetsContext(context.Background(), opt)
+}
+
+// ListTargetsContext is like ListTargets but carries ctx to the request.
+func (s *ReplicationService) ListTargetsContext(ctx context.Context, opt *ListTargetsOptions) ([]Target, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.listTargetsV2(ctx, opt)
+	}
+	var v []Target
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(targetsRoot))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Get a replication target.
+//
+// This endpoint returns the specified replication target.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) GetTarget(id int64) (Target, *gorequest.Response, []error) {
+	return s.GetTargetContext(context.Background(), id)
+}
+
+// GetTargetContext is like GetTarget but carries ctx to the request.
+func (s *ReplicationService) GetTargetContext(ctx context.Context, id int64) (Target, *gorequest.Response, []error) {
+	var v Target
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.getTargetV2(ctx, id)
+	}
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(targetsBase), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Create a replication target.
+//
+// This endpoint creates a replication target and returns its ID.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) CreateTarget(t Target) (int64, *gorequest.Response, []error) {
+	return s.CreateTargetContext(context.Background(), t)
+}
+
+// CreateTargetContext is like CreateTarget but carries ctx to the request.
+func (s *ReplicationService) CreateTargetContext(ctx context.Context, t Target) (int64, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return 0, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.createTargetV2(ctx, t)
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(targetsRoot)).
+		Send(t)
+	resp, errs := s.client.Do(ctx, req, nil)
+	id, _ := client2.LocationID(resp)
+	return id, resp, errs
+}
+
+// Update a replication target.
+//
+// This endpoint updates the name, endpoint and credentials of the specified
+// replication target.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) UpdateTarget(id int64, t Target) (*gorequest.Response, []error) {
+	return s.UpdateTargetContext(context.Background(), id, t)
+}
+
+// UpdateTargetContext is like UpdateTarget but carries ctx to the request.
+func (s *ReplicationService) UpdateTargetContext(ctx context.Context, id int64, t Target) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.updateTargetV2(ctx, id, t)
+	}
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(targetsBase), id)).
+		Send(t)
+	return s.client.Do(ctx, req, nil)
+}
+
+// Delete a replication target.
+//
+// This endpoint deletes the specified replication target, which must not be
+// used by any policy.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) DeleteTarget(id int64) (*gorequest.Response, []error) {
+	return s.DeleteTargetContext(context.Background(), id)
+}
+
+// DeleteTargetContext is like DeleteTarget but carries ctx to the request.
+func (s *ReplicationService) DeleteTargetContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.client.Do(ctx, s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(registriesBase), id)), nil)
+	}
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(targetsBase), id))
+	return s.client.Do(ctx, req, nil)
+}
+
+// Ping a replication target.
+//
+// This endpoint checks that Harbor can reach and log in to the target,
+// reporting the failure as an error.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) PingTarget(t PingTarget) (*gorequest.Response, []error) {
+	return s.PingTargetContext(context.Background(), t)
+}
+
+// PingTargetContext is like PingTarget but carries ctx to the request.
+func (s *ReplicationService) PingTargetContext(ctx context.Context, t PingTarget) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.pingTargetV2(ctx, t)
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(targetsPing)).
+		Send(t)
+	return s.client.Do(ctx, req, nil)
+}
+
+// List the policies of a replication target.
+//
+// This endpoint lists the replication policies using the specified target.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) GetTargetPolicies(id int64) ([]Policy, *gorequest.Response, []error) {
+	return s.GetTargetPoliciesContext(context.Background(), id)
+}
+
+// GetTargetPoliciesContext is like GetTargetPolicies but carries ctx to the
+// request.
+func (s *ReplicationService) GetTargetPoliciesContext(ctx context.Context, id int64) ([]Policy, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.getTargetPoliciesV2(ctx, id)
+	}
+	var v []Policy
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(targetsPolicies), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// List replication policies.
+//
+// This endpoint lists the replication policies, filtered by name and
+// project.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) ListPolicies(opt *ListPoliciesOptions) ([]Policy, *gorequest.Response, []error) {
+	return s.ListPoliciesContext(context.Background(), opt)
+}
+
+// ListPoliciesContext is like ListPolicies but carries ctx to the request.
+func (s *ReplicationService) ListPoliciesContext(ctx context.Context, opt *ListPoliciesOptions) ([]Policy, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.listPoliciesV2Options(ctx, opt)
+	}
+	var v []Policy
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(policiesRoot))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Get a replication policy.
+//
+// This endpoint returns the specified replication policy.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) GetPolicy(id int64) (Policy, *gorequest.Response, []error) {
+	return s.GetPolicyContext(context.Background(), id)
+}
+
+// GetPolicyContext is like GetPolicy but carries ctx to the request.
+func (s *ReplicationService) GetPolicyContext(ctx context.Context, id int64) (Policy, *gorequest.Response, []error) {
+	var v Policy
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.getPolicyV2(ctx, id)
+	}
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(policiesBase), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Create a replication policy.
+//
+// This endpoint creates a replication policy and returns its ID.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) CreatePolicy(p Policy) (int64, *gorequest.Response, []error) {
+	return s.CreatePolicyContext(context.Background(), p)
+}
+
+// CreatePolicyContext is like CreatePolicy but carries ctx to the request.
+func (s *ReplicationService) CreatePolicyContext(ctx context.Context, p Policy) (int64, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return 0, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.createPolicyV2(ctx, p)
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(policiesRoot)).
+		Send(p)
+	resp, errs := s.client.Do(ctx, req, nil)
+	id, _ := client2.LocationID(resp)
+	return id, resp, errs
+}
+
+// Update a replication policy.
+//
+// This endpoint replaces the specified replication policy with p.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) UpdatePolicy(id int64, p Policy) (*gorequest.Response, []error) {
+	return s.UpdatePolicyContext(context.Background(), id, p)
+}
+
+// UpdatePolicyContext is like UpdatePolicy but carries ctx to the request.
+func (s *ReplicationService) UpdatePolicyContext(ctx context.Context, id int64, p Policy) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.updatePolicyV2(ctx, id, p)
+	}
+	p.ID = id
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(policiesBase), id)).
+		Send(p)
+	return s.client.Do(ctx, req, nil)
+}
+
+// Delete a replication policy.
+//
+// This endpoint deletes the specified replication policy, which must not
+// have running jobs.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) DeletePolicy(id int64) (*gorequest.Response, []error) {
+	return s.DeletePolicyContext(context.Background(), id)
+}
+
+// DeletePolicyContext is like DeletePolicy but carries ctx to the request.
+func (s *ReplicationService) DeletePolicyContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.client.Do(ctx, s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(policiesBaseV2), id)), nil)
+	}
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(policiesBase), id))
+	return s.client.Do(ctx, req, nil)
+}
+
+// Trigger a replication policy.
+//
+// This endpoint starts the replication of the specified policy right away,
+// whatever its trigger.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) Trigger(policyID int64) (*gorequest.Response, []error) {
+	return s.TriggerContext(context.Background(), policyID)
+}
+
+// TriggerContext is like Trigger but carries ctx to the request.
+func (s *ReplicationService) TriggerContext(ctx context.Context, policyID int64) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.triggerV2(ctx, policyID)
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(replicationRoot)).
+		Send(struct {
+			PolicyID int64 `json:"policy_id"`
+		}{policyID})
+	return s.client.Do(ctx, req, nil)
+}
+
+// List replication jobs.
+//
+// This endpoint lists the jobs of a replication policy, filtered by status,
+// repository and creation time.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) ListJobs(opt *ListJobsOptions) ([]Job, *gorequest.Response, []error) {
+	return s.ListJobsContext(context.Background(), opt)
+}
+
+// ListJobsContext is like ListJobs but carries ctx to the request.
+func (s *ReplicationService) ListJobsContext(ctx context.Context, opt *ListJobsOptions) ([]Job, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.listJobsV2(ctx, opt)
+	}
+	var v []Job
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(jobsRoot))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Stop replication jobs.
+//
+// This endpoint stops the pending and running jobs of the specified
+// replication policy.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) StopJobs(policyID int64) (*gorequest.Response, []error) {
+	return s.StopJobsContext(context.Background(), policyID)
+}
+
+// StopJobsContext is like StopJobs but carries ctx to the request.
+func (s *ReplicationService) StopJobsContext(ctx context.Context, policyID int64) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.stopJobsV2(ctx, policyID)
+	}
+	req := s.client.NewRequest(gorequest.PUT, s.getConfigString(jobsRoot)).
+		Send(struct {
+			PolicyID int64  `json:"policy_id"`
+			Status   string `json:"status"`
+		}{policyID, "stop"})
+	return s.client.Do(ctx, req, nil)
+}
+
+// Get the log of a replication job.
+//
+// This endpoint returns the plain text log of the specified job. Harbor 2.x
+// addresses the log of a task by its execution too, see GetTaskLog.
+//
+// Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml
+func (s *ReplicationService) GetJobLog(id int64) (string, *gorequest.Response, []error) {
+	return s.GetJobLogContext(context.Background(), id)
+}
+
+// GetJobLogContext is like GetJobLog but carries ctx to the request.
+func (s *ReplicationService) GetJobLogContext(ctx context.Context, id int64) (string, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return "", new(gorequest.Response), errs
+	}
+	if v2 {
+		// The log of a task is addressed by its execution too
+		return "", new(gorequest.Response), []error{client2.ErrNotSupported}
+	}
+	return s.readLog(ctx, fmt.Sprintf(s.getConfigString(jobsLog), id))
+}
+
+// List replication executions.
+//
+// This endpoint lists the runs of the replication policies, filtered by
+// policy, status and trigger. It requires Harbor 2.x.
+func (s *ReplicationService) ListExecutions(opt *ListExecutionsOptions) ([]Execution, *gorequest.Response, []error) {
+	return s.ListExecutionsContext(context.Background(), opt)
+}
+
+// ListExecutionsContext is like ListExecutions but carries ctx to the
+// request.
+func (s *ReplicationService) ListExecutionsContext(ctx context.Context, opt *ListExecutionsOptions) ([]Execution, *gorequest.Response, []error) {
+	if errs := s.supportedV2(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	return s.listExecutions(ctx, opt)
+}
+
+// Get a replication execution.
+//
+// This endpoint returns the specified execution. It requires Harbor 2.x.
+func (s *ReplicationService) GetExecution(id int64) (Execution, *gorequest.Response, []error) {
+	return s.GetExecutionContext(context.Background(), id)
+}
+
+// GetExecutionContext is like GetExecution but carries ctx to the request.
+func (s *ReplicationService) GetExecutionContext(ctx context.Context, id int64) (Execution, *gorequest.Response, []error) {
+	var v Execution
+	if errs := s.supportedV2(ctx); errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(executionsBase), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Stop a replication execution.
+//
+// This endpoint stops the pending and running tasks of the specified
+// execution. It requires Harbor 2.x.
+func (s *ReplicationService) StopExecution(id int64) (*gorequest.Response, []error) {
+	return s.StopExecutionContext(context.Background(), id)
+}
+
+// StopExecutionContext is like StopExecution but carries ctx to the request.
+func (s *ReplicationService) StopExecutionContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	if errs := s.supportedV2(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	return s.stopExecution(ctx, id)
+}
+
+// List the tasks of a replication execution.
+//
+// This endpoint lists the tasks of the specified execution, one per
+// replicated resource. It requires Harbor 2.x.
+func (s *ReplicationService) ListTasks(executionID int64) ([]Task, *gorequest.Response, []error) {
+	return s.ListTasksContext(context.Background(), executionID)
+}
+
+// ListTasksContext is like ListTasks but carries ctx to the request.
+func (s *ReplicationService) ListTasksContext(ctx context.Context, executionID int64) ([]Task, *gorequest.Response, []error) {
+	if errs := s.supportedV2(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	return s.listTasks(ctx, executionID)
+}
+
+// Get the log of a replication task.
+//
+// This endpoint returns the plain text log of the specified task. It
+// requires Harbor 2.x.
+func (s *ReplicationService) GetTaskLog(executionID, taskID int64) (string, *gorequest.Response, []error) {
+	return s.GetTaskLogContext(context.Background(), executionID, taskID)
+}
+
+// GetTaskLogContext is like GetTaskLog but carries ctx to the request.
+func (s *ReplicationService) GetTaskLogContext(ctx context.Context, executionID, taskID int64) (string, *gorequest.Response, []error) {
+	if errs := s.supportedV2(ctx); errs != nil {
+		return "", new(gorequest.Response), errs
+	}
+	return s.readLog(ctx, fmt.Sprintf(s.getConfigString(executionLog), executionID, taskID))
+}
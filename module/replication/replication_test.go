@@ -0,0 +1,86 @@
+package replication
+
+import (
+	"net/http"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+)
+
+func newFakeHarbor(t *testing.T, version client2.APIVersion, routes map[string]fakeharbor.Response) (*fakeharbor.Server, Service) {
+	f, c := fakeharbor.Start(t, version, routes)
+	return f, NewReplicationService(c)
+}
+
+func decodeBody(t *testing.T, r fakeharbor.Request) map[string]interface{} {
+	t.Helper()
+	var v map[string]interface{}
+	r.Decode(t, &v)
+	return v
+}
+
+func TestTargetsV1(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion1, map[string]fakeharbor.Response{
+		"GET /api/targets":             {Body: `[{"id":1,"name":"dr","endpoint":"https://dr.example.com"}]`},
+		"POST /api/targets":            {Status: http.StatusCreated, Location: "/api/targets/7"},
+		"POST /api/targets/ping":       {},
+		"GET /api/targets/7/policies/": {Body: `[{"id":3,"name":"mirror"}]`},
+		"DELETE /api/targets/7":        {},
+	})
+
+	targets, _, errs := s.ListTargets(&ListTargetsOptions{Name: "dr"})
+	if len(errs) != 0 || len(targets) != 1 || targets[0].Endpoint != "https://dr.example.com" {
+		t.Fatalf("ListTargets = %+v, %v", targets, errs)
+	}
+	if r, _ := f.Request("GET /api/targets"); r.Query.Encode() != "name=dr" {
+		t.Errorf("ListTargets query %v", r.Query)
+	}
+	id, _, errs := s.CreateTarget(Target{Name: "dr", Endpoint: "https://dr.example.com", Username: "robot", Password: "secret"})
+	if len(errs) != 0 || id != 7 {
+		t.Fatalf("CreateTarget = %d, %v", id, errs)
+	}
+	if _, errs := s.PingTarget(PingTarget{ID: 7}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	policies, _, errs := s.GetTargetPolicies(7)
+	if len(errs) != 0 || len(policies) != 1 || policies[0].Name != "mirror" {
+		t.Fatalf("GetTargetPolicies = %+v, %v", policies, errs)
+	}
+	if _, errs := s.DeleteTarget(7); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if _, _, errs := s.ListExecutions(nil); len(errs) == 0 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("ListExecutions on 1.x: %v, want ErrNotSupported", errs)
+	}
+}
+
+func TestJobsV1(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion1, map[string]fakeharbor.Response{
+		"GET /api/jobs/replication":        {Body: `[{"id":11,"status":"finished","repository":"library/nginx","policy_id":3}]`},
+		"PUT /api/jobs/replication":        {},
+		"POST /api/replications":           {},
+		"GET /api/jobs/replication/11/log": {Body: "replicated library/nginx:1.19\n"},
+	})
+
+	if _, errs := s.Trigger(3); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("POST /api/replications"); decodeBody(t, r)["policy_id"] != float64(3) {
+		t.Errorf("Trigger body %s", r.Body)
+	}
+	jobs, _, errs := s.ListJobs(&ListJobsOptions{PolicyID: 3, Status: JobFinished})
+	if len(errs) != 0 || len(jobs) != 1 || jobs[0].Repository != "library/nginx" {
+		t.Fatalf("ListJobs = %+v, %v", jobs, errs)
+	}
+	if _, errs := s.StopJobs(3); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("PUT /api/jobs/replication"); decodeBody(t, r)["status"] != "stop" {
+		t.Errorf("StopJobs body %s", r.Body)
+	}
+	log, _, errs := s.GetJobLog(11)
+	if len(errs) != 0 || log != "replicated library/nginx:1.19\n" {
+		t.Errorf("GetJobLog = %q, %v", log, errs)
+	}
+}
@@ -0,0 +1,568 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+	"github.com/parnurzeal/gorequest"
+)
+
+// Routes of the Harbor 2.x API, where targets are registries and jobs are
+// the tasks of the executions of a policy.
+const (
+	registriesRoot = "api.v2.registries.root"
+	registriesBase = "api.v2.registries.base"
+	registriesPing = "api.v2.registries.ping"
+	policiesRootV2 = "api.v2.replication.policies.root"
+	policiesBaseV2 = "api.v2.replication.policies.base"
+	executionsRoot = "api.v2.replication.executions.root"
+	executionsBase = "api.v2.replication.executions.base"
+	executionTasks = "api.v2.replication.executions.tasks"
+	executionLog   = "api.v2.replication.executions.log"
+	projectBase    = "api.projects.base"
+)
+
+// registryV2 is a registry as handled by Harbor 2.x.
+type registryV2 struct {
+	ID           int64                 `json:"id,omitempty"`
+	Name         string                `json:"name"`
+	URL          string                `json:"url"`
+	Type         string                `json:"type"`
+	Insecure     bool                  `json:"insecure"`
+	Credential   *registryCredentialV2 `json:"credential,omitempty"`
+	CreationTime time.Time             `json:"creation_time,omitempty"`
+	UpdateTime   time.Time             `json:"update_time,omitempty"`
+}
+
+type registryCredentialV2 struct {
+	Type         string `json:"type"`
+	AccessKey    string `json:"access_key"`
+	AccessSecret string `json:"access_secret,omitempty"`
+}
+
+// registryUpdateV2 is the body of the update and ping of a registry.
+type registryUpdateV2 struct {
+	ID             int64  `json:"id,omitempty"`
+	Type           string `json:"type,omitempty"`
+	Name           string `json:"name,omitempty"`
+	URL            string `json:"url,omitempty"`
+	CredentialType string `json:"credential_type,omitempty"`
+	AccessKey      string `json:"access_key,omitempty"`
+	AccessSecret   string `json:"access_secret,omitempty"`
+	Insecure       bool   `json:"insecure"`
+}
+
+func registryFromTarget(t Target) registryV2 {
+	r := registryV2{ID: t.ID, Name: t.Name, URL: t.Endpoint, Type: "harbor", Insecure: t.Insecure}
+	if t.Username != "" {
+		r.Credential = &registryCredentialV2{Type: "basic", AccessKey: t.Username, AccessSecret: t.Password}
+	}
+	return r
+}
+
+func (r registryV2) target() Target {
+	t := Target{ID: r.ID, Name: r.Name, Endpoint: r.URL, Insecure: r.Insecure, CreationTime: r.CreationTime, UpdateTime: r.UpdateTime}
+	if r.Credential != nil {
+		t.Username = r.Credential.AccessKey
+	}
+	return t
+}
+
+// policyV2 is a replication policy as handled by Harbor 2.x.
+type policyV2 struct {
+	ID            int64       `json:"id,omitempty"`
+	Name          string      `json:"name"`
+	Description   string      `json:"description,omitempty"`
+	SrcRegistry   *registryV2 `json:"src_registry,omitempty"`
+	DestRegistry  *registryV2 `json:"dest_registry,omitempty"`
+	DestNamespace string      `json:"dest_namespace,omitempty"`
+	Trigger       *triggerV2  `json:"trigger,omitempty"`
+	Filters       []filterV2  `json:"filters,omitempty"`
+	Deletion      bool        `json:"deletion"`
+	Override      bool        `json:"override"`
+	Enabled       bool        `json:"enabled"`
+	CreationTime  time.Time   `json:"creation_time,omitempty"`
+	UpdateTime    time.Time   `json:"update_time,omitempty"`
+}
+
+type triggerV2 struct {
+	Type     string `json:"type"`
+	Settings *struct {
+		Cron string `json:"cron"`
+	} `json:"trigger_settings,omitempty"`
+}
+
+type filterV2 struct {
+	Type  string      `json:"type"`
+	Value interface{} `json:"value"`
+}
+
+// Kinds of trigger of Harbor 2.x, keyed by the ones of 1.x
+var triggersV2 = map[string]string{
+	TriggerManual:    "manual",
+	TriggerImmediate: "event_based",
+	TriggerScheduled: "scheduled",
+}
+
+// policyToV2 converts p to Harbor 2.x, where the projects and the
+// repository filter of a policy become a name filter, e.g. library/**, and
+// the schedule becomes a cron.
+func policyToV2(p Policy) policyV2 {
+	v := policyV2{
+		ID:          p.ID,
+		Name:        p.Name,
+		Description: p.Description,
+		Deletion:    p.ReplicateDeletion,
+		Override:    true,
+		Enabled:     true,
+	}
+	if len(p.Targets) != 0 {
+		v.DestRegistry = &registryV2{ID: p.Targets[0].ID}
+	}
+	repository := "**"
+	for _, f := range p.Filters {
+		switch f.Kind {
+		case FilterRepository:
+			repository = f.Pattern
+		case FilterTag:
+			v.Filters = append(v.Filters, filterV2{Type: "tag", Value: f.Pattern})
+		case FilterLabel:
+			v.Filters = append(v.Filters, filterV2{Type: "label", Value: f.Value})
+		}
+	}
+	var names []string
+	for _, project := range p.Projects {
+		names = append(names, project.Name)
+	}
+	switch len(names) {
+	case 0:
+		if repository != "**" {
+			v.Filters = append(v.Filters, filterV2{Type: "name", Value: repository})
+		}
+	case 1:
+		v.Filters = append(v.Filters, filterV2{Type: "name", Value: names[0] + "/" + repository})
+	default:
+		v.Filters = append(v.Filters, filterV2{Type: "name", Value: "{" + strings.Join(names, ",") + "}/" + repository})
+	}
+	if p.Trigger != nil {
+		v.Trigger = &triggerV2{Type: triggersV2[p.Trigger.Kind]}
+		if p.Trigger.Kind == TriggerScheduled {
+			cron := p.Trigger.Cron
+			if cron == "" && p.Trigger.ScheduleParam != nil {
+				cron = scheduleCron(*p.Trigger.ScheduleParam)
+			}
+			v.Trigger.Settings = &struct {
+				Cron string `json:"cron"`
+			}{cron}
+		}
+	}
+	return v
+}
+
+// policy converts v to the policy of Harbor 1.x.
+func (v policyV2) policy() Policy {
+	p := Policy{
+		ID:                v.ID,
+		Name:              v.Name,
+		Description:       v.Description,
+		ReplicateDeletion: v.Deletion,
+		CreationTime:      v.CreationTime,
+		UpdateTime:        v.UpdateTime,
+	}
+	if v.DestRegistry != nil {
+		p.Targets = []Target{v.DestRegistry.target()}
+	}
+	for _, f := range v.Filters {
+		switch f.Type {
+		case "name":
+			name, _ := f.Value.(string)
+			i := strings.Index(name, "/")
+			if i < 0 {
+				p.Filters = append(p.Filters, Filter{Kind: FilterRepository, Pattern: name})
+				continue
+			}
+			for _, project := range strings.Split(strings.Trim(name[:i], "{}"), ",") {
+				p.Projects = append(p.Projects, projects.Project{Name: project})
+			}
+			if repository := name[i+1:]; repository != "**" {
+				p.Filters = append(p.Filters, Filter{Kind: FilterRepository, Pattern: repository})
+			}
+		case "tag":
+			pattern, _ := f.Value.(string)
+			p.Filters = append(p.Filters, Filter{Kind: FilterTag, Pattern: pattern})
+		case "label":
+			p.Filters = append(p.Filters, Filter{Kind: FilterLabel, Value: f.Value})
+		}
+	}
+	if v.Trigger != nil {
+		p.Trigger = &Trigger{Kind: TriggerManual}
+		for kind, typ := range triggersV2 {
+			if typ == v.Trigger.Type {
+				p.Trigger.Kind = kind
+			}
+		}
+		if v.Trigger.Settings != nil && v.Trigger.Settings.Cron != "" {
+			p.Trigger.Cron = v.Trigger.Settings.Cron
+			p.Trigger.ScheduleParam = cronSchedule(v.Trigger.Settings.Cron)
+		}
+	}
+	return p
+}
+
+// scheduleCron returns the cron, with seconds, of the 1.x schedule s.
+func scheduleCron(s ScheduleParam) string {
+	offtime := s.Offtime % (24 * 3600)
+	cron := fmt.Sprintf("%d %d %d * * ", offtime%60, offtime/60%60, offtime/3600)
+	if s.Type == "weekly" {
+		return cron + strconv.Itoa(int(s.Weekday)%7)
+	}
+	return cron + "*"
+}
+
+// cronSchedule returns the 1.x schedule of cron, or nil when it is neither
+// daily nor weekly.
+func cronSchedule(cron string) *ScheduleParam {
+	fields := strings.Fields(cron)
+	if len(fields) != 6 || fields[3] != "*" || fields[4] != "*" {
+		return nil
+	}
+	var hms [3]int64
+	for i, f := range fields[:3] {
+		n, err := strconv.ParseInt(f, 10, 64)
+		if err != nil {
+			return nil
+		}
+		hms[i] = n
+	}
+	s := &ScheduleParam{Type: "daily", Offtime: hms[2]*3600 + hms[1]*60 + hms[0]}
+	if fields[5] != "*" {
+		weekday, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil
+		}
+		if weekday == 0 {
+			weekday = 7
+		}
+		s.Type, s.Weekday = "weekly", int8(weekday)
+	}
+	return s
+}
+
+// jobStatuses maps the statuses of the tasks of Harbor 2.x to the ones of
+// the jobs of 1.x.
+var jobStatuses = map[string]string{
+	ExecutionPending:    JobPending,
+	ExecutionInProgress: JobRunning,
+	ExecutionSucceed:    JobFinished,
+	ExecutionFailed:     JobError,
+	ExecutionStopped:    JobStopped,
+}
+
+func (t Task) job(policyID int64) Job {
+	status, ok := jobStatuses[t.Status]
+	if !ok {
+		status = strings.ToLower(t.Status)
+	}
+	return Job{
+		ID:           t.ID,
+		ExecutionID:  t.ExecutionID,
+		Status:       status,
+		Repository:   t.SrcResource,
+		PolicyID:     policyID,
+		Operation:    t.Operation,
+		CreationTime: t.StartTime,
+		UpdateTime:   t.EndTime,
+	}
+}
+
+func (s *ReplicationService) listTargetsV2(ctx context.Context, opt *ListTargetsOptions) ([]Target, *gorequest.Response, []error) {
+	var registries []registryV2
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(registriesRoot))
+	if opt != nil && opt.Name != "" {
+		req.Query(url.Values{"name": {opt.Name}}.Encode())
+	}
+	resp, errs := s.client.Do(ctx, req, &registries)
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	targets := make([]Target, 0, len(registries))
+	for _, r := range registries {
+		targets = append(targets, r.target())
+	}
+	return targets, resp, nil
+}
+
+func (s *ReplicationService) getTargetV2(ctx context.Context, id int64) (Target, *gorequest.Response, []error) {
+	var r registryV2
+	resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(registriesBase), id)), &r)
+	return r.target(), resp, errs
+}
+
+func (s *ReplicationService) createTargetV2(ctx context.Context, t Target) (int64, *gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(registriesRoot)).
+		Send(registryFromTarget(t))
+	resp, errs := s.client.Do(ctx, req, nil)
+	id, _ := client2.LocationID(resp)
+	return id, resp, errs
+}
+
+func (s *ReplicationService) updateTargetV2(ctx context.Context, id int64, t Target) (*gorequest.Response, []error) {
+	update := registryUpdateV2{Name: t.Name, URL: t.Endpoint, Insecure: t.Insecure}
+	if t.Username != "" {
+		update.CredentialType, update.AccessKey, update.AccessSecret = "basic", t.Username, t.Password
+	}
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(registriesBase), id)).
+		Send(update)
+	return s.client.Do(ctx, req, nil)
+}
+
+func (s *ReplicationService) pingTargetV2(ctx context.Context, t PingTarget) (*gorequest.Response, []error) {
+	ping := registryUpdateV2{ID: t.ID, URL: t.Endpoint, Insecure: t.Insecure}
+	if t.ID == 0 {
+		ping.Type = "harbor"
+	}
+	if t.Username != "" {
+		ping.CredentialType, ping.AccessKey, ping.AccessSecret = "basic", t.Username, t.Password
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(registriesPing)).
+		Send(ping)
+	return s.client.Do(ctx, req, nil)
+}
+
+// listPoliciesV2 lists the policies whose name contains name, on every page.
+func (s *ReplicationService) listPoliciesV2(ctx context.Context, name string) ([]policyV2, *gorequest.Response, []error) {
+	var (
+		policies []policyV2
+		last     *gorequest.Response
+	)
+	pager := client2.NewPager(ctx, client2.ListOptions{PageSize: 100}, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		query := url.Values{"page": {strconv.Itoa(page.Page)}, "page_size": {strconv.Itoa(page.PageSize)}}
+		if name != "" {
+			query.Set("name", name)
+		}
+		var v []policyV2
+		resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, s.getConfigString(policiesRootV2)).Query(query.Encode()), &v)
+		last = resp
+		policies = append(policies, v...)
+		return len(v), resp, errs
+	})
+	for pager.Next() {
+	}
+	if err := pager.Err(); err != nil {
+		return nil, last, []error{err}
+	}
+	return policies, last, nil
+}
+
+// getTargetPoliciesV2 lists the policies replicating from or to the
+// registry id, which Harbor 2.x has no endpoint for.
+func (s *ReplicationService) getTargetPoliciesV2(ctx context.Context, id int64) ([]Policy, *gorequest.Response, []error) {
+	all, resp, errs := s.listPoliciesV2(ctx, "")
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	var policies []Policy
+	for _, v := range all {
+		if (v.DestRegistry != nil && v.DestRegistry.ID == id) || (v.SrcRegistry != nil && v.SrcRegistry.ID == id) {
+			policies = append(policies, v.policy())
+		}
+	}
+	return policies, resp, nil
+}
+
+// listPoliciesV2Options lists the policies filtered by opt. Harbor 2.x
+// does not filter them by project, so the ones replicating the project of
+// opt.ProjectID are selected here.
+func (s *ReplicationService) listPoliciesV2Options(ctx context.Context, opt *ListPoliciesOptions) ([]Policy, *gorequest.Response, []error) {
+	if opt == nil {
+		opt = &ListPoliciesOptions{}
+	}
+	var project string
+	if opt.ProjectID != 0 {
+		var p projects.Project
+		resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(projectBase), opt.ProjectID)), &p)
+		if len(errs) != 0 {
+			return nil, resp, errs
+		}
+		project = p.Name
+	}
+	all, resp, errs := s.listPoliciesV2(ctx, opt.Name)
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	policies := make([]Policy, 0, len(all))
+	for _, v := range all {
+		p := v.policy()
+		if project != "" && !replicatesProject(p, project) {
+			continue
+		}
+		policies = append(policies, p)
+	}
+	return policies, resp, nil
+}
+
+func replicatesProject(p Policy, name string) bool {
+	for _, project := range p.Projects {
+		if project.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *ReplicationService) getPolicyV2(ctx context.Context, id int64) (Policy, *gorequest.Response, []error) {
+	var v policyV2
+	resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(policiesBaseV2), id)), &v)
+	if len(errs) != 0 {
+		return Policy{}, resp, errs
+	}
+	return v.policy(), resp, nil
+}
+
+// createPolicyV2 creates p, and triggers it right away when it replicates
+// the existing images, which Harbor 2.x leaves to the caller.
+func (s *ReplicationService) createPolicyV2(ctx context.Context, p Policy) (int64, *gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(policiesRootV2)).
+		Send(policyToV2(p))
+	resp, errs := s.client.Do(ctx, req, nil)
+	id, _ := client2.LocationID(resp)
+	if len(errs) != 0 || !p.ReplicateExistingImageNow || id == 0 {
+		return id, resp, errs
+	}
+	if resp, errs := s.triggerV2(ctx, id); len(errs) != 0 {
+		return id, resp, errs
+	}
+	return id, resp, nil
+}
+
+// updatePolicyV2 updates the policy id with p, keeping the settings of
+// Harbor 2.x that Policy does not carry: whether the policy is enabled and
+// overrides the existing images, its destination namespace, and the source
+// registry of a policy pulling images into Harbor.
+func (s *ReplicationService) updatePolicyV2(ctx context.Context, id int64, p Policy) (*gorequest.Response, []error) {
+	var current policyV2
+	resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(policiesBaseV2), id)), &current)
+	if len(errs) != 0 {
+		return resp, errs
+	}
+	p.ID = id
+	v := policyToV2(p)
+	v.Enabled, v.Override = current.Enabled, current.Override
+	v.DestNamespace = current.DestNamespace
+	if current.SrcRegistry != nil {
+		// The destination of a pull-based policy is the local registry
+		v.SrcRegistry, v.DestRegistry = current.SrcRegistry, current.DestRegistry
+	}
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(policiesBaseV2), id)).
+		Send(v)
+	return s.client.Do(ctx, req, nil)
+}
+
+func (s *ReplicationService) triggerV2(ctx context.Context, policyID int64) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(executionsRoot)).
+		Send(struct {
+			PolicyID int64 `json:"policy_id"`
+		}{policyID})
+	return s.client.Do(ctx, req, nil)
+}
+
+func (s *ReplicationService) listExecutions(ctx context.Context, opt *ListExecutionsOptions) ([]Execution, *gorequest.Response, []error) {
+	var v []Execution
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(executionsRoot))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// listTasks lists the tasks of the execution id, on every page.
+func (s *ReplicationService) listTasks(ctx context.Context, id int64) ([]Task, *gorequest.Response, []error) {
+	var (
+		tasks []Task
+		last  *gorequest.Response
+	)
+	pager := client2.NewPager(ctx, client2.ListOptions{PageSize: 100}, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		var v []Task
+		resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(executionTasks), id)).Query(page), &v)
+		last = resp
+		tasks = append(tasks, v...)
+		return len(v), resp, errs
+	})
+	for pager.Next() {
+	}
+	if err := pager.Err(); err != nil {
+		return nil, last, []error{err}
+	}
+	return tasks, last, nil
+}
+
+// listJobsV2 lists the tasks of the executions of the policy of opt, as
+// jobs, the latest first.
+func (s *ReplicationService) listJobsV2(ctx context.Context, opt *ListJobsOptions) ([]Job, *gorequest.Response, []error) {
+	if opt == nil {
+		opt = &ListJobsOptions{}
+	}
+	executions, resp, errs := s.listExecutions(ctx, &ListExecutionsOptions{ListOptions: opt.ListOptions, PolicyID: opt.PolicyID})
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	var jobs []Job
+	for _, e := range executions {
+		if opt.StartTime != 0 && e.StartTime.Unix() < opt.StartTime || opt.EndTime != 0 && e.StartTime.Unix() > opt.EndTime {
+			continue
+		}
+		tasks, resp, errs := s.listTasks(ctx, e.ID)
+		if len(errs) != 0 {
+			return nil, resp, errs
+		}
+		for _, t := range tasks {
+			job := t.job(e.PolicyID)
+			if opt.Status != "" && job.Status != opt.Status || opt.Repository != "" && !strings.Contains(job.Repository, opt.Repository) {
+				continue
+			}
+			jobs = append(jobs, job)
+			if opt.Num > 0 && len(jobs) == opt.Num {
+				return jobs, resp, nil
+			}
+		}
+	}
+	return jobs, resp, nil
+}
+
+// stopJobsV2 stops the executions of the policy in progress.
+func (s *ReplicationService) stopJobsV2(ctx context.Context, policyID int64) (*gorequest.Response, []error) {
+	executions, resp, errs := s.listExecutions(ctx, &ListExecutionsOptions{PolicyID: policyID, Status: ExecutionInProgress})
+	if len(errs) != 0 {
+		return resp, errs
+	}
+	for _, e := range executions {
+		if resp, errs = s.stopExecution(ctx, e.ID); len(errs) != 0 {
+			return resp, errs
+		}
+	}
+	return resp, nil
+}
+
+func (s *ReplicationService) stopExecution(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	return s.client.Do(ctx, s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(executionsBase), id)), nil)
+}
+
+func (s *ReplicationService) readLog(ctx context.Context, path string) (string, *gorequest.Response, []error) {
+	resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, path), nil)
+	if len(errs) != 0 {
+		return "", resp, errs
+	}
+	body, err := ioutil.ReadAll((*resp).Body)
+	if err != nil {
+		return "", resp, []error{err}
+	}
+	return string(body), resp, nil
+}
@@ -0,0 +1,267 @@
+package replication
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+	"github.com/codingXiang/go-harbor-client/module/projects"
+)
+
+func TestScheduleCron(t *testing.T) {
+	for _, tt := range []struct {
+		schedule ScheduleParam
+		cron     string
+	}{
+		{ScheduleParam{Type: "daily", Offtime: 0}, "0 0 0 * * *"},
+		{ScheduleParam{Type: "daily", Offtime: 2*3600 + 30*60 + 15}, "15 30 2 * * *"},
+		{ScheduleParam{Type: "weekly", Weekday: 1, Offtime: 3600}, "0 0 1 * * 1"},
+		{ScheduleParam{Type: "weekly", Weekday: 7, Offtime: 3600}, "0 0 1 * * 0"},
+	} {
+		if got := scheduleCron(tt.schedule); got != tt.cron {
+			t.Errorf("scheduleCron(%+v) = %q, want %q", tt.schedule, got, tt.cron)
+		}
+		if got := cronSchedule(tt.cron); got == nil || *got != tt.schedule {
+			t.Errorf("cronSchedule(%q) = %+v, want %+v", tt.cron, got, tt.schedule)
+		}
+	}
+	for _, cron := range []string{"", "0 0 2 1 * *", "0 */5 * * * *", "0 0 2 * *"} {
+		if got := cronSchedule(cron); got != nil {
+			t.Errorf("cronSchedule(%q) = %+v, want nil", cron, got)
+		}
+	}
+}
+
+func TestPolicyV2RoundTrip(t *testing.T) {
+	for _, p := range []Policy{
+		{
+			ID:       3,
+			Name:     "mirror",
+			Projects: []projects.Project{{Name: "library"}},
+			Targets:  []Target{{ID: 7}},
+			Trigger:  &Trigger{Kind: TriggerImmediate},
+		},
+		{
+			Name:     "nightly",
+			Projects: []projects.Project{{Name: "library"}, {Name: "tools"}},
+			Targets:  []Target{{ID: 7}},
+			Trigger: &Trigger{
+				Kind:          TriggerScheduled,
+				ScheduleParam: &ScheduleParam{Type: "daily", Offtime: 7200},
+				Cron:          "0 0 2 * * *",
+			},
+			Filters: []Filter{
+				{Kind: FilterTag, Pattern: "v*"},
+				{Kind: FilterRepository, Pattern: "nginx*"},
+			},
+			ReplicateDeletion: true,
+		},
+	} {
+		got := policyToV2(p).policy()
+		if !reflect.DeepEqual(got, p) {
+			t.Errorf("policy %s after a round trip through 2.x:\n got %+v\nwant %+v", p.Name, got, p)
+		}
+	}
+
+	v := policyToV2(Policy{Projects: []projects.Project{{Name: "library"}}, Filters: []Filter{{Kind: FilterRepository, Pattern: "nginx"}}})
+	if want := []filterV2{{Type: "name", Value: "library/nginx"}}; !reflect.DeepEqual(v.Filters, want) {
+		t.Errorf("filters %+v, want %+v", v.Filters, want)
+	}
+}
+
+func TestTargetsV2(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/registries":       {Body: `[{"id":7,"name":"dr","url":"https://dr.example.com","type":"harbor","credential":{"type":"basic","access_key":"robot"}}]`},
+		"POST /api/v2.0/registries":      {Status: http.StatusCreated, Location: "/api/v2.0/registries/7"},
+		"PUT /api/v2.0/registries/7":     {},
+		"POST /api/v2.0/registries/ping": {},
+		"DELETE /api/v2.0/registries/7":  {},
+		"GET /api/v2.0/replication/policies": {Body: `[
+			{"id":3,"name":"to-dr","dest_registry":{"id":7},"filters":[{"type":"name","value":"library/**"}]},
+			{"id":4,"name":"from-dr","src_registry":{"id":7}},
+			{"id":5,"name":"elsewhere","dest_registry":{"id":8}}
+		]`},
+	})
+
+	targets, _, errs := s.ListTargets(&ListTargetsOptions{Name: "dr"})
+	want := []Target{{ID: 7, Name: "dr", Endpoint: "https://dr.example.com", Username: "robot"}}
+	if len(errs) != 0 || !reflect.DeepEqual(targets, want) {
+		t.Fatalf("ListTargets = %+v, %v", targets, errs)
+	}
+
+	id, _, errs := s.CreateTarget(Target{Name: "dr", Endpoint: "https://dr.example.com", Username: "robot", Password: "secret"})
+	if len(errs) != 0 || id != 7 {
+		t.Fatalf("CreateTarget = %d, %v", id, errs)
+	}
+	r, _ := f.Request("POST /api/v2.0/registries")
+	body := decodeBody(t, r)
+	credential, _ := body["credential"].(map[string]interface{})
+	if body["url"] != "https://dr.example.com" || body["type"] != "harbor" || credential["access_key"] != "robot" || credential["access_secret"] != "secret" {
+		t.Errorf("CreateTarget body %s", r.Body)
+	}
+
+	if _, errs := s.UpdateTarget(7, Target{Name: "dr", Endpoint: "https://dr2.example.com", Username: "robot", Password: "new"}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("PUT /api/v2.0/registries/7"); decodeBody(t, r)["access_secret"] != "new" {
+		t.Errorf("UpdateTarget body %s", r.Body)
+	}
+	if _, errs := s.PingTarget(PingTarget{ID: 7}); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+
+	policies, _, errs := s.GetTargetPolicies(7)
+	if len(errs) != 0 || len(policies) != 2 || policies[0].Name != "to-dr" || policies[1].Name != "from-dr" {
+		t.Fatalf("GetTargetPolicies = %+v, %v", policies, errs)
+	}
+	if _, errs := s.DeleteTarget(7); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestPoliciesV2(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/projects/1": {Body: `{"project_id":1,"name":"library"}`},
+		"GET /api/v2.0/replication/policies": {Body: `[
+			{"id":3,"name":"library","filters":[{"type":"name","value":"library/**"}]},
+			{"id":4,"name":"tools","filters":[{"type":"name","value":"tools/**"}]}
+		]`},
+		"POST /api/v2.0/replication/policies":     {Status: http.StatusCreated, Location: "/api/v2.0/replication/policies/9"},
+		"POST /api/v2.0/replication/executions":   {Status: http.StatusCreated, Location: "/api/v2.0/replication/executions/20"},
+		"GET /api/v2.0/replication/policies/9":    {Body: `{"id":9,"name":"mirror","dest_registry":{"id":7},"enabled":true,"override":true}`},
+		"PUT /api/v2.0/replication/policies/9":    {},
+		"DELETE /api/v2.0/replication/policies/9": {},
+	})
+
+	policies, _, errs := s.ListPolicies(&ListPoliciesOptions{ProjectID: 1})
+	if len(errs) != 0 || len(policies) != 1 || policies[0].ID != 3 {
+		t.Fatalf("ListPolicies(project 1) = %+v, %v", policies, errs)
+	}
+
+	p := Policy{
+		Name:                      "mirror",
+		Projects:                  []projects.Project{{Name: "library"}},
+		Targets:                   []Target{{ID: 7}},
+		Trigger:                   &Trigger{Kind: TriggerScheduled, ScheduleParam: &ScheduleParam{Type: "weekly", Weekday: 6, Offtime: 3600}},
+		ReplicateExistingImageNow: true,
+	}
+	id, _, errs := s.CreatePolicy(p)
+	if len(errs) != 0 || id != 9 {
+		t.Fatalf("CreatePolicy = %d, %v", id, errs)
+	}
+	r, _ := f.Request("POST /api/v2.0/replication/policies")
+	body := decodeBody(t, r)
+	trigger, _ := body["trigger"].(map[string]interface{})
+	settings, _ := trigger["trigger_settings"].(map[string]interface{})
+	if trigger["type"] != "scheduled" || settings["cron"] != "0 0 1 * * 6" || body["enabled"] != true {
+		t.Errorf("CreatePolicy body %s", r.Body)
+	}
+	if r, ok := f.Request("POST /api/v2.0/replication/executions"); !ok || decodeBody(t, r)["policy_id"] != float64(9) {
+		t.Errorf("the existing images of the new policy were not replicated")
+	}
+
+	if _, errs := s.UpdatePolicy(9, p); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("PUT /api/v2.0/replication/policies/9"); decodeBody(t, r)["id"] != float64(9) || decodeBody(t, r)["enabled"] != true {
+		t.Errorf("UpdatePolicy body %s", r.Body)
+	}
+	if _, errs := s.DeletePolicy(9); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+}
+
+func TestUpdatePullPolicyV2(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/replication/policies/5": {Body: `{
+			"id":5,"name":"pull-hub",
+			"src_registry":{"id":2,"name":"hub","url":"https://hub.docker.com","type":"docker-hub","insecure":false},
+			"dest_registry":{"id":0,"name":"Local","url":"http://core:8080","type":"harbor","insecure":true},
+			"dest_namespace":"mirror",
+			"filters":[{"type":"name","value":"library/nginx"}],
+			"trigger":{"type":"manual"},
+			"deletion":false,"override":false,"enabled":false
+		}`},
+		"PUT /api/v2.0/replication/policies/5": {},
+	})
+	p, _, errs := s.GetPolicy(5)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	p.Description = "mirror of nginx"
+	if _, errs := s.UpdatePolicy(5, p); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	r, _ := f.Request("PUT /api/v2.0/replication/policies/5")
+	var got policyV2
+	r.Decode(t, &got)
+	if got.Description != "mirror of nginx" || got.Enabled || got.Override || got.DestNamespace != "mirror" {
+		t.Errorf("UpdatePolicy body %s", r.Body)
+	}
+	if got.SrcRegistry == nil || got.SrcRegistry.ID != 2 || got.DestRegistry == nil || got.DestRegistry.ID != 0 || got.DestRegistry.Name != "Local" {
+		t.Errorf("UpdatePolicy registries %s", r.Body)
+	}
+}
+
+func TestJobsV2(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/replication/executions": {Body: `[
+			{"id":21,"policy_id":3,"status":"InProgress","start_time":"2020-06-02T00:00:00Z"},
+			{"id":20,"policy_id":3,"status":"Succeed","start_time":"2020-06-01T00:00:00Z"}
+		]`},
+		"GET /api/v2.0/replication/executions/21/tasks": {Body: `[
+			{"id":211,"execution_id":21,"src_resource":"library/nginx:[1.19]","operation":"copy","status":"InProgress"}
+		]`},
+		"GET /api/v2.0/replication/executions/20/tasks": {Body: `[
+			{"id":201,"execution_id":20,"src_resource":"library/nginx:[1.18]","operation":"copy","status":"Succeed"},
+			{"id":202,"execution_id":20,"src_resource":"library/redis:[6]","operation":"copy","status":"Failed"}
+		]`},
+		"PUT /api/v2.0/replication/executions/21":               {},
+		"GET /api/v2.0/replication/executions/20/tasks/202/log": {Body: "unauthorized\n"},
+	})
+
+	jobs, _, errs := s.ListJobs(&ListJobsOptions{PolicyID: 3})
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	var got []string
+	for _, j := range jobs {
+		got = append(got, j.Status+" "+j.Repository)
+		if j.PolicyID != 3 {
+			t.Errorf("job %d of policy %d, want 3", j.ID, j.PolicyID)
+		}
+	}
+	want := []string{"running library/nginx:[1.19]", "finished library/nginx:[1.18]", "error library/redis:[6]"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListJobs = %q, want %q", got, want)
+	}
+	if r, _ := f.Request("GET /api/v2.0/replication/executions"); r.Query.Encode() != "policy_id=3" {
+		t.Errorf("executions query %v", r.Query)
+	}
+
+	jobs, _, errs = s.ListJobs(&ListJobsOptions{PolicyID: 3, Status: JobError})
+	if len(errs) != 0 || len(jobs) != 1 || jobs[0].ID != 202 || jobs[0].ExecutionID != 20 {
+		t.Fatalf("ListJobs(error) = %+v, %v", jobs, errs)
+	}
+
+	f.SetRoute("GET /api/v2.0/replication/executions", fakeharbor.Response{Body: `[{"id":21,"policy_id":3,"status":"InProgress"}]`})
+	if _, errs := s.StopJobs(3); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if r, _ := f.Request("GET /api/v2.0/replication/executions"); r.Query.Encode() != "policy_id=3&status=InProgress" {
+		t.Errorf("StopJobs query %v", r.Query)
+	}
+	if _, ok := f.Request("PUT /api/v2.0/replication/executions/21"); !ok {
+		t.Error("StopJobs did not stop the execution in progress")
+	}
+
+	log, _, errs := s.GetTaskLog(20, 202)
+	if len(errs) != 0 || log != "unauthorized\n" {
+		t.Errorf("GetTaskLog = %q, %v", log, errs)
+	}
+	if _, _, errs := s.GetJobLog(202); len(errs) == 0 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("GetJobLog on 2.x: %v, want ErrNotSupported", errs)
+	}
+}
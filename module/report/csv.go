@@ -0,0 +1,24 @@
+package report
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+var csvHeader = []string{"repository", "tag", "digest", "id", "severity", "package", "version", "fixed_version", "link", "description"}
+
+// WriteCSV exports s as CSV, with a header and a row per vulnerability.
+func WriteCSV(w io.Writer, s Scan) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, item := range s.sorted() {
+		row := []string{s.Repository, s.Tag, s.Digest, item.ID, item.Severity.String(), item.Pkg, item.Version, item.Fixed, item.Link, item.Description}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
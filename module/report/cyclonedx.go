@@ -0,0 +1,178 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+const cycloneDXVersion = "1.4"
+
+type cdxBOM struct {
+	BOMFormat       string             `json:"bomFormat"`
+	SpecVersion     string             `json:"specVersion"`
+	Version         int                `json:"version"`
+	Metadata        cdxMetadata        `json:"metadata"`
+	Components      []cdxComponent     `json:"components"`
+	Vulnerabilities []cdxVulnerability `json:"vulnerabilities"`
+}
+
+type cdxMetadata struct {
+	Tools     []cdxTool    `json:"tools"`
+	Component cdxComponent `json:"component"`
+}
+
+type cdxTool struct {
+	Vendor string `json:"vendor"`
+	Name   string `json:"name"`
+}
+
+type cdxComponent struct {
+	BOMRef  string    `json:"bom-ref"`
+	Type    string    `json:"type"`
+	Name    string    `json:"name"`
+	Version string    `json:"version,omitempty"`
+	Hashes  []cdxHash `json:"hashes,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxVulnerability struct {
+	BOMRef         string        `json:"bom-ref"`
+	ID             string        `json:"id"`
+	Source         *cdxSource    `json:"source,omitempty"`
+	Ratings        []cdxRating   `json:"ratings"`
+	Description    string        `json:"description,omitempty"`
+	Recommendation string        `json:"recommendation,omitempty"`
+	Advisories     []cdxAdvisory `json:"advisories,omitempty"`
+	Affects        []cdxAffect   `json:"affects"`
+	Analysis       cdxAnalysis   `json:"analysis"`
+}
+
+type cdxSource struct {
+	URL string `json:"url"`
+}
+
+type cdxRating struct {
+	Severity string `json:"severity"`
+	Method   string `json:"method"`
+}
+
+type cdxAdvisory struct {
+	URL string `json:"url"`
+}
+
+type cdxAffect struct {
+	Ref string `json:"ref"`
+}
+
+type cdxAnalysis struct {
+	State string `json:"state"`
+}
+
+// cdxSeverity maps a severity to the ones of CycloneDX.
+func cdxSeverity(sev repositories.Severity) string {
+	if sev == repositories.SeverityNegligible {
+		return "info"
+	}
+	return strings.ToLower(sev.String())
+}
+
+// WriteCycloneDX exports s as a CycloneDX 1.4 VEX document, listing the
+// affected packages as components of the image and a vulnerability per ID.
+// Harbor does not tell whether a vulnerability is exploitable, so every
+// analysis is left in triage.
+func WriteCycloneDX(w io.Writer, s Scan) error {
+	image := cdxComponent{BOMRef: s.Reference(), Type: "container", Name: s.Repository, Version: s.Tag}
+	if i := strings.Index(s.Digest, ":"); i > 0 && strings.EqualFold(s.Digest[:i], "sha256") {
+		image.Hashes = []cdxHash{{Alg: "SHA-256", Content: s.Digest[i+1:]}}
+	}
+	bom := cdxBOM{
+		BOMFormat:       "CycloneDX",
+		SpecVersion:     cycloneDXVersion,
+		Version:         1,
+		Metadata:        cdxMetadata{Tools: []cdxTool{{Vendor: "goharbor", Name: "Harbor"}}, Component: image},
+		Components:      []cdxComponent{},
+		Vulnerabilities: []cdxVulnerability{},
+	}
+
+	var (
+		components = map[string]bool{}
+		vulns      = map[string]int{}
+		// Upgrades fixing each vulnerability, per package
+		fixes = map[string][]string{}
+	)
+	for _, item := range s.sorted() {
+		ref := item.Pkg + "@" + item.Version
+		if !components[ref] {
+			components[ref] = true
+			bom.Components = append(bom.Components, cdxComponent{BOMRef: ref, Type: "library", Name: item.Pkg, Version: item.Version})
+		}
+		i, ok := vulns[item.ID]
+		if !ok {
+			i = len(bom.Vulnerabilities)
+			vulns[item.ID] = i
+			v := cdxVulnerability{
+				BOMRef:      item.ID,
+				ID:          item.ID,
+				Ratings:     []cdxRating{{Severity: cdxSeverity(item.Severity), Method: "other"}},
+				Description: item.Description,
+				Analysis:    cdxAnalysis{State: "in_triage"},
+			}
+			if item.Link != "" {
+				v.Source = &cdxSource{URL: item.Link}
+				v.Advisories = []cdxAdvisory{{URL: item.Link}}
+			}
+			bom.Vulnerabilities = append(bom.Vulnerabilities, v)
+		}
+		if v := &bom.Vulnerabilities[i]; ok {
+			if v.Description == "" {
+				v.Description = item.Description
+			}
+			if v.Source == nil && item.Link != "" {
+				v.Source = &cdxSource{URL: item.Link}
+				v.Advisories = []cdxAdvisory{{URL: item.Link}}
+			}
+		}
+		bom.Vulnerabilities[i].Affects = append(bom.Vulnerabilities[i].Affects, cdxAffect{Ref: ref})
+		if item.Fixed != "" {
+			fixes[item.ID] = appendFix(fixes[item.ID], item.Pkg+" to "+item.Fixed)
+		}
+	}
+	for i := range bom.Vulnerabilities {
+		v := &bom.Vulnerabilities[i]
+		if upgrades := fixes[v.ID]; len(upgrades) != 0 {
+			sort.Strings(upgrades)
+			v.Recommendation = "Upgrade " + strings.Join(upgrades, ", ")
+		}
+	}
+	// Components are listed by reference, independently of the severities
+	sortComponents(bom.Components)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(bom)
+}
+
+func sortComponents(components []cdxComponent) {
+	sort.Slice(components, func(i, j int) bool {
+		return components[i].BOMRef < components[j].BOMRef
+	})
+}
+
+// appendFix appends the upgrade fix to fixes unless it is already listed,
+// as when several versions of a package share the same fix.
+func appendFix(fixes []string, fix string) []string {
+	for _, f := range fixes {
+		if f == fix {
+			return fixes
+		}
+	}
+	return append(fixes, fix)
+}
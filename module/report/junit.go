@@ -0,0 +1,77 @@
+package report
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitCase     `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit exports s as JUnit XML, with a failed test case per
+// vulnerability, named after the package, or a single passed one when the
+// image has no vulnerability.
+func WriteJUnit(w io.Writer, s Scan) error {
+	suite := junitSuite{
+		Name: s.Reference(),
+		Properties: []junitProperty{
+			{Name: "repository", Value: s.Repository},
+			{Name: "tag", Value: s.Tag},
+			{Name: "digest", Value: s.Digest},
+		},
+	}
+	for _, item := range s.sorted() {
+		suite.Cases = append(suite.Cases, junitCase{
+			ClassName: item.Pkg,
+			Name:      "[" + item.Severity.String() + "] " + item.ID,
+			Failure: &junitFailure{
+				Message: message(item),
+				Type:    item.Severity.String(),
+				Text:    description(item),
+			},
+		})
+		suite.Failures++
+	}
+	if len(suite.Cases) == 0 {
+		suite.Cases = []junitCase{{ClassName: s.Repository, Name: "no vulnerabilities"}}
+	}
+	suite.Tests = len(suite.Cases)
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,107 @@
+// Package report exports the vulnerabilities of an image scan to the
+// formats of other tools: SARIF for code scanning dashboards, CycloneDX VEX,
+// CSV for spreadsheets, and JUnit XML for CI. Every format lists the
+// vulnerabilities in the same order, so that a scan always gives the same
+// output.
+package report
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// Format is an export format.
+type Format string
+
+const (
+	SARIF     Format = "sarif"
+	CycloneDX Format = "cyclonedx"
+	CSV       Format = "csv"
+	JUnit     Format = "junit"
+)
+
+// Formats lists the supported formats.
+func Formats() []Format {
+	return []Format{SARIF, CycloneDX, CSV, JUnit}
+}
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats() {
+		if strings.EqualFold(s, string(f)) {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("report: unknown format %q", s)
+}
+
+// Scan is the scan of an image to export.
+type Scan struct {
+	// Name of the repository, e.g. library/nginx
+	Repository string
+	Tag        string
+	Digest     string
+	// Vulnerabilities, in any order
+	Vulnerabilities []repositories.VulnerabilityItem
+}
+
+// FromReport returns the Scan of a report of ScanAndWait.
+func FromReport(r repositories.ScanReport) Scan {
+	return Scan{
+		Repository:      r.Repository,
+		Tag:             r.Tag,
+		Digest:          r.Digest,
+		Vulnerabilities: r.Vulnerabilities,
+	}
+}
+
+// Reference returns the reference of the image, e.g. library/nginx:1.19 or
+// library/nginx@sha256:..., preferring the digest.
+func (s Scan) Reference() string {
+	if s.Digest != "" {
+		return s.Repository + "@" + s.Digest
+	}
+	if s.Tag != "" {
+		return s.Repository + ":" + s.Tag
+	}
+	return s.Repository
+}
+
+// sorted returns the vulnerabilities from the most severe, then by ID,
+// package and version.
+func (s Scan) sorted() []repositories.VulnerabilityItem {
+	items := append([]repositories.VulnerabilityItem(nil), s.Vulnerabilities...)
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.Severity != b.Severity {
+			return a.Severity > b.Severity
+		}
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		if a.Pkg != b.Pkg {
+			return a.Pkg < b.Pkg
+		}
+		return a.Version < b.Version
+	})
+	return items
+}
+
+// Write exports s to w in format f.
+func Write(w io.Writer, f Format, s Scan) error {
+	switch f {
+	case SARIF:
+		return WriteSARIF(w, s)
+	case CycloneDX:
+		return WriteCycloneDX(w, s)
+	case CSV:
+		return WriteCSV(w, s)
+	case JUnit:
+		return WriteJUnit(w, s)
+	}
+	return fmt.Errorf("report: unknown format %q", f)
+}
@@ -0,0 +1,118 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+var update = flag.Bool("update", false, "update the golden files of testdata")
+
+// scans are exported to every format and compared to testdata/<name>.<format>.golden.
+var scans = map[string]Scan{
+	"nginx": {
+		Repository: "library/nginx",
+		Tag:        "1.19",
+		Digest:     "sha256:0123456789abcdef",
+		// Out of order on purpose, the same CVE affecting two packages
+		Vulnerabilities: []repositories.VulnerabilityItem{
+			{ID: "CVE-2020-0002", Severity: repositories.SeverityLow, Pkg: "zlib", Version: "1.2.11", Description: "Low & \"quoted\"", Link: "https://nvd.example.com/CVE-2020-0002"},
+			{ID: "CVE-2020-0001", Severity: repositories.SeverityCritical, Pkg: "openssl", Version: "1.1.1d", Fixed: "1.1.1g", Link: "https://nvd.example.com/CVE-2020-0001"},
+			{ID: "CVE-2020-0001", Severity: repositories.SeverityCritical, Pkg: "libssl", Version: "1.1.1d", Fixed: "1.1.1f", Description: "Remote code execution, with a comma"},
+			{ID: "CVE-2020-0003", Severity: repositories.SeverityNegligible, Pkg: "tzdata", Version: "2020a"},
+		},
+	},
+	"clean": {
+		Repository: "library/alpine",
+		Tag:        "3.12",
+	},
+}
+
+func TestGolden(t *testing.T) {
+	for name, scan := range scans {
+		for _, f := range Formats() {
+			t.Run(name+"/"+string(f), func(t *testing.T) {
+				var buf bytes.Buffer
+				if err := Write(&buf, f, scan); err != nil {
+					t.Fatal(err)
+				}
+				golden := filepath.Join("testdata", name+"."+string(f)+".golden")
+				if *update {
+					if err := ioutil.WriteFile(golden, buf.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+				want, err := ioutil.ReadFile(golden)
+				if err != nil {
+					t.Fatalf("%v, run go test -update to create it", err)
+				}
+				if !bytes.Equal(buf.Bytes(), want) {
+					t.Errorf("%s differs from %s:\n%s", f, golden, buf.String())
+				}
+			})
+		}
+	}
+}
+
+func TestWriteIsDeterministic(t *testing.T) {
+	scan := scans["nginx"]
+	reversed := scan
+	reversed.Vulnerabilities = nil
+	for i := len(scan.Vulnerabilities) - 1; i >= 0; i-- {
+		reversed.Vulnerabilities = append(reversed.Vulnerabilities, scan.Vulnerabilities[i])
+	}
+	for _, f := range Formats() {
+		var a, b bytes.Buffer
+		if err := Write(&a, f, scan); err != nil {
+			t.Fatal(err)
+		}
+		if err := Write(&b, f, reversed); err != nil {
+			t.Fatal(err)
+		}
+		if a.String() != b.String() {
+			t.Errorf("%s depends on the order of the vulnerabilities", f)
+		}
+	}
+}
+
+func TestCycloneDXRecommendation(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCycloneDX(&buf, scans["nginx"]); err != nil {
+		t.Fatal(err)
+	}
+	if want := `"recommendation": "Upgrade libssl to 1.1.1f, openssl to 1.1.1g"`; !strings.Contains(buf.String(), want) {
+		t.Errorf("missing %s in\n%s", want, buf.String())
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, f := range Formats() {
+		if got, err := ParseFormat(strings.ToUpper(string(f))); err != nil || got != f {
+			t.Errorf("ParseFormat(%q) = %q, %v", strings.ToUpper(string(f)), got, err)
+		}
+	}
+	if _, err := ParseFormat("html"); err == nil {
+		t.Error("ParseFormat(html) succeeded")
+	}
+}
+
+func TestReference(t *testing.T) {
+	for _, tt := range []struct {
+		scan Scan
+		want string
+	}{
+		{Scan{Repository: "library/nginx", Tag: "1.19", Digest: "sha256:abc"}, "library/nginx@sha256:abc"},
+		{Scan{Repository: "library/nginx", Tag: "1.19"}, "library/nginx:1.19"},
+		{Scan{Repository: "library/nginx"}, "library/nginx"},
+	} {
+		if got := tt.scan.Reference(); got != tt.want {
+			t.Errorf("Reference() = %q, want %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+const (
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifVersion = "2.1.0"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool       sarifTool         `json:"tool"`
+	Results    []sarifResult     `json:"results"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string             `json:"id"`
+	ShortDescription     sarifMessage       `json:"shortDescription"`
+	FullDescription      sarifMessage       `json:"fullDescription"`
+	HelpURI              string             `json:"helpUri,omitempty"`
+	DefaultConfiguration sarifConfiguration `json:"defaultConfiguration"`
+	Properties           sarifRuleProps     `json:"properties"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifRuleProps struct {
+	SecuritySeverity string   `json:"security-severity"`
+	Tags             []string `json:"tags"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+	} `json:"physicalLocation"`
+}
+
+// sarifLevel maps a severity to the level of a SARIF result.
+func sarifLevel(sev repositories.Severity) string {
+	switch {
+	case sev >= repositories.SeverityHigh:
+		return "error"
+	case sev == repositories.SeverityMedium:
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// securitySeverity maps a severity to the CVSS like score used by code
+// scanning dashboards to rank the results.
+func securitySeverity(sev repositories.Severity) string {
+	switch sev {
+	case repositories.SeverityCritical:
+		return "9.5"
+	case repositories.SeverityHigh:
+		return "8.0"
+	case repositories.SeverityMedium:
+		return "5.5"
+	case repositories.SeverityLow:
+		return "2.0"
+	default:
+		return "0.0"
+	}
+}
+
+// WriteSARIF exports s as a SARIF 2.1.0 log, with a rule per vulnerability
+// and a result per affected package.
+func WriteSARIF(w io.Writer, s Scan) error {
+	var (
+		items = s.sorted()
+		rules []sarifRule
+		index = map[string]int{}
+		uri   = s.Reference()
+	)
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{Name: "Harbor", InformationURI: "https://goharbor.io"}},
+		// Results must be an empty array rather than null when the image
+		// has no vulnerability
+		Results: []sarifResult{},
+		Properties: map[string]string{
+			"repository": s.Repository,
+			"tag":        s.Tag,
+			"digest":     s.Digest,
+		},
+	}
+	for _, item := range items {
+		i, ok := index[item.ID]
+		if !ok {
+			i = len(rules)
+			index[item.ID] = i
+			rules = append(rules, sarifRule{
+				ID:                   item.ID,
+				ShortDescription:     sarifMessage{Text: fmt.Sprintf("%s %s vulnerability", item.ID, item.Severity)},
+				FullDescription:      sarifMessage{Text: description(item)},
+				HelpURI:              item.Link,
+				DefaultConfiguration: sarifConfiguration{Level: sarifLevel(item.Severity)},
+				Properties: sarifRuleProps{
+					SecuritySeverity: securitySeverity(item.Severity),
+					Tags:             []string{"vulnerability", "security", item.Severity.String()},
+				},
+			})
+		} else {
+			// The packages affected by a vulnerability may not all carry
+			// its description and link
+			rule := &rules[i]
+			if rule.FullDescription.Text == item.ID {
+				rule.FullDescription.Text = description(item)
+			}
+			if rule.HelpURI == "" {
+				rule.HelpURI = item.Link
+			}
+		}
+		result := sarifResult{
+			RuleID:    item.ID,
+			RuleIndex: i,
+			Level:     sarifLevel(item.Severity),
+			Message:   sarifMessage{Text: message(item)},
+			Locations: make([]sarifLocation, 1),
+		}
+		result.Locations[0].PhysicalLocation.ArtifactLocation.URI = uri
+		run.Results = append(run.Results, result)
+	}
+	if rules == nil {
+		rules = []sarifRule{}
+	}
+	run.Tool.Driver.Rules = rules
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{Schema: sarifSchema, Version: sarifVersion, Runs: []sarifRun{run}})
+}
+
+// message describes the vulnerability of a package in one line.
+func message(item repositories.VulnerabilityItem) string {
+	msg := fmt.Sprintf("%s: %s@%s is affected by %s", item.Severity, item.Pkg, item.Version, item.ID)
+	if item.Fixed != "" {
+		msg += ", fixed in " + item.Fixed
+	}
+	return msg
+}
+
+func description(item repositories.VulnerabilityItem) string {
+	if item.Description != "" {
+		return item.Description
+	}
+	return item.ID
+}
@@ -7,13 +7,13 @@ import (
 
 // VulnerabilityItem is an item in the vulnerability result returned by vulnerability details API.
 type VulnerabilityItem struct {
-	ID          string `json:"id"`
-	Severity    int64  `json:"severity"`
-	Pkg         string `json:"package"`
-	Version     string `json:"version"`
-	Description string `json:"description"`
-	Link        string `json:"link"`
-	Fixed       string `json:"fixedVersion,omitempty"`
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Pkg         string   `json:"package"`
+	Version     string   `json:"version"`
+	Description string   `json:"description"`
+	Link        string   `json:"link"`
+	Fixed       string   `json:"fixedVersion,omitempty"`
 }
 
 type RepoResp struct {
@@ -52,17 +52,17 @@ type ComponentsOverview struct {
 
 //ComponentsOverviewEntry ...
 type ComponentsOverviewEntry struct {
-	Sev   int `json:"severity"`
-	Count int `json:"count"`
+	Sev   Severity `json:"severity"`
+	Count int      `json:"count"`
 }
 
 //ImgScanOverview mapped to a record of image scan overview.
 type ImgScanOverview struct {
 	ID              int64               `json:"-"`
 	Digest          string              `json:"image_digest"`
-	Status          string              `json:"scan_status"`
+	Status          ScanStatus          `json:"scan_status"`
 	JobID           int64               `json:"job_id"`
-	Sev             int                 `json:"severity"`
+	Sev             Severity            `json:"severity"`
 	CompOverviewStr string              `json:"-"`
 	CompOverview    *ComponentsOverview `json:"components,omitempty"`
 	DetailsKey      string              `json:"details_key"`
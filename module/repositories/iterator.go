@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+// RepoIterator walks the repositories matching a ListRepositoriesOption,
+// fetching the pages on demand.
+type RepoIterator struct {
+	pager *client2.Pager
+	page  []RepoRecord
+	cur   RepoRecord
+}
+
+// Next advances to the next repository, fetching the next page when needed.
+func (it *RepoIterator) Next() bool {
+	for len(it.page) == 0 {
+		if !it.pager.Next() {
+			return false
+		}
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Repository returns the current repository.
+func (it *RepoIterator) Repository() RepoRecord { return it.cur }
+
+// Total returns the total number of repositories reported by Harbor, or -1
+// when no page has been fetched yet.
+func (it *RepoIterator) Total() int { return it.pager.Total() }
+
+// Err returns the error that stopped the iteration, if any.
+func (it *RepoIterator) Err() error { return it.pager.Err() }
+
+// Stop ends the iteration early.
+func (it *RepoIterator) Stop() {
+	it.page = nil
+	it.pager.Stop()
+}
+
+// Iterate returns an iterator over every repository matching opt, following
+// the pages returned by Harbor.
+func (s *RepositoriesService) Iterate(ctx context.Context, opt *ListRepositoriesOption) *RepoIterator {
+	o := ListRepositoriesOption{}
+	if opt != nil {
+		o = *opt
+	}
+	it := &RepoIterator{}
+	it.pager = client2.NewPager(ctx, o.ListOptions, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		o.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, &o)
+		it.page = v
+		return len(v), resp, errs
+	})
+	return it
+}
+
+// ListAll returns every repository matching opt. Up to concurrency pages are
+// fetched at the same time once the total count is known.
+func (s *RepositoriesService) ListAll(ctx context.Context, opt *ListRepositoriesOption, concurrency int) ([]RepoRecord, []error) {
+	o := ListRepositoriesOption{}
+	if opt != nil {
+		o = *opt
+	}
+	var (
+		mu    sync.Mutex
+		pages = map[int][]RepoRecord{}
+	)
+	errs := client2.FetchAll(ctx, o.ListOptions, concurrency, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		po := o
+		po.ListOptions = page
+		v, resp, errs := s.ListContext(ctx, &po)
+		mu.Lock()
+		pages[page.Page] = v
+		mu.Unlock()
+		return len(v), resp, errs
+	})
+	if len(errs) != 0 {
+		return nil, errs
+	}
+	keys := make([]int, 0, len(pages))
+	for page := range pages {
+		keys = append(keys, page)
+	}
+	sort.Ints(keys)
+	var all []RepoRecord
+	for _, page := range keys {
+		all = append(all, pages[page]...)
+	}
+	return all, nil
+}
@@ -0,0 +1,40 @@
+package repositories_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+func TestIterate(t *testing.T) {
+	srv := harbortest.Start(t)
+	p := srv.AddProject("library", true)
+	for i := 0; i < 23; i++ {
+		if _, err := srv.PushImage(fmt.Sprintf("library/image-%02d", i), "latest"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+	opt := &repositories.ListRepositoriesOption{ListOptions: client.ListOptions{PageSize: 10}, ProjectId: p.ProjectID}
+
+	it := s.Iterate(context.Background(), opt)
+	seen := map[string]bool{}
+	for it.Next() {
+		seen[it.Repository().Name] = true
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != 23 || it.Total() != 23 {
+		t.Errorf("Iterate returned %d repositories of %d", len(seen), it.Total())
+	}
+
+	all, errs := s.ListAll(context.Background(), opt, 3)
+	if len(errs) != 0 || len(all) != 23 {
+		t.Errorf("ListAll returned %d repositories, %v", len(all), errs)
+	}
+}
@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/codingXiang/go-harbor-client/module/manifest"
+)
+
+// Parse returns the typed manifest, see the manifest package.
+func (m ManifestResp) Parse() (*manifest.Manifest, error) {
+	data, err := rawJSON(m.Manifest)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Parse(data)
+}
+
+// ImageConfig returns the parsed configuration of the image, which Harbor
+// returns along with schema 2 manifests.
+func (m ManifestResp) ImageConfig() (*manifest.ImageConfig, error) {
+	if m.Config == nil {
+		return nil, errors.New("repositories: the manifest has no image config")
+	}
+	data, err := rawJSON(m.Config)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.ParseConfig(data)
+}
+
+// rawJSON returns the JSON of a decoded field, Harbor returning some of
+// them as a JSON document in a string.
+func rawJSON(v interface{}) ([]byte, error) {
+	if s, ok := v.(string); ok {
+		return []byte(s), nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,46 @@
+package repositories_test
+
+import (
+	"testing"
+
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/manifest"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+func TestManifestParse(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	tag, err := srv.PushImage("library/nginx", "1.19")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+
+	resp, _, errs := s.GetTagManifests("library/nginx", "1.19", "")
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	m, err := resp.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.MediaType != manifest.MediaTypeDockerSchema2 || len(m.Layers()) != 1 || m.Layers()[0].Digest != tag.Digest {
+		t.Errorf("Parse = %+v", m)
+	}
+	// Harbor returns the config as a JSON document in a string
+	if _, ok := resp.Config.(string); !ok {
+		t.Fatalf("config of type %T", resp.Config)
+	}
+	config, err := resp.ImageConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := config.Platform(); p.String() != "linux/amd64" {
+		t.Errorf("Platform = %v", p)
+	}
+
+	if _, err := (repositories.ManifestResp{Manifest: map[string]interface{}{"schemaVersion": 2}}).ImageConfig(); err == nil {
+		t.Error("ImageConfig of a manifest without config succeeded")
+	}
+}
@@ -1,8 +1,10 @@
 package repositories
 
 import (
-	client2 "github.com/codingXiang/go-harbor-client/client"
+	"context"
 	"fmt"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
 	"github.com/parnurzeal/gorequest"
 )
 
@@ -23,19 +25,37 @@ const (
 // RepositoriesService handles communication with the user related methods of
 // the Harbor API.
 //
+// The same interface is served by Harbor 1.x and 2.x: against 2.x, names are
+// split into project and repository and tags are read from the artifacts.
+// Calls without a 2.x equivalent return client.ErrNotSupported.
+//
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L891
 type Service interface {
 	List(opt *ListRepositoriesOption) ([]RepoRecord, *gorequest.Response, []error)
+	ListContext(ctx context.Context, opt *ListRepositoriesOption) ([]RepoRecord, *gorequest.Response, []error)
 	Update(name string, d RepositoryDescription) (*gorequest.Response, []error)
+	UpdateContext(ctx context.Context, name string, d RepositoryDescription) (*gorequest.Response, []error)
 	Delete(name string) (*gorequest.Response, []error)
+	DeleteContext(ctx context.Context, name string) (*gorequest.Response, []error)
 	GetTag(projectName string, repoName string, tag string) (TagResp, *gorequest.Response, []error)
+	GetTagContext(ctx context.Context, projectName string, repoName string, tag string) (TagResp, *gorequest.Response, []error)
 	DeleteTag(projectName string, repoName string, tag string) (*gorequest.Response, []error)
+	DeleteTagContext(ctx context.Context, projectName string, repoName string, tag string) (*gorequest.Response, []error)
 	ListTags(projectName string, repoName string) ([]TagResp, *gorequest.Response, []error)
+	ListTagsContext(ctx context.Context, projectName string, repoName string) ([]TagResp, *gorequest.Response, []error)
 	GetTagManifests(name string, tag string, version string) (ManifestResp, *gorequest.Response, []error)
+	GetTagManifestsContext(ctx context.Context, name string, tag string, version string) (ManifestResp, *gorequest.Response, []error)
 	ScanImage(name string, tag string) (*gorequest.Response, []error)
+	ScanImageContext(ctx context.Context, name string, tag string) (*gorequest.Response, []error)
+	ScanAndWait(ctx context.Context, name string, tag string, opt *ScanWaitOptions) (ScanReport, *gorequest.Response, []error)
 	GetImageDetails(name string, tag string) ([]VulnerabilityItem, *gorequest.Response, []error)
+	GetImageDetailsContext(ctx context.Context, name string, tag string) ([]VulnerabilityItem, *gorequest.Response, []error)
 	GetSignature(name string) ([]Signature, *gorequest.Response, []error)
+	GetSignatureContext(ctx context.Context, name string) ([]Signature, *gorequest.Response, []error)
 	GetTop(top interface{}) ([]RepoResp, *gorequest.Response, []error)
+	GetTopContext(ctx context.Context, top interface{}) ([]RepoResp, *gorequest.Response, []error)
+	Iterate(ctx context.Context, opt *ListRepositoriesOption) *RepoIterator
+	ListAll(ctx context.Context, opt *ListRepositoriesOption, concurrency int) ([]RepoRecord, []error)
 }
 
 type RepositoriesService struct {
@@ -57,12 +77,23 @@ func (s *RepositoriesService) getConfigString(key string) string {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L892
 func (s *RepositoriesService) List(opt *ListRepositoriesOption) ([]RepoRecord, *gorequest.Response, []error) {
+	return s.ListContext(context.Background(), opt)
+}
+
+// ListContext is like List but carries ctx to the request.
+func (s *RepositoriesService) ListContext(ctx context.Context, opt *ListRepositoriesOption) ([]RepoRecord, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.listV2(ctx, opt)
+	}
 	var v []RepoRecord
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, s.getConfigString(root)).
-		Query(*opt).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(root)).
+		Query(*opt)
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Delete a repository.
@@ -71,10 +102,20 @@ func (s *RepositoriesService) List(opt *ListRepositoriesOption) ([]RepoRecord, *
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L948
 func (s *RepositoriesService) Delete(repoName string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), repoName)).
-		End()
-	return &resp, errs
+	return s.DeleteContext(context.Background(), repoName)
+}
+
+// DeleteContext is like Delete but carries ctx to the request.
+func (s *RepositoriesService) DeleteContext(ctx context.Context, repoName string) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.client.Do(ctx, s.requestV2(gorequest.DELETE, baseV2, repoName), nil)
+	}
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), repoName))
+	return s.client.Do(ctx, req, nil)
 }
 
 type RepositoryDescription struct {
@@ -87,11 +128,23 @@ type RepositoryDescription struct {
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L971
 func (s *RepositoriesService) Update(repoName string, d RepositoryDescription) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(base), repoName)).
-		Send(d).
-		End()
-	return &resp, errs
+	return s.UpdateContext(context.Background(), repoName, d)
+}
+
+// UpdateContext is like Update but carries ctx to the request.
+func (s *RepositoriesService) UpdateContext(ctx context.Context, repoName string, d RepositoryDescription) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		req := s.requestV2(gorequest.PUT, baseV2, repoName).
+			Send(d)
+		return s.client.Do(ctx, req, nil)
+	}
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(base), repoName)).
+		Send(d)
+	return s.client.Do(ctx, req, nil)
 }
 
 // Get the tag of the repository.
@@ -103,11 +156,22 @@ func (s *RepositoriesService) Update(repoName string, d RepositoryDescription) (
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L999
 func (s *RepositoriesService) GetTag(projectName string, repoName, tag string) (TagResp, *gorequest.Response, []error) {
+	return s.GetTagContext(context.Background(), projectName, repoName, tag)
+}
+
+// GetTagContext is like GetTag but carries ctx to the request.
+func (s *RepositoriesService) GetTagContext(ctx context.Context, projectName string, repoName, tag string) (TagResp, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return TagResp{}, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.getTagV2(ctx, projectName, repoName, tag)
+	}
 	var v TagResp
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(tagBase), projectName, repoName, tag)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(tagBase), projectName, repoName, tag))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Delete a tag in a repository.
@@ -116,10 +180,21 @@ func (s *RepositoriesService) GetTag(projectName string, repoName, tag string) (
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1025
 func (s *RepositoriesService) DeleteTag(projectName string, repoName, tag string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(tagBase), projectName, repoName, tag)).
-		End()
-	return &resp, errs
+	return s.DeleteTagContext(context.Background(), projectName, repoName, tag)
+}
+
+// DeleteTagContext is like DeleteTag but carries ctx to the request.
+func (s *RepositoriesService) DeleteTagContext(ctx context.Context, projectName string, repoName, tag string) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		path := fmt.Sprintf(s.getConfigString(artifactTagV2), projectName, escapeRepo(repoName), tag, tag)
+		return s.client.Do(ctx, s.client.NewRequest(gorequest.DELETE, path), nil)
+	}
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(tagBase), projectName, repoName, tag))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Get tags of a relevant repository.
@@ -131,29 +206,53 @@ func (s *RepositoriesService) DeleteTag(projectName string, repoName, tag string
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1054
 func (s *RepositoriesService) ListTags(projectName string, repoName string) ([]TagResp, *gorequest.Response, []error) {
+	return s.ListTagsContext(context.Background(), projectName, repoName)
+}
+
+// ListTagsContext is like ListTags but carries ctx to the request.
+func (s *RepositoriesService) ListTagsContext(ctx context.Context, projectName string, repoName string) ([]TagResp, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.listTagsV2(ctx, projectName, repoName)
+	}
 	var v []TagResp
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(tagRoot), projectName, repoName)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(tagRoot), projectName, repoName))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Get manifests of a relevant repository.
 //
 // This endpoint aims to retreive manifests from a relevant repository.
+// ManifestResp.Parse and ManifestResp.ImageConfig return them typed.
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1079
 func (s *RepositoriesService) GetTagManifests(repoName, tag string, version string) (ManifestResp, *gorequest.Response, []error) {
+	return s.GetTagManifestsContext(context.Background(), repoName, tag, version)
+}
+
+// GetTagManifestsContext is like GetTagManifests but carries ctx to the request.
+func (s *RepositoriesService) GetTagManifestsContext(ctx context.Context, repoName, tag string, version string) (ManifestResp, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return ManifestResp{}, new(gorequest.Response), errs
+	}
+	if v2 {
+		// Harbor 2.x only serves manifests through the registry API
+		return ManifestResp{}, new(gorequest.Response), []error{client2.ErrNotSupported}
+	}
 	var v ManifestResp
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, func() string {
-			if version == "" {
-				return fmt.Sprintf(s.getConfigString(tagManifest), repoName, tag)
-			}
-			return fmt.Sprintf(s.getConfigString(tagManifestVersion), repoName, tag, version)
-		}()).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, func() string {
+		if version == "" {
+			return fmt.Sprintf(s.getConfigString(tagManifest), repoName, tag)
+		}
+		return fmt.Sprintf(s.getConfigString(tagManifestVersion), repoName, tag, version)
+	}())
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Scan the image.
@@ -164,10 +263,20 @@ func (s *RepositoriesService) GetTagManifests(repoName, tag string, version stri
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1113
 func (s *RepositoriesService) ScanImage(repoName, tag string) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, fmt.Sprintf("/repositories/%s/tags/%s/scan", repoName, tag)).
-		End()
-	return &resp, errs
+	return s.ScanImageContext(context.Background(), repoName, tag)
+}
+
+// ScanImageContext is like ScanImage but carries ctx to the request.
+func (s *RepositoriesService) ScanImageContext(ctx context.Context, repoName, tag string) (*gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.client.Do(ctx, s.requestV2(gorequest.POST, artifactScanV2, repoName, tag), nil)
+	}
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf("/repositories/%s/tags/%s/scan", repoName, tag))
+	return s.client.Do(ctx, req, nil)
 }
 
 // Get vulnerability details of the image.
@@ -176,11 +285,22 @@ func (s *RepositoriesService) ScanImage(repoName, tag string) (*gorequest.Respon
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1177
 func (s *RepositoriesService) GetImageDetails(repoName, tag string) ([]VulnerabilityItem, *gorequest.Response, []error) {
+	return s.GetImageDetailsContext(context.Background(), repoName, tag)
+}
+
+// GetImageDetailsContext is like GetImageDetails but carries ctx to the request.
+func (s *RepositoriesService) GetImageDetailsContext(ctx context.Context, repoName, tag string) ([]VulnerabilityItem, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		return s.getImageDetailsV2(ctx, repoName, tag)
+	}
 	var v []VulnerabilityItem
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf("repositories/%s/tags/%s/vulnerability/details", repoName, tag)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf("/repositories/%s/tags/%s/vulnerability/details", repoName, tag))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Get signature information of a repository.
@@ -192,11 +312,23 @@ func (s *RepositoriesService) GetImageDetails(repoName, tag string) ([]Vulnerabi
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1211
 func (s *RepositoriesService) GetSignature(repoName string) ([]Signature, *gorequest.Response, []error) {
+	return s.GetSignatureContext(context.Background(), repoName)
+}
+
+// GetSignatureContext is like GetSignature but carries ctx to the request.
+func (s *RepositoriesService) GetSignatureContext(ctx context.Context, repoName string) ([]Signature, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		// Harbor 2.x reports signatures on the tags of the artifacts
+		return nil, new(gorequest.Response), []error{client2.ErrNotSupported}
+	}
 	var v []Signature
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf("repositories/%s/signatures", repoName)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf("/repositories/%s/signatures", repoName))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 // Get public repositories which are accessed most.
@@ -205,14 +337,26 @@ func (s *RepositoriesService) GetSignature(repoName string) ([]Signature, *goreq
 //
 // Harbor API docs: https://github.com/vmware/harbor/blob/release-1.4.0/docs/swagger.yaml#L1241
 func (s *RepositoriesService) GetTop(top interface{}) ([]RepoResp, *gorequest.Response, []error) {
+	return s.GetTopContext(context.Background(), top)
+}
+
+// GetTopContext is like GetTop but carries ctx to the request.
+func (s *RepositoriesService) GetTopContext(ctx context.Context, top interface{}) ([]RepoResp, *gorequest.Response, []error) {
+	v2, errs := s.isV2(ctx)
+	if errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	if v2 {
+		// Harbor 2.x dropped the top repositories endpoint
+		return nil, new(gorequest.Response), []error{client2.ErrNotSupported}
+	}
 	var v []RepoResp
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, func() string {
-			if t, ok := top.(int); ok {
-				return fmt.Sprintf("repositories/top?count=%d", t)
-			}
-			return fmt.Sprintf("repositories/top")
-		}()).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, func() string {
+		if t, ok := top.(int); ok {
+			return fmt.Sprintf("/repositories/top?count=%d", t)
+		}
+		return fmt.Sprintf("/repositories/top")
+	}())
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
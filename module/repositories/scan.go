@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+// ErrScanFailed is returned by ScanAndWait when the scan ends with an error
+// or is stopped.
+var ErrScanFailed = errors.New("harbor: scan failed")
+
+// ScanWaitOptions specifies how ScanAndWait polls the scan.
+type ScanWaitOptions struct {
+	// Delay before the first poll, doubled after every poll up to
+	// MaxInterval. Defaults to 2 seconds.
+	Interval time.Duration
+	// Defaults to 30 seconds
+	MaxInterval time.Duration
+	// Limit of the whole wait, 0 to only rely on the context
+	Timeout time.Duration
+}
+
+// ScanReport is the result of the scan of an image.
+type ScanReport struct {
+	Repository string
+	Tag        string
+	Digest     string
+	Status     ScanStatus
+	// Highest severity of the vulnerabilities
+	Severity Severity
+	// Number of vulnerabilities per severity
+	Summary         map[Severity]int
+	Total           int
+	Vulnerabilities []VulnerabilityItem
+	EndTime         time.Time
+}
+
+// Count returns the number of vulnerabilities of severity min or higher.
+func (r ScanReport) Count(min Severity) int {
+	n := 0
+	for sev, count := range r.Summary {
+		if sev >= min {
+			n += count
+		}
+	}
+	return n
+}
+
+// Summarize counts the vulnerabilities per severity and returns the highest
+// severity found.
+func Summarize(items []VulnerabilityItem) (map[Severity]int, Severity) {
+	summary := map[Severity]int{}
+	highest := SeverityNone
+	for _, item := range items {
+		summary[item.Severity]++
+		if item.Severity > highest {
+			highest = item.Severity
+		}
+	}
+	return summary, highest
+}
+
+// ScanAndWait scans the image, waits for the scan to end and returns its
+// report. The scan overview of the tag is polled with an exponential
+// backoff. A scan ending with an error is reported as ErrScanFailed, along
+// with the report holding its status.
+func (s *RepositoriesService) ScanAndWait(ctx context.Context, repoName, tag string, opt *ScanWaitOptions) (ScanReport, *gorequest.Response, []error) {
+	o := ScanWaitOptions{}
+	if opt != nil {
+		o = *opt
+	}
+	if o.Interval <= 0 {
+		o.Interval = 2 * time.Second
+	}
+	if o.MaxInterval <= 0 {
+		o.MaxInterval = 30 * time.Second
+	}
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+	report := ScanReport{Repository: repoName, Tag: tag}
+	projectName, repo, err := splitRepoName(repoName)
+	if err != nil {
+		return report, new(gorequest.Response), []error{err}
+	}
+
+	// The overview of the previous scan tells apart its end from the one of
+	// the scan triggered here.
+	before, resp, errs := s.GetTagContext(ctx, projectName, repo, tag)
+	if len(errs) != 0 {
+		return report, resp, errs
+	}
+	if resp, errs = s.ScanImageContext(ctx, repoName, tag); len(errs) != 0 && !client2.IsConflict(errs[0]) {
+		return report, resp, errs
+	}
+
+	// A scan in progress, on which Harbor refuses to start another one, is
+	// waited for; its end may share the update time of its last status.
+	var (
+		overview *ImgScanOverview
+		started  = before.ScanOverview != nil && !before.ScanOverview.Status.Done()
+		interval = o.Interval
+		timer    = time.NewTimer(interval)
+	)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return report, resp, []error{ctx.Err()}
+		case <-timer.C:
+		}
+		t, r, errs := s.GetTagContext(ctx, projectName, repo, tag)
+		resp = r
+		if len(errs) != 0 {
+			return report, resp, errs
+		}
+		overview = t.ScanOverview
+		report.Digest = t.Digest
+		if overview != nil {
+			report.Status = overview.Status.Normalize()
+			if !overview.Status.Done() {
+				started = true
+			} else if started || before.ScanOverview == nil || !overview.UpdateTime.Equal(before.ScanOverview.UpdateTime) {
+				break
+			}
+		}
+		if interval *= 2; interval > o.MaxInterval {
+			interval = o.MaxInterval
+		}
+		timer.Reset(interval)
+	}
+
+	report.EndTime = overview.UpdateTime
+	if !overview.Status.Succeeded() {
+		return report, resp, []error{fmt.Errorf("%w: %s:%s is %s", ErrScanFailed, repoName, tag, report.Status)}
+	}
+	items, resp, errs := s.GetImageDetailsContext(ctx, repoName, tag)
+	if len(errs) != 0 {
+		return report, resp, errs
+	}
+	report.Vulnerabilities = items
+	report.Summary, report.Severity = Summarize(items)
+	report.Total = len(items)
+	return report, resp, nil
+}
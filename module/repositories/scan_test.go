@@ -0,0 +1,126 @@
+package repositories_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+var fastPoll = &repositories.ScanWaitOptions{Interval: time.Millisecond, MaxInterval: 5 * time.Millisecond, Timeout: 5 * time.Second}
+
+func TestScanAndWait(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	vulns := []repositories.VulnerabilityItem{
+		{ID: "CVE-2020-0001", Severity: repositories.SeverityCritical, Pkg: "openssl"},
+		{ID: "CVE-2020-0002", Severity: repositories.SeverityHigh, Pkg: "openssl"},
+		{ID: "CVE-2020-0003", Severity: repositories.SeverityLow, Pkg: "zlib"},
+		{ID: "CVE-2020-0004", Severity: repositories.SeverityLow, Pkg: "zlib"},
+	}
+	if err := srv.SetVulnerabilities("library/nginx", "1.19", vulns); err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+
+	report, _, errs := s.ScanAndWait(context.Background(), "library/nginx", "1.19", fastPoll)
+	if len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	want := map[repositories.Severity]int{repositories.SeverityCritical: 1, repositories.SeverityHigh: 1, repositories.SeverityLow: 2}
+	if report.Status != repositories.ScanSuccess || report.Severity != repositories.SeverityCritical || report.Total != 4 || !reflect.DeepEqual(report.Summary, want) {
+		t.Errorf("ScanAndWait = %+v", report)
+	}
+	if report.Digest == "" || report.EndTime.IsZero() || len(report.Vulnerabilities) != 4 {
+		t.Errorf("ScanAndWait = %+v", report)
+	}
+
+	// A rescan waits for the new scan rather than returning the previous one
+	if err := srv.SetVulnerabilities("library/nginx", "1.19", vulns[3:]); err != nil {
+		t.Fatal(err)
+	}
+	report, _, errs = s.ScanAndWait(context.Background(), "library/nginx", "1.19", fastPoll)
+	if len(errs) != 0 || report.Total != 1 || report.Severity != repositories.SeverityLow {
+		t.Errorf("rescan = %+v, %v", report, errs)
+	}
+}
+
+func TestScanAndWaitScanInProgress(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+	if _, errs := s.ScanImage("library/nginx", "1.19"); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	// Harbor refuses to scan an image being scanned; the scan in progress
+	// is waited for instead
+	report, _, errs := s.ScanAndWait(context.Background(), "library/nginx", "1.19", fastPoll)
+	if len(errs) != 0 || report.Status != repositories.ScanSuccess || report.Total != 0 {
+		t.Errorf("ScanAndWait = %+v, %v", report, errs)
+	}
+}
+
+func TestScanAndWaitTimeout(t *testing.T) {
+	srv := harbortest.Start(t)
+	srv.AddProject("library", true)
+	if _, err := srv.PushImage("library/nginx", "1.19"); err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(srv.Client())
+	_, _, errs := s.ScanAndWait(context.Background(), "library/nginx", "1.19", &repositories.ScanWaitOptions{Interval: time.Hour, Timeout: 50 * time.Millisecond})
+	if len(errs) != 1 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("ScanAndWait past its timeout: %v", errs)
+	}
+
+	if _, _, errs := s.ScanAndWait(context.Background(), "nginx", "1.19", fastPoll); len(errs) != 1 {
+		t.Errorf("ScanAndWait of a repository without project: %v", errs)
+	}
+}
+
+func TestScanAndWaitFailed(t *testing.T) {
+	// The scan fails at once, Harbor 1.x reporting it in lower case
+	var scanned int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/repositories/library/nginx/tags/1.19":
+			if atomic.LoadInt32(&scanned) == 0 {
+				w.Write([]byte(`{"digest":"sha256:a"}`))
+				return
+			}
+			w.Write([]byte(`{"digest":"sha256:a","scan_overview":{"scan_status":"error","update_time":"2020-06-01T00:00:00Z"}}`))
+		case "POST /api/repositories/library/nginx/tags/1.19/scan":
+			atomic.StoreInt32(&scanned, 1)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+	c, err := client.New(client.WithBaseURL(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := repositories.NewRepositoriesService(c)
+
+	report, _, errs := s.ScanAndWait(context.Background(), "library/nginx", "1.19", fastPoll)
+	if len(errs) != 1 || !errors.Is(errs[0], repositories.ErrScanFailed) {
+		t.Fatalf("ScanAndWait of a failing scan: %v", errs)
+	}
+	if report.Status != repositories.ScanError || report.Digest != "sha256:a" || report.EndTime.IsZero() {
+		t.Errorf("report of a failed scan %+v", report)
+	}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Severity is the severity of a vulnerability, ordered from SeverityNone to
+// SeverityCritical.
+type Severity int
+
+const (
+	SeverityNone Severity = iota
+	SeverityUnknown
+	SeverityNegligible
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+var severityNames = [...]string{"None", "Unknown", "Negligible", "Low", "Medium", "High", "Critical"}
+
+// severitiesV1 maps the severity codes of Harbor 1.x, which has neither
+// Negligible nor Critical, to Severity.
+var severitiesV1 = map[int]Severity{
+	1: SeverityNone,
+	2: SeverityUnknown,
+	3: SeverityLow,
+	4: SeverityMedium,
+	5: SeverityHigh,
+}
+
+// Severities lists the severities from the lowest to the highest.
+func Severities() []Severity {
+	return []Severity{SeverityNone, SeverityUnknown, SeverityNegligible, SeverityLow, SeverityMedium, SeverityHigh, SeverityCritical}
+}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return "Severity(" + strconv.Itoa(int(s)) + ")"
+	}
+	return severityNames[s]
+}
+
+// ParseSeverity parses the name of a severity, as reported by Harbor 2.x,
+// or the code of a severity of Harbor 1.x.
+func ParseSeverity(s string) (Severity, error) {
+	s = strings.TrimSpace(s)
+	if code, err := strconv.Atoi(s); err == nil {
+		if sev, ok := severitiesV1[code]; ok {
+			return sev, nil
+		}
+		return SeverityUnknown, fmt.Errorf("harbor: unknown severity code %d", code)
+	}
+	for i, name := range severityNames {
+		if strings.EqualFold(s, name) {
+			return Severity(i), nil
+		}
+	}
+	return SeverityUnknown, fmt.Errorf("harbor: unknown severity %q", s)
+}
+
+// MarshalText encodes the severity as its name.
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText decodes a severity name or a Harbor 1.x code.
+func (s *Severity) UnmarshalText(text []byte) error {
+	sev, err := ParseSeverity(string(text))
+	if err != nil {
+		return err
+	}
+	*s = sev
+	return nil
+}
+
+// UnmarshalJSON decodes the severity of Harbor 2.x, a string, or the one of
+// Harbor 1.x, a number.
+func (s *Severity) UnmarshalJSON(data []byte) error {
+	var code int
+	if err := json.Unmarshal(data, &code); err == nil {
+		// 0 stands for a missing severity in Harbor 1.x
+		if code == 0 {
+			*s = SeverityNone
+			return nil
+		}
+		return s.UnmarshalText([]byte(strconv.Itoa(code)))
+	}
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return err
+	}
+	if name == "" {
+		*s = SeverityNone
+		return nil
+	}
+	return s.UnmarshalText([]byte(name))
+}
+
+// ScanStatus is the status of the scan of an image.
+type ScanStatus string
+
+// Statuses of a scan, as reported by Harbor 2.x. Harbor 1.x reports them in
+// lower case, with finished in place of Success; use the methods of
+// ScanStatus rather than comparing the values.
+const (
+	ScanNotScanned ScanStatus = "Not Scanned"
+	ScanPending    ScanStatus = "Pending"
+	ScanScheduled  ScanStatus = "Scheduled"
+	ScanRunning    ScanStatus = "Running"
+	ScanSuccess    ScanStatus = "Success"
+	ScanError      ScanStatus = "Error"
+	ScanStopped    ScanStatus = "Stopped"
+)
+
+// Normalize returns the Harbor 2.x form of the status.
+func (s ScanStatus) Normalize() ScanStatus {
+	switch strings.ToLower(string(s)) {
+	case "", "not scanned", "notscanned":
+		return ScanNotScanned
+	case "pending":
+		return ScanPending
+	case "scheduled":
+		return ScanScheduled
+	case "running":
+		return ScanRunning
+	case "success", "finished":
+		return ScanSuccess
+	case "error":
+		return ScanError
+	case "stopped", "cancelled", "canceled":
+		return ScanStopped
+	}
+	return s
+}
+
+// Done reports whether the scan ended, successfully or not.
+func (s ScanStatus) Done() bool {
+	switch s.Normalize() {
+	case ScanSuccess, ScanError, ScanStopped:
+		return true
+	}
+	return false
+}
+
+// Succeeded reports whether the scan ended successfully.
+func (s ScanStatus) Succeeded() bool {
+	return s.Normalize() == ScanSuccess
+}
@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for _, tt := range []struct {
+		in      string
+		want    Severity
+		wantErr bool
+	}{
+		{"Critical", SeverityCritical, false},
+		{"high", SeverityHigh, false},
+		{" Negligible ", SeverityNegligible, false},
+		{"None", SeverityNone, false},
+		// Codes of Harbor 1.x
+		{"1", SeverityNone, false},
+		{"2", SeverityUnknown, false},
+		{"3", SeverityLow, false},
+		{"5", SeverityHigh, false},
+		{"6", SeverityUnknown, true},
+		{"severe", SeverityUnknown, true},
+	} {
+		got, err := ParseSeverity(tt.in)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseSeverity(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	var names []string
+	for _, sev := range Severities() {
+		names = append(names, sev.String())
+		if parsed, err := ParseSeverity(sev.String()); err != nil || parsed != sev {
+			t.Errorf("ParseSeverity(%q) = %v, %v", sev, parsed, err)
+		}
+	}
+	if want := []string{"None", "Unknown", "Negligible", "Low", "Medium", "High", "Critical"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("names %v, want %v", names, want)
+	}
+	if got := Severity(9).String(); got != "Severity(9)" {
+		t.Errorf("String of an invalid severity = %q", got)
+	}
+}
+
+func TestSeverityJSON(t *testing.T) {
+	var v struct {
+		V1      Severity `json:"v1"`
+		V2      Severity `json:"v2"`
+		Missing Severity `json:"missing"`
+		Empty   Severity `json:"empty"`
+	}
+	if err := json.Unmarshal([]byte(`{"v1":4,"v2":"Critical","missing":0,"empty":""}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.V1 != SeverityMedium || v.V2 != SeverityCritical || v.Missing != SeverityNone || v.Empty != SeverityNone {
+		t.Errorf("decoded %+v", v)
+	}
+	for _, data := range []string{`7`, `"severe"`, `true`} {
+		var sev Severity
+		if err := json.Unmarshal([]byte(data), &sev); err == nil {
+			t.Errorf("decoding %s succeeded: %v", data, sev)
+		}
+	}
+
+	data, err := json.Marshal(map[Severity]int{SeverityHigh: 2, SeverityLow: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"High":2,"Low":1}` {
+		t.Errorf("encoded %s", data)
+	}
+	var summary map[Severity]int
+	if err := json.Unmarshal(data, &summary); err != nil || summary[SeverityHigh] != 2 || summary[SeverityLow] != 1 {
+		t.Errorf("round trip %v, %v", summary, err)
+	}
+}
+
+func TestScanStatus(t *testing.T) {
+	for _, tt := range []struct {
+		status    ScanStatus
+		want      ScanStatus
+		done      bool
+		succeeded bool
+	}{
+		{"", ScanNotScanned, false, false},
+		{"pending", ScanPending, false, false},
+		{"Scheduled", ScanScheduled, false, false},
+		{"running", ScanRunning, false, false},
+		{"finished", ScanSuccess, true, true},
+		{ScanSuccess, ScanSuccess, true, true},
+		{"error", ScanError, true, false},
+		{"cancelled", ScanStopped, true, false},
+		{"Paused", "Paused", false, false},
+	} {
+		if got := tt.status.Normalize(); got != tt.want {
+			t.Errorf("%q.Normalize() = %q, want %q", tt.status, got, tt.want)
+		}
+		if tt.status.Done() != tt.done || tt.status.Succeeded() != tt.succeeded {
+			t.Errorf("%q: Done %v, Succeeded %v", tt.status, tt.status.Done(), tt.status.Succeeded())
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary, highest := Summarize(nil)
+	if len(summary) != 0 || highest != SeverityNone {
+		t.Errorf("Summarize(nil) = %v, %v", summary, highest)
+	}
+	items := []VulnerabilityItem{
+		{ID: "CVE-1", Severity: SeverityMedium},
+		{ID: "CVE-2", Severity: SeverityHigh},
+		{ID: "CVE-3", Severity: SeverityMedium},
+		{ID: "CVE-4", Severity: SeverityNegligible},
+	}
+	summary, highest = Summarize(items)
+	want := map[Severity]int{SeverityMedium: 2, SeverityHigh: 1, SeverityNegligible: 1}
+	if highest != SeverityHigh || !reflect.DeepEqual(summary, want) {
+		t.Errorf("Summarize = %v, %v", summary, highest)
+	}
+	report := ScanReport{Summary: summary}
+	for min, n := range map[Severity]int{SeverityCritical: 0, SeverityHigh: 1, SeverityMedium: 3, SeverityNone: 4} {
+		if got := report.Count(min); got != n {
+			t.Errorf("Count(%v) = %d, want %d", min, got, n)
+		}
+	}
+}
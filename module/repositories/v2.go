@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+// Routes of the Harbor 2.x API, where repositories are nested under their
+// project and tags are attached to artifacts.
+const (
+	repoV2          = "api.v2.repositories."
+	allV2           = repoV2 + "all"
+	rootV2          = repoV2 + "root"
+	baseV2          = repoV2 + "base"
+	artifactRootV2  = repoV2 + "artifacts.root"
+	artifactBaseV2  = repoV2 + "artifacts.base"
+	artifactTagV2   = repoV2 + "artifacts.tags.base"
+	artifactScanV2  = repoV2 + "artifacts.scan"
+	artifactVulnsV2 = repoV2 + "artifacts.vulnerabilities"
+	projectBase     = "api.projects.base"
+)
+
+// repositoryV2 is a repository as returned by Harbor 2.x.
+type repositoryV2 struct {
+	ID            int64     `json:"id"`
+	Name          string    `json:"name"`
+	ProjectID     int64     `json:"project_id"`
+	Description   string    `json:"description"`
+	ArtifactCount int64     `json:"artifact_count"`
+	PullCount     int64     `json:"pull_count"`
+	CreationTime  time.Time `json:"creation_time"`
+	UpdateTime    time.Time `json:"update_time"`
+}
+
+// artifactV2 holds the fields of a Harbor 2.x artifact needed to build the
+// 1.x tag representation.
+type artifactV2 struct {
+	Digest       string                    `json:"digest"`
+	Size         int64                     `json:"size"`
+	PushTime     time.Time                 `json:"push_time"`
+	ExtraAttrs   artifactAttrsV2           `json:"extra_attrs"`
+	Tags         []artifactTagV2Resp       `json:"tags"`
+	ScanOverview map[string]scanOverviewV2 `json:"scan_overview"`
+}
+
+type artifactAttrsV2 struct {
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Author       string    `json:"author"`
+	Created      time.Time `json:"created"`
+	Config       struct {
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+type artifactTagV2Resp struct {
+	Name     string    `json:"name"`
+	Signed   bool      `json:"signed"`
+	PushTime time.Time `json:"push_time"`
+}
+
+type scanOverviewV2 struct {
+	ReportID  string     `json:"report_id"`
+	Status    ScanStatus `json:"scan_status"`
+	Severity  Severity   `json:"severity"`
+	StartTime time.Time  `json:"start_time"`
+	EndTime   time.Time  `json:"end_time"`
+	Summary   struct {
+		Total   int              `json:"total"`
+		Summary map[Severity]int `json:"summary"`
+	} `json:"summary"`
+}
+
+// vulnerabilityReportV2 is one report of the vulnerabilities addition.
+type vulnerabilityReportV2 struct {
+	Vulnerabilities []struct {
+		ID          string   `json:"id"`
+		Package     string   `json:"package"`
+		Version     string   `json:"version"`
+		FixVersion  string   `json:"fix_version"`
+		Severity    Severity `json:"severity"`
+		Description string   `json:"description"`
+		Links       []string `json:"links"`
+	} `json:"vulnerabilities"`
+}
+
+// isV2 reports whether the server speaks the 2.x API, negotiating the API
+// version with ctx if need be.
+func (s *RepositoriesService) isV2(ctx context.Context) (bool, []error) {
+	v, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return false, []error{err}
+	}
+	return v == client2.APIVersion2, nil
+}
+
+// escapeRepo escapes the repository part of a name for Harbor 2.x, which
+// expects the slashes of nested repositories to be encoded twice.
+func escapeRepo(repo string) string {
+	return url.PathEscape(url.PathEscape(repo))
+}
+
+// splitRepoName splits a full repository name, e.g. library/nginx, into
+// its project and repository parts. Harbor names every repository after its
+// project, so a name without project is an error.
+func splitRepoName(name string) (string, string, error) {
+	i := strings.Index(name, "/")
+	if i <= 0 || i == len(name)-1 {
+		return "", "", fmt.Errorf("harbor: repository name %q is not of the form project/repository", name)
+	}
+	return name[:i], name[i+1:], nil
+}
+
+// requestV2 creates a request on the route key of the repository repoName,
+// completed by args. An invalid repoName is reported by Do.
+func (s *RepositoriesService) requestV2(method, key, repoName string, args ...interface{}) *gorequest.SuperAgent {
+	project, repo, err := splitRepoName(repoName)
+	if err != nil {
+		req := s.client.NewRequest(method, "")
+		req.Errors = append(req.Errors, err)
+		return req
+	}
+	return s.client.NewRequest(method, fmt.Sprintf(s.getConfigString(key), append([]interface{}{project, escapeRepo(repo)}, args...)...))
+}
+
+func (s *RepositoriesService) listV2(ctx context.Context, opt *ListRepositoriesOption) ([]RepoRecord, *gorequest.Response, []error) {
+	path := s.getConfigString(allV2)
+	if opt.ProjectId != 0 {
+		var project struct {
+			Name string `json:"name"`
+		}
+		resp, errs := s.client.Do(ctx, s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(projectBase), opt.ProjectId)), &project)
+		if len(errs) != 0 {
+			return nil, resp, errs
+		}
+		path = fmt.Sprintf(s.getConfigString(rootV2), project.Name)
+	}
+	query := url.Values{}
+	if opt.Page != 0 {
+		query.Set("page", fmt.Sprint(opt.Page))
+	}
+	if opt.PageSize != 0 {
+		query.Set("page_size", fmt.Sprint(opt.PageSize))
+	}
+	if opt.Q != "" {
+		query.Set("q", "name=~"+opt.Q)
+	}
+	if opt.Sort != "" {
+		query.Set("sort", opt.Sort)
+	}
+	var repos []repositoryV2
+	req := s.client.NewRequest(gorequest.GET, path).
+		Query(query.Encode())
+	resp, errs := s.client.Do(ctx, req, &repos)
+	records := make([]RepoRecord, 0, len(repos))
+	for _, r := range repos {
+		records = append(records, RepoRecord{
+			RepositoryID: r.ID,
+			Name:         r.Name,
+			ProjectID:    r.ProjectID,
+			Description:  r.Description,
+			PullCount:    r.PullCount,
+			CreationTime: r.CreationTime,
+			UpdateTime:   r.UpdateTime,
+		})
+	}
+	return records, resp, errs
+}
+
+func (s *RepositoriesService) getTagV2(ctx context.Context, projectName, repoName, tag string) (TagResp, *gorequest.Response, []error) {
+	var a artifactV2
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(artifactBaseV2), projectName, escapeRepo(repoName), tag)).
+		Query("with_tag=true&with_scan_overview=true&with_signature=true")
+	resp, errs := s.client.Do(ctx, req, &a)
+	if len(errs) != 0 {
+		return TagResp{}, resp, errs
+	}
+	for _, t := range a.Tags {
+		if t.Name == tag {
+			return a.tagResp(t), resp, nil
+		}
+	}
+	return a.tagResp(artifactTagV2Resp{Name: tag}), resp, nil
+}
+
+func (s *RepositoriesService) listTagsV2(ctx context.Context, projectName, repoName string) ([]TagResp, *gorequest.Response, []error) {
+	var (
+		tags []TagResp
+		last *gorequest.Response
+	)
+	pager := client2.NewPager(ctx, client2.ListOptions{PageSize: 100}, func(ctx context.Context, page client2.ListOptions) (int, *gorequest.Response, []error) {
+		var artifacts []artifactV2
+		req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(artifactRootV2), projectName, escapeRepo(repoName))).
+			Query(fmt.Sprintf("with_tag=true&with_scan_overview=true&with_signature=true&page=%d&page_size=%d", page.Page, page.PageSize))
+		resp, errs := s.client.Do(ctx, req, &artifacts)
+		last = resp
+		for _, a := range artifacts {
+			for _, t := range a.Tags {
+				tags = append(tags, a.tagResp(t))
+			}
+		}
+		return len(artifacts), resp, errs
+	})
+	for pager.Next() {
+	}
+	if err := pager.Err(); err != nil {
+		return nil, last, []error{err}
+	}
+	return tags, last, nil
+}
+
+func (s *RepositoriesService) getImageDetailsV2(ctx context.Context, repoName, tag string) ([]VulnerabilityItem, *gorequest.Response, []error) {
+	var reports map[string]vulnerabilityReportV2
+	req := s.requestV2(gorequest.GET, artifactVulnsV2, repoName, tag)
+	resp, errs := s.client.Do(ctx, req, &reports)
+	if len(errs) != 0 {
+		return nil, resp, errs
+	}
+	mimeTypes := make([]string, 0, len(reports))
+	for mimeType := range reports {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	var items []VulnerabilityItem
+	for _, mimeType := range mimeTypes {
+		for _, v := range reports[mimeType].Vulnerabilities {
+			item := VulnerabilityItem{
+				ID:          v.ID,
+				Severity:    v.Severity,
+				Pkg:         v.Package,
+				Version:     v.Version,
+				Description: v.Description,
+				Fixed:       v.FixVersion,
+			}
+			if len(v.Links) != 0 {
+				item.Link = v.Links[0]
+			}
+			items = append(items, item)
+		}
+	}
+	// The most severe first, in a stable order
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Severity != items[j].Severity {
+			return items[i].Severity > items[j].Severity
+		}
+		if items[i].ID != items[j].ID {
+			return items[i].ID < items[j].ID
+		}
+		return items[i].Pkg < items[j].Pkg
+	})
+	return items, resp, nil
+}
+
+// tagResp converts the artifact and one of its tags to the 1.x tag
+// representation.
+func (a artifactV2) tagResp(t artifactTagV2Resp) TagResp {
+	r := TagResp{
+		tagDetail: tagDetail{
+			Digest:       a.Digest,
+			Name:         t.Name,
+			Size:         a.Size,
+			Architecture: a.ExtraAttrs.Architecture,
+			OS:           a.ExtraAttrs.OS,
+			Author:       a.ExtraAttrs.Author,
+			Created:      a.ExtraAttrs.Created,
+			Config:       &cfg{Labels: a.ExtraAttrs.Config.Labels},
+		},
+	}
+	if t.Signed {
+		r.Signature = &Signature{Tag: t.Name}
+	}
+	// Harbor reports one overview per report MIME type, the first one is
+	// kept
+	mimeTypes := make([]string, 0, len(a.ScanOverview))
+	for mimeType := range a.ScanOverview {
+		mimeTypes = append(mimeTypes, mimeType)
+	}
+	sort.Strings(mimeTypes)
+	for _, mimeType := range mimeTypes {
+		o := a.ScanOverview[mimeType]
+		overview := &ImgScanOverview{
+			Digest:       a.Digest,
+			Status:       o.Status,
+			Sev:          o.Severity,
+			CreationTime: o.StartTime,
+			UpdateTime:   o.EndTime,
+			CompOverview: &ComponentsOverview{Total: o.Summary.Total},
+		}
+		severities := Severities()
+		for i := len(severities) - 1; i >= 0; i-- {
+			if count, ok := o.Summary.Summary[severities[i]]; ok {
+				overview.CompOverview.Summary = append(overview.CompOverview.Summary, &ComponentsOverviewEntry{Sev: severities[i], Count: count})
+			}
+		}
+		r.ScanOverview = overview
+		break
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+)
+
+func TestSplitRepoName(t *testing.T) {
+	for _, tt := range []struct {
+		name, project, repo string
+		err                 bool
+	}{
+		{name: "library/nginx", project: "library", repo: "nginx"},
+		{name: "team/tools/builder", project: "team", repo: "tools/builder"},
+		{name: "nginx", err: true},
+		{name: "/nginx", err: true},
+		{name: "library/", err: true},
+		{name: "", err: true},
+	} {
+		project, repo, err := splitRepoName(tt.name)
+		if (err != nil) != tt.err || project != tt.project || repo != tt.repo {
+			t.Errorf("splitRepoName(%q) = %q, %q, %v", tt.name, project, repo, err)
+		}
+	}
+}
+
+func TestTagRespSummaryOrder(t *testing.T) {
+	var a artifactV2
+	err := json.Unmarshal([]byte(`{
+		"digest": "sha256:1",
+		"scan_overview": {
+			"application/vnd.security.vulnerability.report; version=1.1": {
+				"scan_status": "Success",
+				"severity": "High",
+				"summary": {"total": 6, "summary": {"Low": 1, "Critical": 2, "Medium": 3}}
+			},
+			"application/vnd.scanner.adapter.vuln.report.harbor+json; version=1.0": {
+				"scan_status": "Success",
+				"severity": "Critical",
+				"summary": {"total": 9, "summary": {"High": 4, "Critical": 5, "Unknown": 0}}
+			}
+		}
+	}`), &a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		r := a.tagResp(artifactTagV2Resp{Name: "1.0"})
+		if r.ScanOverview == nil || r.ScanOverview.CompOverview.Total != 9 {
+			t.Fatalf("overview %+v, want the one of the first MIME type", r.ScanOverview)
+		}
+		var got []Severity
+		for _, e := range r.ScanOverview.CompOverview.Summary {
+			got = append(got, e.Sev)
+		}
+		if want := []Severity{SeverityCritical, SeverityHigh, SeverityUnknown}; !reflect.DeepEqual(got, want) {
+			t.Fatalf("summary %v, want %v", got, want)
+		}
+	}
+}
+
+func TestGetImageDetailsV2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.EscapedPath() != "/api/v2.0/projects/library/repositories/tools%252Fnginx/artifacts/1.19/additions/vulnerabilities" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{
+			"b": {"vulnerabilities": [
+				{"id": "CVE-2", "package": "zlib", "severity": "Low"},
+				{"id": "CVE-1", "package": "openssl", "severity": "Critical", "links": ["https://example.com/CVE-1"]}
+			]},
+			"a": {"vulnerabilities": [
+				{"id": "CVE-3", "package": "curl", "severity": "Critical"},
+				{"id": "CVE-1", "package": "libssl", "severity": "Critical"}
+			]}
+		}`))
+	}))
+	defer srv.Close()
+	c, err := client2.New(client2.WithBaseURL(srv.URL), client2.WithAPIVersion(client2.APIVersion2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewRepositoriesService(c)
+
+	for i := 0; i < 10; i++ {
+		items, _, errs := s.GetImageDetailsContext(context.Background(), "library/tools/nginx", "1.19")
+		if len(errs) != 0 {
+			t.Fatal(errs)
+		}
+		var got []string
+		for _, item := range items {
+			got = append(got, item.ID+" "+item.Pkg)
+		}
+		want := []string{"CVE-1 libssl", "CVE-1 openssl", "CVE-3 curl", "CVE-2 zlib"}
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("vulnerabilities %q, want %q", got, want)
+		}
+		if items[1].Link != "https://example.com/CVE-1" {
+			t.Errorf("link %q", items[1].Link)
+		}
+	}
+
+	if _, _, errs := s.GetImageDetailsContext(context.Background(), "nginx", "1.19"); len(errs) == 0 {
+		t.Error("GetImageDetails of a repository without project succeeded")
+	}
+}
@@ -0,0 +1,96 @@
+package retention
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Times the most recent tags can be ranked by
+const (
+	ByPushTime = "push_time"
+	ByCreated  = "created"
+)
+
+// Policy is the set of rules deciding which tags of a repository are kept.
+// A tag is kept when any rule retains it, and deleted otherwise. Signed and
+// immutable tags are always kept.
+type Policy struct {
+	// Keep the N most recent tags of each repository
+	KeepLatest int `json:"keep_latest,omitempty" yaml:"keep_latest,omitempty"`
+	// Time the most recent tags are ranked by, ByPushTime by default
+	By string `json:"by,omitempty" yaml:"by,omitempty"`
+	// Regular expressions of the tags to keep, e.g. ^release-
+	KeepMatching []string `json:"keep_matching,omitempty" yaml:"keep_matching,omitempty"`
+	// Keep the tags that are semantic versions satisfying the constraint,
+	// e.g. ">=1.0.0, <2", or any semantic version for "*"
+	KeepSemver string `json:"keep_semver,omitempty" yaml:"keep_semver,omitempty"`
+	// Keep the tags pulled within the last days
+	KeepPulledWithinDays int `json:"keep_pulled_within_days,omitempty" yaml:"keep_pulled_within_days,omitempty"`
+}
+
+// rules is a validated policy.
+type rules struct {
+	Policy
+	matching []*regexp.Regexp
+	semver   constraint
+}
+
+// ParsePolicy decodes a policy written in YAML or JSON.
+func ParsePolicy(data []byte) (Policy, error) {
+	var p Policy
+	if err := yaml.UnmarshalStrict(data, &p); err != nil {
+		return p, fmt.Errorf("retention: invalid policy: %v", err)
+	}
+	return p, p.Validate()
+}
+
+// LoadPolicy reads the policy of the YAML or JSON file at filename.
+func LoadPolicy(filename string) (Policy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return Policy{}, err
+	}
+	return ParsePolicy(data)
+}
+
+// Validate checks the rules of the policy. A policy retaining no tag is
+// rejected, as it would empty every repository.
+func (p Policy) Validate() error {
+	_, err := p.compile()
+	return err
+}
+
+func (p Policy) compile() (*rules, error) {
+	r := &rules{Policy: p}
+	switch p.By {
+	case "":
+		r.By = ByPushTime
+	case ByPushTime, ByCreated:
+	default:
+		return nil, fmt.Errorf("retention: invalid by %q, expected %s or %s", p.By, ByPushTime, ByCreated)
+	}
+	if p.KeepLatest < 0 || p.KeepPulledWithinDays < 0 {
+		return nil, fmt.Errorf("retention: negative keep_latest or keep_pulled_within_days")
+	}
+	for _, expr := range p.KeepMatching {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("retention: invalid pattern %q: %v", expr, err)
+		}
+		r.matching = append(r.matching, re)
+	}
+	if p.KeepSemver != "" {
+		c, err := parseConstraint(p.KeepSemver)
+		if err != nil {
+			return nil, err
+		}
+		r.semver = c
+	}
+	if p.KeepLatest == 0 && len(p.KeepMatching) == 0 && p.KeepSemver == "" && p.KeepPulledWithinDays == 0 {
+		return nil, fmt.Errorf("retention: the policy retains no tag")
+	}
+	return r, nil
+}
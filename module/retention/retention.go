@@ -0,0 +1,291 @@
+// Package retention deletes the old tags of repositories according to a
+// policy. A plan lists what would be kept and deleted and why, so that it
+// can be reviewed as a dry run before being executed.
+package retention
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// Tag is a tag of a repository, as known to the rules.
+type Tag struct {
+	// Full name of the repository, e.g. library/nginx
+	Repository string
+	Name       string
+	Digest     string
+	PushTime   time.Time
+	// Creation time of the image
+	Created time.Time
+	// Zero when the tag was never pulled, or the Source does not know
+	PullTime  time.Time
+	Signed    bool
+	Immutable bool
+}
+
+// rankTime returns the time the tag is ranked by, the creation time of the
+// image when the push time is unknown.
+func (t Tag) rankTime(by string) time.Time {
+	if by == ByPushTime && !t.PushTime.IsZero() {
+		return t.PushTime
+	}
+	return t.Created
+}
+
+// Source lists and deletes the tags of repositories, see Repositories and
+// Artifacts.
+type Source interface {
+	ListTags(ctx context.Context, repository string) ([]Tag, error)
+	DeleteTag(ctx context.Context, tag Tag) error
+}
+
+// DigestSource is implemented by the sources deleting a tag by the digest
+// of its image, which deletes the other tags of the digest along with it.
+// The plans of such a source never delete a digest of which a tag is kept.
+// DeleteTag must not fail on a tag already deleted with another tag of its
+// digest.
+type DigestSource interface {
+	Source
+	DeletesDigest() bool
+}
+
+// deletesDigest reports whether deleting a tag of src deletes its digest.
+func deletesDigest(src Source) bool {
+	ds, ok := src.(DigestSource)
+	return ok && ds.DeletesDigest()
+}
+
+// Decision tells whether a tag is kept, and why.
+type Decision struct {
+	Tag    Tag
+	Keep   bool
+	Reason string
+}
+
+// Evaluate decides which of the tags of a repository are kept at now.
+// Decisions are returned from the most recent tag.
+func (p Policy) Evaluate(tags []Tag, now time.Time) ([]Decision, error) {
+	r, err := p.compile()
+	if err != nil {
+		return nil, err
+	}
+	return r.evaluate(tags, now), nil
+}
+
+func (r *rules) evaluate(tags []Tag, now time.Time) []Decision {
+	tags = append([]Tag(nil), tags...)
+	sort.SliceStable(tags, func(i, j int) bool {
+		ti, tj := tags[i].rankTime(r.By), tags[j].rankTime(r.By)
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	decisions := make([]Decision, 0, len(tags))
+	for rank, tag := range tags {
+		reason := r.retains(tag, rank, now)
+		keep := reason != ""
+		if !keep {
+			reason = fmt.Sprintf("not retained, #%d most recent", rank+1)
+		}
+		decisions = append(decisions, Decision{Tag: tag, Keep: keep, Reason: reason})
+	}
+	return decisions
+}
+
+// keepDigests keeps the tags sharing their digest with a kept tag, the
+// decisions being of the tags of a single repository.
+func keepDigests(decisions []Decision) []Decision {
+	kept := map[string]string{}
+	for _, d := range decisions {
+		if _, ok := kept[d.Tag.Digest]; d.Keep && d.Tag.Digest != "" && !ok {
+			kept[d.Tag.Digest] = d.Tag.Name
+		}
+	}
+	for i, d := range decisions {
+		if name, ok := kept[d.Tag.Digest]; !d.Keep && ok {
+			decisions[i].Keep = true
+			decisions[i].Reason = "kept: shares digest with " + name
+		}
+	}
+	return decisions
+}
+
+// retains returns why the tag of the given rank is kept, or "" if it is not.
+func (r *rules) retains(tag Tag, rank int, now time.Time) string {
+	switch {
+	case tag.Signed:
+		return "signed"
+	case tag.Immutable:
+		return "immutable"
+	case rank < r.KeepLatest:
+		return fmt.Sprintf("among the %d most recent", r.KeepLatest)
+	}
+	for _, re := range r.matching {
+		if re.MatchString(tag.Name) {
+			return "matches " + re.String()
+		}
+	}
+	if r.semver != nil && r.semver.allows(tag.Name) {
+		return "semver matches " + r.KeepSemver
+	}
+	if r.KeepPulledWithinDays > 0 && !tag.PullTime.IsZero() && now.Sub(tag.PullTime) <= time.Duration(r.KeepPulledWithinDays)*24*time.Hour {
+		return fmt.Sprintf("pulled within %d days", r.KeepPulledWithinDays)
+	}
+	return ""
+}
+
+// Plan is the evaluation of a policy against repositories.
+type Plan struct {
+	Policy Policy
+	// Decisions, grouped by repository in the order they were given
+	Decisions []Decision
+}
+
+// NewPlan lists the tags of the repositories from src and evaluates the
+// policy against each of them at the time given by clock, time.Now if nil.
+// Nothing is deleted until Execute.
+func NewPlan(ctx context.Context, src Source, p Policy, repositories []string, clock func() time.Time) (Plan, error) {
+	r, err := p.compile()
+	if err != nil {
+		return Plan{Policy: p}, err
+	}
+	if clock == nil {
+		clock = time.Now
+	}
+	plan := Plan{Policy: r.Policy}
+	now := clock()
+	for _, repo := range repositories {
+		tags, err := src.ListTags(ctx, repo)
+		if err != nil {
+			return plan, fmt.Errorf("retention: list tags of %s: %w", repo, err)
+		}
+		decisions := r.evaluate(tags, now)
+		if deletesDigest(src) {
+			decisions = keepDigests(decisions)
+		}
+		plan.Decisions = append(plan.Decisions, decisions...)
+	}
+	return plan, nil
+}
+
+// Deletions returns the tags the plan deletes.
+func (p Plan) Deletions() []Tag {
+	var tags []Tag
+	for _, d := range p.Decisions {
+		if !d.Keep {
+			tags = append(tags, d.Tag)
+		}
+	}
+	return tags
+}
+
+// Write writes the plan in a human readable form, e.g. for a dry run.
+func (p Plan) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	deleted := 0
+	for _, d := range p.Decisions {
+		action := "keep"
+		if !d.Keep {
+			action = "delete"
+			deleted++
+		}
+		fmt.Fprintf(tw, "%s\t%s:%s\t%s\t%s\n", action, d.Tag.Repository, d.Tag.Name, formatTime(d.Tag.rankTime(p.Policy.By)), d.Reason)
+	}
+	fmt.Fprintf(tw, "%d tags kept, %d deleted\n", len(p.Decisions)-deleted, deleted)
+	return tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Failure is a tag that could not be deleted.
+type Failure struct {
+	Tag Tag
+	Err error
+}
+
+// Report is the result of the execution of a plan.
+type Report struct {
+	Deleted []Tag
+	Failed  []Failure
+	Kept    int
+}
+
+// Execute deletes the tags of the plan, up to concurrency at the same time.
+// The tags not deleted when ctx is done are reported as failed.
+func (p Plan) Execute(ctx context.Context, src Source, concurrency int) Report {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	var (
+		report  = Report{Kept: len(p.Decisions) - len(p.Deletions())}
+		tags    = p.Deletions()
+		errs    = make([]error, len(tags))
+		wg      sync.WaitGroup
+		workers = make(chan struct{}, concurrency)
+	)
+	for i, tag := range tags {
+		select {
+		case workers <- struct{}{}:
+		case <-ctx.Done():
+			errs[i] = ctx.Err()
+			continue
+		}
+		wg.Add(1)
+		go func(i int, tag Tag) {
+			defer func() {
+				<-workers
+				wg.Done()
+			}()
+			if err := ctx.Err(); err != nil {
+				errs[i] = err
+				return
+			}
+			errs[i] = src.DeleteTag(ctx, tag)
+		}(i, tag)
+	}
+	wg.Wait()
+	for i, tag := range tags {
+		if errs[i] != nil {
+			report.Failed = append(report.Failed, Failure{Tag: tag, Err: errs[i]})
+		} else {
+			report.Deleted = append(report.Deleted, tag)
+		}
+	}
+	return report
+}
+
+// Err returns an error summing up the failures, if any.
+func (r Report) Err() error {
+	if len(r.Failed) == 0 {
+		return nil
+	}
+	f := r.Failed[0]
+	return fmt.Errorf("retention: %d tags not deleted, e.g. %s:%s: %w", len(r.Failed), f.Tag.Repository, f.Tag.Name, f.Err)
+}
+
+// Write writes the report in a human readable form.
+func (r Report) Write(w io.Writer) error {
+	for _, tag := range r.Deleted {
+		if _, err := fmt.Fprintf(w, "deleted %s:%s\n", tag.Repository, tag.Name); err != nil {
+			return err
+		}
+	}
+	for _, f := range r.Failed {
+		if _, err := fmt.Fprintf(w, "failed  %s:%s: %v\n", f.Tag.Repository, f.Tag.Name, f.Err); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d tags deleted, %d failed, %d kept\n", len(r.Deleted), len(r.Failed), r.Kept)
+	return err
+}
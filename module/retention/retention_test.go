@@ -0,0 +1,240 @@
+package retention
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2020, 6, 30, 12, 0, 0, 0, time.UTC)
+
+func clock() time.Time {
+	return now
+}
+
+func daysAgo(n int) time.Time {
+	return now.Add(-time.Duration(n) * 24 * time.Hour)
+}
+
+// fakeSource serves tags from memory. With byDigest, deleting a tag deletes
+// the other tags of its digest, as Harbor 1.x does.
+type fakeSource struct {
+	byDigest bool
+
+	mu   sync.Mutex
+	tags map[string][]Tag
+}
+
+func (f *fakeSource) DeletesDigest() bool {
+	return f.byDigest
+}
+
+func (f *fakeSource) ListTags(ctx context.Context, repository string) ([]Tag, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tags, ok := f.tags[repository]
+	if !ok {
+		return nil, fmt.Errorf("no repository %s", repository)
+	}
+	return append([]Tag(nil), tags...), nil
+}
+
+func (f *fakeSource) DeleteTag(ctx context.Context, tag Tag) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var left []Tag
+	for _, t := range f.tags[tag.Repository] {
+		if t.Name != tag.Name && !(f.byDigest && t.Digest == tag.Digest) {
+			left = append(left, t)
+		}
+	}
+	f.tags[tag.Repository] = left
+	return nil
+}
+
+func (f *fakeSource) names(repository string) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var names []string
+	for _, t := range f.tags[repository] {
+		names = append(names, t.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func decisions(plan Plan) []string {
+	var got []string
+	for _, d := range plan.Decisions {
+		action := "keep"
+		if !d.Keep {
+			action = "delete"
+		}
+		got = append(got, action+" "+d.Tag.Name+": "+d.Reason)
+	}
+	return got
+}
+
+func TestEvaluate(t *testing.T) {
+	p := Policy{KeepLatest: 2, KeepMatching: []string{"^release-"}, KeepSemver: ">=2.0.0", KeepPulledWithinDays: 7}
+	tags := []Tag{
+		{Name: "old", PushTime: daysAgo(30)},
+		{Name: "latest", PushTime: daysAgo(1)},
+		{Name: "release-1", PushTime: daysAgo(20)},
+		{Name: "1.0.0", PushTime: daysAgo(10)},
+		{Name: "2.1.0", PushTime: daysAgo(12)},
+		{Name: "pulled", PushTime: daysAgo(25), PullTime: daysAgo(3)},
+		{Name: "signed", PushTime: daysAgo(40), Signed: true},
+		{Name: "dev", PushTime: daysAgo(2)},
+	}
+	got, err := p.Evaluate(tags, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"keep latest: among the 2 most recent",
+		"keep dev: among the 2 most recent",
+		"delete 1.0.0: not retained, #3 most recent",
+		"keep 2.1.0: semver matches >=2.0.0",
+		"keep release-1: matches ^release-",
+		"keep pulled: pulled within 7 days",
+		"delete old: not retained, #7 most recent",
+		"keep signed: signed",
+	}
+	if g := decisions(Plan{Decisions: got}); !reflect.DeepEqual(g, want) {
+		t.Errorf("Evaluate =\n%q\nwant\n%q", g, want)
+	}
+}
+
+func TestPolicyValidate(t *testing.T) {
+	for _, p := range []Policy{
+		{},
+		{KeepLatest: -1},
+		{KeepLatest: 1, By: "size"},
+		{KeepMatching: []string{"("}},
+		{KeepSemver: "not a constraint"},
+	} {
+		if err := p.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded", p)
+		}
+	}
+	p, err := ParsePolicy([]byte("keep_latest: 3\nkeep_semver: \"*\"\n"))
+	if err != nil || p.KeepLatest != 3 || p.KeepSemver != "*" {
+		t.Errorf("ParsePolicy = %+v, %v", p, err)
+	}
+	if _, err := ParsePolicy([]byte("keep_lastest: 3\n")); err == nil {
+		t.Error("ParsePolicy accepted an unknown field")
+	}
+}
+
+func TestNewPlanUsesClock(t *testing.T) {
+	src := &fakeSource{tags: map[string][]Tag{
+		"library/nginx": {
+			{Repository: "library/nginx", Name: "a", PushTime: daysAgo(30), PullTime: daysAgo(5)},
+			{Repository: "library/nginx", Name: "b", PushTime: daysAgo(1)},
+		},
+	}}
+	p := Policy{KeepLatest: 1, KeepPulledWithinDays: 7}
+	plan, err := NewPlan(context.Background(), src, p, []string{"library/nginx"}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := plan.Decisions[1]; d.Tag.Name != "a" || !d.Keep {
+		t.Errorf("a pulled 5 days before the clock: %+v", d)
+	}
+	later := func() time.Time { return now.Add(10 * 24 * time.Hour) }
+	plan, err = NewPlan(context.Background(), src, p, []string{"library/nginx"}, later)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := plan.Decisions[1]; d.Tag.Name != "a" || d.Keep {
+		t.Errorf("a pulled 15 days before the clock: %+v", d)
+	}
+}
+
+func TestNewPlanSharedDigests(t *testing.T) {
+	tags := []Tag{
+		{Repository: "library/nginx", Name: "latest", Digest: "sha256:b", PushTime: daysAgo(1)},
+		{Repository: "library/nginx", Name: "1.19", Digest: "sha256:b", PushTime: daysAgo(2)},
+		{Repository: "library/nginx", Name: "1.18", Digest: "sha256:a", PushTime: daysAgo(3)},
+		{Repository: "library/nginx", Name: "1.18-alpine", Digest: "sha256:a", PushTime: daysAgo(4)},
+		{Repository: "library/nginx", Name: "1.17", Digest: "sha256:c", PushTime: daysAgo(5)},
+		{Repository: "library/nginx", Name: "1.17-alpine", Digest: "sha256:c", PushTime: daysAgo(6)},
+	}
+	p := Policy{KeepLatest: 1, KeepMatching: []string{"^1\\.18$"}}
+
+	for _, tt := range []struct {
+		name     string
+		byDigest bool
+		want     []string
+		left     []string
+	}{
+		{
+			name:     "by digest",
+			byDigest: true,
+			want: []string{
+				"keep latest: among the 1 most recent",
+				"keep 1.19: kept: shares digest with latest",
+				"keep 1.18: matches ^1\\.18$",
+				"keep 1.18-alpine: kept: shares digest with 1.18",
+				"delete 1.17: not retained, #5 most recent",
+				"delete 1.17-alpine: not retained, #6 most recent",
+			},
+			left: []string{"1.18", "1.18-alpine", "1.19", "latest"},
+		},
+		{
+			name: "by tag",
+			want: []string{
+				"keep latest: among the 1 most recent",
+				"delete 1.19: not retained, #2 most recent",
+				"keep 1.18: matches ^1\\.18$",
+				"delete 1.18-alpine: not retained, #4 most recent",
+				"delete 1.17: not retained, #5 most recent",
+				"delete 1.17-alpine: not retained, #6 most recent",
+			},
+			left: []string{"1.18", "latest"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := &fakeSource{byDigest: tt.byDigest, tags: map[string][]Tag{"library/nginx": tags}}
+			plan, err := NewPlan(context.Background(), src, p, []string{"library/nginx"}, clock)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := decisions(plan); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("plan =\n%q\nwant\n%q", got, tt.want)
+			}
+			report := plan.Execute(context.Background(), src, 2)
+			if err := report.Err(); err != nil {
+				t.Fatal(err)
+			}
+			if got := src.names("library/nginx"); !reflect.DeepEqual(got, tt.left) {
+				t.Errorf("tags left %q, want %q", got, tt.left)
+			}
+		})
+	}
+}
+
+func TestExecuteCancelled(t *testing.T) {
+	src := &fakeSource{tags: map[string][]Tag{
+		"library/nginx": {
+			{Repository: "library/nginx", Name: "a", PushTime: daysAgo(1)},
+			{Repository: "library/nginx", Name: "b", PushTime: daysAgo(2)},
+			{Repository: "library/nginx", Name: "c", PushTime: daysAgo(3)},
+		},
+	}}
+	plan, err := NewPlan(context.Background(), src, Policy{KeepLatest: 1}, []string{"library/nginx"}, clock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report := plan.Execute(ctx, src, 1)
+	if len(report.Failed) != 2 || len(report.Deleted) != 0 || report.Kept != 1 || report.Err() == nil {
+		t.Errorf("Execute with a cancelled context = %+v", report)
+	}
+}
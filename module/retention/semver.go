@@ -0,0 +1,156 @@
+package retention
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// version is a semantic version, e.g. v1.2.3-rc.1+build. The build metadata
+// is ignored.
+type version struct {
+	major, minor, patch int
+	pre                 []string
+}
+
+// parseVersion parses a semantic version, with an optional v prefix. The
+// minor and patch numbers may be left out, e.g. 1.2.
+func parseVersion(s string) (version, bool) {
+	var v version
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.Index(s, "+"); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.Index(s, "-"); i >= 0 {
+		if i == len(s)-1 {
+			return v, false
+		}
+		v.pre = strings.Split(s[i+1:], ".")
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) > 3 {
+		return v, false
+	}
+	nums := []*int{&v.major, &v.minor, &v.patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 || part != strconv.Itoa(n) {
+			return v, false
+		}
+		*nums[i] = n
+	}
+	return v, true
+}
+
+// compare returns -1, 0 or 1 when v is lower, equal or greater than o.
+func (v version) compare(o version) int {
+	for _, d := range []int{v.major - o.major, v.minor - o.minor, v.patch - o.patch} {
+		if d != 0 {
+			return sign(d)
+		}
+	}
+	// A pre-release is lower than its release
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		a, aerr := strconv.Atoi(v.pre[i])
+		b, berr := strconv.Atoi(o.pre[i])
+		switch {
+		case aerr == nil && berr == nil:
+			if a != b {
+				return sign(a - b)
+			}
+		case aerr == nil:
+			return -1
+		case berr == nil:
+			return 1
+		default:
+			if c := strings.Compare(v.pre[i], o.pre[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return sign(len(v.pre) - len(o.pre))
+}
+
+func sign(d int) int {
+	switch {
+	case d < 0:
+		return -1
+	case d > 0:
+		return 1
+	}
+	return 0
+}
+
+// constraint is a list of comparisons a version must all satisfy; an empty
+// constraint is satisfied by any version.
+type constraint []comparison
+
+type comparison struct {
+	op string
+	v  version
+}
+
+// parseConstraint parses comparisons separated by commas, e.g.
+// ">=1.0, <2", or "*".
+func parseConstraint(s string) (constraint, error) {
+	c := constraint{}
+	if strings.TrimSpace(s) == "*" {
+		return c, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		op := strings.TrimRight(part[:len(part)-len(strings.TrimLeft(part, "<>=!"))], " ")
+		switch op {
+		case "":
+			op = "="
+		case "=", "!=", "<", "<=", ">", ">=":
+		default:
+			return nil, fmt.Errorf("retention: invalid semver constraint %q", s)
+		}
+		v, ok := parseVersion(strings.TrimSpace(strings.TrimLeft(part, "<>=!")))
+		if !ok {
+			return nil, fmt.Errorf("retention: invalid semver constraint %q", s)
+		}
+		c = append(c, comparison{op: op, v: v})
+	}
+	return c, nil
+}
+
+// allows reports whether the tag is a semantic version satisfying c.
+func (c constraint) allows(tag string) bool {
+	v, ok := parseVersion(tag)
+	if !ok {
+		return false
+	}
+	for _, cmp := range c {
+		d := v.compare(cmp.v)
+		var ok bool
+		switch cmp.op {
+		case "=":
+			ok = d == 0
+		case "!=":
+			ok = d != 0
+		case "<":
+			ok = d < 0
+		case "<=":
+			ok = d <= 0
+		case ">":
+			ok = d > 0
+		case ">=":
+			ok = d >= 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
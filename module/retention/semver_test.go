@@ -0,0 +1,34 @@
+package retention
+
+import "testing"
+
+func TestConstraintAllows(t *testing.T) {
+	for _, tt := range []struct {
+		constraint string
+		tag        string
+		want       bool
+	}{
+		{"*", "1.2.3", true},
+		{"*", "latest", false},
+		{">=1.0, <2", "1.9.9", true},
+		{">=1.0, <2", "2.0.0", false},
+		{">=1.0, <2", "0.9", false},
+		{"!=1.2.3", "1.2.3", false},
+		{"1.2", "v1.2.0", true},
+		{">1.0.0", "1.0.1-rc.1", true},
+		{">=1.0.0", "1.0.0-rc.1", false},
+	} {
+		c, err := parseConstraint(tt.constraint)
+		if err != nil {
+			t.Fatalf("parseConstraint(%q): %v", tt.constraint, err)
+		}
+		if got := c.allows(tt.tag); got != tt.want {
+			t.Errorf("%q allows %q = %v, want %v", tt.constraint, tt.tag, got, tt.want)
+		}
+	}
+	for _, s := range []string{"~1.0", ">=x", ">=1.0,"} {
+		if _, err := parseConstraint(s); err == nil {
+			t.Errorf("parseConstraint(%q) succeeded", s)
+		}
+	}
+}
@@ -0,0 +1,134 @@
+package retention
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/module/artifacts"
+	"github.com/codingXiang/go-harbor-client/module/repositories"
+)
+
+// splitRepository splits a full repository name, e.g. library/nginx, into
+// its project and repository parts.
+func splitRepository(name string) (string, string) {
+	i := strings.Index(name, "/")
+	if i < 0 {
+		return "", name
+	}
+	return name[:i], name[i+1:]
+}
+
+// firstError returns the first of errs, if any.
+func firstError(errs []error) error {
+	if len(errs) != 0 {
+		return errs[0]
+	}
+	return nil
+}
+
+type repositoriesSource struct {
+	s repositories.Service
+}
+
+// Repositories returns a Source using the repositories service, served by
+// Harbor 1.x and 2.x. It does not know when tags were pushed or pulled: tags
+// are ranked by the creation time of their image, and KeepPulledWithinDays
+// retains none of them.
+//
+// Harbor 1.x deletes a tag by the digest of its image, along with the other
+// tags of the image, so the source is a DigestSource: the tags sharing their
+// digest with a kept tag are kept too. Against 2.x, which deletes tags one
+// by one, this keeps more tags than the policy asks.
+func Repositories(s repositories.Service) Source {
+	return repositoriesSource{s: s}
+}
+
+func (src repositoriesSource) DeletesDigest() bool {
+	return true
+}
+
+func (src repositoriesSource) ListTags(ctx context.Context, repository string) ([]Tag, error) {
+	project, repo := splitRepository(repository)
+	resp, _, errs := src.s.ListTagsContext(ctx, project, repo)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, 0, len(resp))
+	for _, t := range resp {
+		tags = append(tags, Tag{
+			Repository: repository,
+			Name:       t.Name,
+			Digest:     t.Digest,
+			Created:    t.Created,
+			Signed:     t.Signature != nil,
+		})
+	}
+	return tags, nil
+}
+
+func (src repositoriesSource) DeleteTag(ctx context.Context, tag Tag) error {
+	project, repo := splitRepository(tag.Repository)
+	_, errs := src.s.DeleteTagContext(ctx, project, repo, tag.Name)
+	// Already deleted with another tag of its digest
+	if err := firstError(errs); err != nil && !client2.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+type artifactsSource struct {
+	s artifacts.Service
+}
+
+// Artifacts returns a Source using the artifacts service of Harbor 2.x,
+// which knows when tags were pushed and pulled, and whether they are
+// immutable. Deleting a tag leaves its artifact, untagged, to the garbage
+// collection of Harbor.
+func Artifacts(s artifacts.Service) Source {
+	return artifactsSource{s: s}
+}
+
+func (src artifactsSource) ListTags(ctx context.Context, repository string) ([]Tag, error) {
+	project, repo := splitRepository(repository)
+	opt := &artifacts.ListArtifactsOptions{
+		ListOptions:         client2.ListOptions{PageSize: 100},
+		WithSignature:       true,
+		WithImmutableStatus: true,
+	}
+	list, errs := src.s.ListAll(ctx, project, repo, opt, 4)
+	if err := firstError(errs); err != nil {
+		return nil, err
+	}
+	var tags []Tag
+	for _, a := range list {
+		var created time.Time
+		if s, ok := a.ExtraAttrs["created"].(string); ok {
+			created, _ = time.Parse(time.RFC3339Nano, s)
+		}
+		for _, t := range a.Tags {
+			tags = append(tags, Tag{
+				Repository: repository,
+				Name:       t.Name,
+				Digest:     a.Digest,
+				PushTime:   t.PushTime,
+				Created:    created,
+				PullTime:   t.PullTime,
+				Signed:     t.Signed,
+				Immutable:  t.Immutable,
+			})
+		}
+	}
+	return tags, nil
+}
+
+func (src artifactsSource) DeleteTag(ctx context.Context, tag Tag) error {
+	project, repo := splitRepository(tag.Repository)
+	reference := tag.Digest
+	if reference == "" {
+		reference = tag.Name
+	}
+	_, errs := src.s.DeleteTagContext(ctx, project, repo, reference, tag.Name)
+	return firstError(errs)
+}
@@ -0,0 +1,162 @@
+package robots
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/client"
+)
+
+// Levels of a robot account
+const (
+	LevelSystem  = "system"
+	LevelProject = "project"
+)
+
+// Kinds of the permission of a robot account
+const (
+	KindSystem  = "system"
+	KindProject = "project"
+)
+
+// Resources a robot account can be granted access to
+const (
+	ResourceRepository       = "repository"
+	ResourceArtifact         = "artifact"
+	ResourceArtifactLabel    = "artifact-label"
+	ResourceTag              = "tag"
+	ResourceScan             = "scan"
+	ResourceHelmChart        = "helm-chart"
+	ResourceHelmChartVersion = "helm-chart-version"
+)
+
+// Actions a robot account can be granted
+const (
+	ActionPull   = "pull"
+	ActionPush   = "push"
+	ActionRead   = "read"
+	ActionList   = "list"
+	ActionCreate = "create"
+	ActionDelete = "delete"
+)
+
+// DurationNever is the duration of a robot account that never expires.
+const DurationNever = -1
+
+// Access grants an action on a resource.
+type Access struct {
+	Resource string `json:"resource"`
+	Action   string `json:"action"`
+	Effect   string `json:"effect,omitempty"`
+}
+
+// Permission is a set of accesses granted on a namespace, a project name or
+// "*" for every project.
+type Permission struct {
+	Kind      string   `json:"kind"`
+	Namespace string   `json:"namespace"`
+	Access    []Access `json:"access"`
+}
+
+// ProjectPermission returns the permission granting access on the project
+// named namespace.
+func ProjectPermission(namespace string, access ...Access) Permission {
+	return Permission{Kind: KindProject, Namespace: namespace, Access: access}
+}
+
+// ProjectResource returns the resource of a project as expected by the
+// project-level robot accounts, e.g. /project/1/repository.
+func ProjectResource(projectID int64, resource string) string {
+	return fmt.Sprintf("/project/%d/%s", projectID, resource)
+}
+
+// Robot holds the details of a robot account.
+type Robot struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Level       string       `json:"level"`
+	Duration    int64        `json:"duration"`
+	Editable    bool         `json:"editable"`
+	Disable     bool         `json:"disable"`
+	ExpiresAt   int64        `json:"expires_at"`
+	Permissions []Permission `json:"permissions"`
+	// Set for project-level robot accounts only
+	ProjectID    int64     `json:"project_id,omitempty"`
+	CreationTime time.Time `json:"creation_time"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+// Expired reports whether the robot account has expired at t.
+func (r Robot) Expired(t time.Time) bool {
+	return r.ExpiresAt > 0 && t.Unix() >= r.ExpiresAt
+}
+
+// RobotRequest holds the fields of a system-level robot account to create.
+type RobotRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Level       string `json:"level"`
+	Disable     bool   `json:"disable"`
+	// Lifetime in days, DurationNever for no expiry
+	Duration    int64        `json:"duration"`
+	Permissions []Permission `json:"permissions"`
+}
+
+// ProjectRobotRequest holds the fields of a project-level robot account to
+// create.
+type ProjectRobotRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Unix time of the expiry, 0 for the default of the system
+	ExpiresAt int64    `json:"expires_at,omitempty"`
+	Access    []Access `json:"access"`
+}
+
+// RobotCreated is the response to the creation of a robot account, holding
+// the secret that Harbor never returns again.
+type RobotCreated struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Secret       string    `json:"secret"`
+	CreationTime time.Time `json:"creation_time"`
+	ExpiresAt    int64     `json:"expires_at"`
+}
+
+// RobotSecret holds the secret of a robot account.
+type RobotSecret struct {
+	Secret string `json:"secret"`
+}
+
+// ListRobotsOptions specifies the optional parameters of the list methods.
+type ListRobotsOptions struct {
+	client.ListOptions
+	Q    string `json:"q,omitempty"`
+	Sort string `json:"sort,omitempty"`
+}
+
+// projectRobot is a project-level robot account as returned by Harbor.
+type projectRobot struct {
+	ID           int64     `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	ProjectID    int64     `json:"project_id"`
+	ExpiresAt    int64     `json:"expires_at"`
+	Disabled     bool      `json:"disabled"`
+	CreationTime time.Time `json:"creation_time"`
+	UpdateTime   time.Time `json:"update_time"`
+}
+
+func (r projectRobot) robot() Robot {
+	return Robot{
+		ID:           r.ID,
+		Name:         r.Name,
+		Description:  r.Description,
+		Level:        LevelProject,
+		Disable:      r.Disabled,
+		ExpiresAt:    r.ExpiresAt,
+		ProjectID:    r.ProjectID,
+		CreationTime: r.CreationTime,
+		UpdateTime:   r.UpdateTime,
+	}
+}
@@ -0,0 +1,360 @@
+package robots
+
+import (
+	"context"
+	"fmt"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/parnurzeal/gorequest"
+)
+
+const (
+	projectRoot = "api.projects.robots.root"
+	projectBase = "api.projects.robots.base"
+	root        = "api.robots.root"
+	base        = "api.robots.base"
+)
+
+// RobotsService handles communication with the robot account related
+// methods of the Harbor API. Project-level robot accounts are available from
+// Harbor 1.9, system-level ones from Harbor 2.2.
+//
+// Harbor API docs: https://github.com/goharbor/harbor/blob/v2.2.0/api/v2.0/swagger.yaml
+type Service interface {
+	//列出專案的 robot 帳號
+	ListProjectRobots(projectID int64, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error)
+	ListProjectRobotsContext(ctx context.Context, projectID int64, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error)
+	//取得專案的特定 robot 帳號
+	GetProjectRobot(projectID, robotID int64) (Robot, *gorequest.Response, []error)
+	GetProjectRobotContext(ctx context.Context, projectID, robotID int64) (Robot, *gorequest.Response, []error)
+	//建立專案的 robot 帳號，回傳的 secret 只會出現這一次
+	CreateProjectRobot(projectID int64, r ProjectRobotRequest) (RobotCreated, *gorequest.Response, []error)
+	CreateProjectRobotContext(ctx context.Context, projectID int64, r ProjectRobotRequest) (RobotCreated, *gorequest.Response, []error)
+	//啟用專案的 robot 帳號
+	EnableProjectRobot(projectID, robotID int64) (*gorequest.Response, []error)
+	EnableProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error)
+	//停用專案的 robot 帳號
+	DisableProjectRobot(projectID, robotID int64) (*gorequest.Response, []error)
+	DisableProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error)
+	//刪除專案的 robot 帳號
+	DeleteProjectRobot(projectID, robotID int64) (*gorequest.Response, []error)
+	DeleteProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error)
+	//列出 robot 帳號
+	List(opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error)
+	ListContext(ctx context.Context, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error)
+	//取得特定 robot 帳號
+	Get(id int64) (Robot, *gorequest.Response, []error)
+	GetContext(ctx context.Context, id int64) (Robot, *gorequest.Response, []error)
+	//建立 robot 帳號，回傳的 secret 只會出現這一次
+	Create(r RobotRequest) (RobotCreated, *gorequest.Response, []error)
+	CreateContext(ctx context.Context, r RobotRequest) (RobotCreated, *gorequest.Response, []error)
+	//更新 robot 帳號
+	Update(id int64, r Robot) (*gorequest.Response, []error)
+	UpdateContext(ctx context.Context, id int64, r Robot) (*gorequest.Response, []error)
+	//啟用 robot 帳號
+	Enable(id int64) (*gorequest.Response, []error)
+	EnableContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+	//停用 robot 帳號
+	Disable(id int64) (*gorequest.Response, []error)
+	DisableContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+	//更新 robot 帳號的 secret，secret 為空時由 Harbor 產生
+	RefreshSecret(id int64, secret string) (RobotSecret, *gorequest.Response, []error)
+	RefreshSecretContext(ctx context.Context, id int64, secret string) (RobotSecret, *gorequest.Response, []error)
+	//刪除 robot 帳號
+	Delete(id int64) (*gorequest.Response, []error)
+	DeleteContext(ctx context.Context, id int64) (*gorequest.Response, []error)
+}
+
+type RobotsService struct {
+	client client2.ClientInterface
+}
+
+func NewRobotsService(client client2.ClientInterface) Service {
+	return &RobotsService{client: client}
+}
+
+func (s *RobotsService) getConfigString(key string) string {
+	return s.client.GetConfig().GetString(key)
+}
+
+// supported reports whether the server speaks the 2.x API, required by the
+// system-level robot accounts.
+func (s *RobotsService) supported(ctx context.Context) []error {
+	v, err := s.client.NegotiateAPIVersion(ctx)
+	if err != nil {
+		return []error{err}
+	}
+	if v != client2.APIVersion2 {
+		return []error{client2.ErrNotSupported}
+	}
+	return nil
+}
+
+// List robot accounts of a project.
+//
+// This endpoint lists the robot accounts of the specified project.
+func (s *RobotsService) ListProjectRobots(projectID int64, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error) {
+	return s.ListProjectRobotsContext(context.Background(), projectID, opt)
+}
+
+// ListProjectRobotsContext is like ListProjectRobots but carries ctx to the
+// request.
+func (s *RobotsService) ListProjectRobotsContext(ctx context.Context, projectID int64, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error) {
+	var v []projectRobot
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(projectRoot), projectID))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	robots := make([]Robot, 0, len(v))
+	for _, r := range v {
+		robots = append(robots, r.robot())
+	}
+	return robots, resp, errs
+}
+
+// Get a robot account of a project.
+//
+// This endpoint returns the specified robot account of the project.
+func (s *RobotsService) GetProjectRobot(projectID, robotID int64) (Robot, *gorequest.Response, []error) {
+	return s.GetProjectRobotContext(context.Background(), projectID, robotID)
+}
+
+// GetProjectRobotContext is like GetProjectRobot but carries ctx to the
+// request.
+func (s *RobotsService) GetProjectRobotContext(ctx context.Context, projectID, robotID int64) (Robot, *gorequest.Response, []error) {
+	var v projectRobot
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(projectBase), projectID, robotID))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v.robot(), resp, errs
+}
+
+// Create a robot account for a project.
+//
+// This endpoint creates a robot account granted the accesses of r on the
+// specified project. The secret of the account is only returned here.
+func (s *RobotsService) CreateProjectRobot(projectID int64, r ProjectRobotRequest) (RobotCreated, *gorequest.Response, []error) {
+	return s.CreateProjectRobotContext(context.Background(), projectID, r)
+}
+
+// CreateProjectRobotContext is like CreateProjectRobot but carries ctx to
+// the request.
+func (s *RobotsService) CreateProjectRobotContext(ctx context.Context, projectID int64, r ProjectRobotRequest) (RobotCreated, *gorequest.Response, []error) {
+	var v struct {
+		Name  string `json:"name"`
+		Token string `json:"token"`
+	}
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(projectRoot), projectID)).
+		Send(r)
+	resp, errs := s.client.Do(ctx, req, &v)
+	created := RobotCreated{Name: v.Name, Secret: v.Token, ExpiresAt: r.ExpiresAt}
+	// Harbor only reports the ID of the new account in the Location header
+	if len(errs) == 0 {
+		created.ID, _ = client2.LocationID(resp)
+	}
+	return created, resp, errs
+}
+
+// Enable a robot account of a project.
+func (s *RobotsService) EnableProjectRobot(projectID, robotID int64) (*gorequest.Response, []error) {
+	return s.EnableProjectRobotContext(context.Background(), projectID, robotID)
+}
+
+// EnableProjectRobotContext is like EnableProjectRobot but carries ctx to
+// the request.
+func (s *RobotsService) EnableProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error) {
+	return s.setProjectRobotDisabled(ctx, projectID, robotID, false)
+}
+
+// Disable a robot account of a project.
+func (s *RobotsService) DisableProjectRobot(projectID, robotID int64) (*gorequest.Response, []error) {
+	return s.DisableProjectRobotContext(context.Background(), projectID, robotID)
+}
+
+// DisableProjectRobotContext is like DisableProjectRobot but carries ctx to
+// the request.
+func (s *RobotsService) DisableProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error) {
+	return s.setProjectRobotDisabled(ctx, projectID, robotID, true)
+}
+
+func (s *RobotsService) setProjectRobotDisabled(ctx context.Context, projectID, robotID int64, disabled bool) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(projectBase), projectID, robotID)).
+		Send(struct {
+			Disabled bool `json:"disabled"`
+		}{disabled})
+	return s.client.Do(ctx, req, nil)
+}
+
+// Delete a robot account of a project.
+//
+// This endpoint deletes the specified robot account of the project.
+func (s *RobotsService) DeleteProjectRobot(projectID, robotID int64) (*gorequest.Response, []error) {
+	return s.DeleteProjectRobotContext(context.Background(), projectID, robotID)
+}
+
+// DeleteProjectRobotContext is like DeleteProjectRobot but carries ctx to
+// the request.
+func (s *RobotsService) DeleteProjectRobotContext(ctx context.Context, projectID, robotID int64) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(projectBase), projectID, robotID))
+	return s.client.Do(ctx, req, nil)
+}
+
+// List robot accounts.
+//
+// This endpoint lists the system-level and project-level robot accounts
+// matching opt. It requires Harbor 2.2 or later.
+func (s *RobotsService) List(opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error) {
+	return s.ListContext(context.Background(), opt)
+}
+
+// ListContext is like List but carries ctx to the request.
+func (s *RobotsService) ListContext(ctx context.Context, opt *ListRobotsOptions) ([]Robot, *gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return nil, new(gorequest.Response), errs
+	}
+	var v []Robot
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(root))
+	if opt != nil {
+		req.Query(*opt)
+	}
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Get a robot account.
+//
+// This endpoint returns the specified robot account. It requires Harbor 2.2
+// or later.
+func (s *RobotsService) Get(id int64) (Robot, *gorequest.Response, []error) {
+	return s.GetContext(context.Background(), id)
+}
+
+// GetContext is like Get but carries ctx to the request.
+func (s *RobotsService) GetContext(ctx context.Context, id int64) (Robot, *gorequest.Response, []error) {
+	var v Robot
+	if errs := s.supported(ctx); errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Create a robot account.
+//
+// This endpoint creates a robot account granted the permissions of r. The
+// secret of the account is only returned here. It requires Harbor 2.2 or
+// later.
+func (s *RobotsService) Create(r RobotRequest) (RobotCreated, *gorequest.Response, []error) {
+	return s.CreateContext(context.Background(), r)
+}
+
+// CreateContext is like Create but carries ctx to the request.
+func (s *RobotsService) CreateContext(ctx context.Context, r RobotRequest) (RobotCreated, *gorequest.Response, []error) {
+	var v RobotCreated
+	if errs := s.supported(ctx); errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	if r.Level == "" {
+		r.Level = LevelSystem
+	}
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(root)).
+		Send(r)
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
+}
+
+// Update a robot account.
+//
+// This endpoint replaces the description, permissions, duration and status
+// of the specified robot account. It requires Harbor 2.2 or later.
+func (s *RobotsService) Update(id int64, r Robot) (*gorequest.Response, []error) {
+	return s.UpdateContext(context.Background(), id, r)
+}
+
+// UpdateContext is like Update but carries ctx to the request.
+func (s *RobotsService) UpdateContext(ctx context.Context, id int64, r Robot) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	r.ID = id
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(base), id)).
+		Send(r)
+	return s.client.Do(ctx, req, nil)
+}
+
+// Enable a robot account.
+func (s *RobotsService) Enable(id int64) (*gorequest.Response, []error) {
+	return s.EnableContext(context.Background(), id)
+}
+
+// EnableContext is like Enable but carries ctx to the request.
+func (s *RobotsService) EnableContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	return s.setDisabled(ctx, id, false)
+}
+
+// Disable a robot account.
+func (s *RobotsService) Disable(id int64) (*gorequest.Response, []error) {
+	return s.DisableContext(context.Background(), id)
+}
+
+// DisableContext is like Disable but carries ctx to the request.
+func (s *RobotsService) DisableContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	return s.setDisabled(ctx, id, true)
+}
+
+// setDisabled updates the status of a robot account, Harbor only accepting
+// the whole account on update.
+func (s *RobotsService) setDisabled(ctx context.Context, id int64, disabled bool) (*gorequest.Response, []error) {
+	r, resp, errs := s.GetContext(ctx, id)
+	if len(errs) != 0 {
+		return resp, errs
+	}
+	if r.Disable == disabled {
+		return resp, nil
+	}
+	r.Disable = disabled
+	return s.UpdateContext(ctx, id, r)
+}
+
+// Refresh the secret of a robot account.
+//
+// This endpoint sets the secret of the specified robot account, Harbor
+// generating a new one when secret is empty. It requires Harbor 2.2 or
+// later.
+func (s *RobotsService) RefreshSecret(id int64, secret string) (RobotSecret, *gorequest.Response, []error) {
+	return s.RefreshSecretContext(context.Background(), id, secret)
+}
+
+// RefreshSecretContext is like RefreshSecret but carries ctx to the request.
+func (s *RobotsService) RefreshSecretContext(ctx context.Context, id int64, secret string) (RobotSecret, *gorequest.Response, []error) {
+	var v RobotSecret
+	if errs := s.supported(ctx); errs != nil {
+		return v, new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.PATCH, fmt.Sprintf(s.getConfigString(base), id)).
+		Send(RobotSecret{Secret: secret})
+	resp, errs := s.client.Do(ctx, req, &v)
+	if len(errs) == 0 && v.Secret == "" {
+		// Harbor does not echo a secret chosen by the caller
+		v.Secret = secret
+	}
+	return v, resp, errs
+}
+
+// Delete a robot account.
+//
+// This endpoint deletes the specified robot account. It requires Harbor 2.2
+// or later.
+func (s *RobotsService) Delete(id int64) (*gorequest.Response, []error) {
+	return s.DeleteContext(context.Background(), id)
+}
+
+// DeleteContext is like Delete but carries ctx to the request.
+func (s *RobotsService) DeleteContext(ctx context.Context, id int64) (*gorequest.Response, []error) {
+	if errs := s.supported(ctx); errs != nil {
+		return new(gorequest.Response), errs
+	}
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), id))
+	return s.client.Do(ctx, req, nil)
+}
@@ -0,0 +1,164 @@
+package robots
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
+	"github.com/codingXiang/go-harbor-client/internal/fakeharbor"
+)
+
+func newFakeHarbor(t *testing.T, version client2.APIVersion, routes map[string]fakeharbor.Response) (*fakeharbor.Server, Service) {
+	f, c := fakeharbor.Start(t, version, routes)
+	return f, NewRobotsService(c)
+}
+
+func TestProjectRobots(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion1, map[string]fakeharbor.Response{
+		"GET /api/projects/1/robots":      {Body: `[{"id":3,"name":"robot$ci","project_id":1,"disabled":true,"expires_at":1600000000}]`},
+		"GET /api/projects/1/robots/3":    {Body: `{"id":3,"name":"robot$ci","project_id":1}`},
+		"POST /api/projects/1/robots":     {Status: http.StatusCreated, Body: `{"name":"robot$deploy","token":"s3cret"}`, Location: "/api/projects/1/robots/7"},
+		"PUT /api/projects/1/robots/3":    {},
+		"DELETE /api/projects/1/robots/3": {},
+	})
+	list, _, errs := s.ListProjectRobots(1, &ListRobotsOptions{ListOptions: client2.ListOptions{Page: 2}})
+	want := []Robot{{ID: 3, Name: "robot$ci", Level: LevelProject, Disable: true, ExpiresAt: 1600000000, ProjectID: 1}}
+	if len(errs) != 0 || !reflect.DeepEqual(list, want) {
+		t.Fatalf("ListProjectRobots = %+v, %v", list, errs)
+	}
+	if r, _ := f.Request("GET /api/projects/1/robots"); r.Query.Get("page") != "2" {
+		t.Errorf("ListProjectRobots query %v", r.Query)
+	}
+	if r, _, errs := s.GetProjectRobot(1, 3); len(errs) != 0 || r.Level != LevelProject || r.ProjectID != 1 {
+		t.Errorf("GetProjectRobot = %+v, %v", r, errs)
+	}
+
+	access := []Access{{Resource: ProjectResource(1, ResourceRepository), Action: ActionPush}}
+	created, _, errs := s.CreateProjectRobot(1, ProjectRobotRequest{Name: "deploy", ExpiresAt: 1700000000, Access: access})
+	if want := (RobotCreated{ID: 7, Name: "robot$deploy", Secret: "s3cret", ExpiresAt: 1700000000}); len(errs) != 0 || created != want {
+		t.Errorf("CreateProjectRobot = %+v, %v, want %+v", created, errs, want)
+	}
+	var req ProjectRobotRequest
+	r, _ := f.Request("POST /api/projects/1/robots")
+	r.Decode(t, &req)
+	if !reflect.DeepEqual(req.Access, access) || req.Access[0].Resource != "/project/1/repository" {
+		t.Errorf("CreateProjectRobot access %+v", req.Access)
+	}
+
+	for _, disabled := range []bool{true, false} {
+		var errs []error
+		if disabled {
+			_, errs = s.DisableProjectRobot(1, 3)
+		} else {
+			_, errs = s.EnableProjectRobot(1, 3)
+		}
+		var status map[string]interface{}
+		r, _ := f.Request("PUT /api/projects/1/robots/3")
+		r.Decode(t, &status)
+		if len(errs) != 0 || !reflect.DeepEqual(status, map[string]interface{}{"disabled": disabled}) {
+			t.Errorf("disabled %v: sent %v, %v", disabled, status, errs)
+		}
+	}
+	if _, errs := s.DeleteProjectRobot(1, 3); len(errs) != 0 {
+		t.Error(errs)
+	}
+}
+
+func TestSystemRobots(t *testing.T) {
+	robot := `{"id":5,"name":"robot$ci","level":"system","duration":30,"disable":false,` +
+		`"permissions":[{"kind":"project","namespace":"*","access":[{"resource":"repository","action":"pull"}]}]}`
+	f, s := newFakeHarbor(t, client2.APIVersion2, map[string]fakeharbor.Response{
+		"GET /api/v2.0/robots":      {Body: `[` + robot + `]`},
+		"GET /api/v2.0/robots/5":    {Body: robot},
+		"POST /api/v2.0/robots":     {Status: http.StatusCreated, Body: `{"id":5,"name":"robot$ci","secret":"s3cret"}`, Location: "/api/v2.0/robots/7"},
+		"PUT /api/v2.0/robots/5":    {},
+		"PATCH /api/v2.0/robots/5":  {Body: `{}`},
+		"DELETE /api/v2.0/robots/5": {},
+	})
+	list, _, errs := s.List(&ListRobotsOptions{Q: "Level=system"})
+	if len(errs) != 0 || len(list) != 1 || list[0].Permissions[0].Namespace != "*" {
+		t.Fatalf("List = %+v, %v", list, errs)
+	}
+	if r, _ := f.Request("GET /api/v2.0/robots"); r.Query.Get("q") != "Level=system" {
+		t.Errorf("List query %v", r.Query)
+	}
+
+	perm := ProjectPermission("*", Access{Resource: ResourceRepository, Action: ActionPull})
+	created, _, errs := s.Create(RobotRequest{Name: "ci", Duration: DurationNever, Permissions: []Permission{perm}})
+	if len(errs) != 0 || created.Secret != "s3cret" {
+		t.Errorf("Create = %+v, %v", created, errs)
+	}
+	var req RobotRequest
+	r, _ := f.Request("POST /api/v2.0/robots")
+	r.Decode(t, &req)
+	if req.Level != LevelSystem || req.Duration != DurationNever || !reflect.DeepEqual(req.Permissions, []Permission{perm}) {
+		t.Errorf("Create sent %+v", req)
+	}
+
+	// Disabling sends the whole account back, enabling an enabled one
+	// sends nothing
+	if _, errs := s.Disable(5); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	var update Robot
+	r, _ = f.Request("PUT /api/v2.0/robots/5")
+	r.Decode(t, &update)
+	if update.ID != 5 || !update.Disable || update.Duration != 30 || len(update.Permissions) != 1 {
+		t.Errorf("Disable sent %+v", update)
+	}
+	before := len(f.Routes())
+	if _, errs := s.Enable(5); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if routes := f.Routes()[before:]; !reflect.DeepEqual(routes, []string{"GET /api/v2.0/robots/5"}) {
+		t.Errorf("Enable of an enabled robot requested %v", routes)
+	}
+
+	// Harbor does not echo a secret chosen by the caller
+	secret, _, errs := s.RefreshSecret(5, "chosen")
+	if len(errs) != 0 || secret.Secret != "chosen" {
+		t.Errorf("RefreshSecret = %+v, %v", secret, errs)
+	}
+	var sent RobotSecret
+	r, _ = f.Request("PATCH /api/v2.0/robots/5")
+	r.Decode(t, &sent)
+	if sent.Secret != "chosen" {
+		t.Errorf("RefreshSecret sent %+v", sent)
+	}
+	if _, errs := s.Delete(5); len(errs) != 0 {
+		t.Error(errs)
+	}
+}
+
+func TestSystemRobotsNotSupportedOnV1(t *testing.T) {
+	f, s := newFakeHarbor(t, client2.APIVersion1, map[string]fakeharbor.Response{})
+	if _, _, errs := s.List(nil); len(errs) != 1 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("List on 1.x: %v, want ErrNotSupported", errs)
+	}
+	if _, errs := s.Disable(5); len(errs) != 1 || errs[0] != client2.ErrNotSupported {
+		t.Errorf("Disable on 1.x: %v, want ErrNotSupported", errs)
+	}
+	if routes := f.Routes(); len(routes) != 0 {
+		t.Errorf("requests sent to 1.x: %v", routes)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	now := time.Unix(1600000000, 0)
+	for _, tt := range []struct {
+		expiresAt int64
+		want      bool
+	}{
+		{0, false},
+		{-1, false},
+		{now.Unix() + 1, false},
+		{now.Unix(), true},
+		{now.Unix() - 1, true},
+	} {
+		if got := (Robot{ExpiresAt: tt.expiresAt}).Expired(now); got != tt.want {
+			t.Errorf("Expired with expires_at %d = %v, want %v", tt.expiresAt, got, tt.want)
+		}
+	}
+}
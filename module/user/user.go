@@ -1,8 +1,10 @@
 package user
 
 import (
-	client2 "github.com/codingXiang/go-harbor-client/client"
+	"context"
 	"fmt"
+
+	client2 "github.com/codingXiang/go-harbor-client/client"
 	"github.com/parnurzeal/gorequest"
 )
 
@@ -17,13 +19,21 @@ const (
 
 type Service interface {
 	List() ([]User, *gorequest.Response, []error)
+	ListContext(ctx context.Context) ([]User, *gorequest.Response, []error)
 	Get(id int) (User, *gorequest.Response, []error)
+	GetContext(ctx context.Context, id int) (User, *gorequest.Response, []error)
 	Create(user *User) (*gorequest.Response, []error)
+	CreateContext(ctx context.Context, user *User) (*gorequest.Response, []error)
 	Update(id int, user *User) (*gorequest.Response, []error)
+	UpdateContext(ctx context.Context, id int, user *User) (*gorequest.Response, []error)
 	Delete(id int) (*gorequest.Response, []error)
+	DeleteContext(ctx context.Context, id int) (*gorequest.Response, []error)
 	Current() (User, *gorequest.Response, []error)
+	CurrentContext(ctx context.Context) (User, *gorequest.Response, []error)
 	ChangeSysadmin(id int, role UpdateRole) (*gorequest.Response, []error)
+	ChangeSysadminContext(ctx context.Context, id int, role UpdateRole) (*gorequest.Response, []error)
 	ChangePassword(id int, password UpdatePassword) (*gorequest.Response, []error)
+	ChangePasswordContext(ctx context.Context, id int, password UpdatePassword) (*gorequest.Response, []error)
 }
 
 type UserService struct {
@@ -39,61 +49,83 @@ func (s *UserService) getConfigString(key string) string {
 }
 
 func (s *UserService) List() ([]User, *gorequest.Response, []error) {
+	return s.ListContext(context.Background())
+}
+
+func (s *UserService) ListContext(ctx context.Context) ([]User, *gorequest.Response, []error) {
 	var v []User
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, s.getConfigString(root)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(root))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 func (s *UserService) Get(id int) (User, *gorequest.Response, []error) {
+	return s.GetContext(context.Background(), id)
+}
+
+func (s *UserService) GetContext(ctx context.Context, id int) (User, *gorequest.Response, []error) {
 	var v User
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), id)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, fmt.Sprintf(s.getConfigString(base), id))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 func (s *UserService) Create(user *User) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, s.getConfigString(root)).
-		Send(*user).
-		End()
-	return &resp, errs
+	return s.CreateContext(context.Background(), user)
+}
+
+func (s *UserService) CreateContext(ctx context.Context, user *User) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, s.getConfigString(root)).
+		Send(*user)
+	return s.client.Do(ctx, req, nil)
 }
+
 func (s *UserService) Update(id int, user *User) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(base), id)).
-		Send(*user).
-		End()
-	return &resp, errs
+	return s.UpdateContext(context.Background(), id, user)
+}
+
+func (s *UserService) UpdateContext(ctx context.Context, id int, user *User) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.POST, fmt.Sprintf(s.getConfigString(base), id)).
+		Send(*user)
+	return s.client.Do(ctx, req, nil)
 }
+
 func (s *UserService) Delete(id int) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), id)).
-		End()
-	return &resp, errs
+	return s.DeleteContext(context.Background(), id)
+}
+
+func (s *UserService) DeleteContext(ctx context.Context, id int) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.DELETE, fmt.Sprintf(s.getConfigString(base), id))
+	return s.client.Do(ctx, req, nil)
 }
+
 func (s *UserService) Current() (User, *gorequest.Response, []error) {
+	return s.CurrentContext(context.Background())
+}
+
+func (s *UserService) CurrentContext(ctx context.Context) (User, *gorequest.Response, []error) {
 	var v User
-	resp, _, errs := s.client.
-		NewRequest(gorequest.GET, s.getConfigString(current)).
-		EndStruct(&v)
-	return v, &resp, errs
+	req := s.client.NewRequest(gorequest.GET, s.getConfigString(current))
+	resp, errs := s.client.Do(ctx, req, &v)
+	return v, resp, errs
 }
 
 func (s *UserService) ChangePassword(id int, password UpdatePassword) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(pwd), id)).
-		Send(password).
-		End()
-	return &resp, errs
+	return s.ChangePasswordContext(context.Background(), id, password)
+}
+
+func (s *UserService) ChangePasswordContext(ctx context.Context, id int, password UpdatePassword) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(pwd), id)).
+		Send(password)
+	return s.client.Do(ctx, req, nil)
 }
 
 func (s *UserService) ChangeSysadmin(id int, role UpdateRole) (*gorequest.Response, []error) {
-	resp, _, errs := s.client.
-		NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(sysadmin), id)).
-		Send(role).
-		End()
-	return &resp, errs
+	return s.ChangeSysadminContext(context.Background(), id, role)
+}
+
+func (s *UserService) ChangeSysadminContext(ctx context.Context, id int, role UpdateRole) (*gorequest.Response, []error) {
+	req := s.client.NewRequest(gorequest.PUT, fmt.Sprintf(s.getConfigString(sysadmin), id)).
+		Send(role)
+	return s.client.Do(ctx, req, nil)
 }
@@ -0,0 +1,56 @@
+package user_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/codingXiang/go-harbor-client/harbortest"
+	"github.com/codingXiang/go-harbor-client/module/user"
+)
+
+func TestContextVariants(t *testing.T) {
+	srv := harbortest.Start(t)
+	dev := srv.AddUser("dev", "Passw0rd", false)
+	s := user.NewUserService(srv.Client())
+	ctx := context.Background()
+
+	u, _, errs := s.GetContext(ctx, dev.UserID)
+	if len(errs) != 0 || u.Username != "dev" {
+		t.Fatalf("GetContext = %+v, %v", u, errs)
+	}
+	update := user.User{Email: "dev@example.com", Realname: "Dev", Comment: "updated"}
+	if _, errs := s.UpdateContext(ctx, dev.UserID, &update); len(errs) != 0 {
+		t.Fatal(errs)
+	}
+	if u, _, _ := s.Get(dev.UserID); u.Comment != "updated" {
+		t.Errorf("Get after UpdateContext = %+v", u)
+	}
+	current, _, errs := s.CurrentContext(ctx)
+	if len(errs) != 0 || current.Username != harbortest.AdminUsername {
+		t.Errorf("CurrentContext = %+v, %v", current, errs)
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	srv := harbortest.Start(t)
+	s := user.NewUserService(srv.Client())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, _, errs := s.ListContext(ctx); len(errs) == 0 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("ListContext with a cancelled context: %v, want context.Canceled", errs)
+	}
+
+	srv.Inject(harbortest.Fault{Latency: time.Second})
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, _, errs := s.CurrentContext(ctx); len(errs) == 0 || !errors.Is(errs[0], context.DeadlineExceeded) {
+		t.Errorf("CurrentContext past its deadline: %v, want context.DeadlineExceeded", errs)
+	}
+	if elapsed := time.Since(start); elapsed >= time.Second {
+		t.Errorf("CurrentContext returned after %s, not at its deadline", elapsed)
+	}
+}